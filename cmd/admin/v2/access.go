@@ -0,0 +1,272 @@
+package v2
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/fatih/color"
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/pkg/helpers"
+	helpersaudit "github.com/metal-stack/cli/pkg/helpers/audit"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const accessHistoryFile = "access-history.jsonl"
+
+// privilegedMethods are the api methods which grant privileged access and therefore require a reason.
+var privilegedMethods = []string{
+	adminv2connect.MachineServiceConsolePasswordProcedure,
+	adminv2connect.VPNServiceAuthKeyProcedure,
+}
+
+type access struct {
+	c *config.Config
+}
+
+func newAccessCmd(c *config.Config) *cobra.Command {
+	w := &access{
+		c: c,
+	}
+
+	accessCmd := &cobra.Command{
+		Use:   "access",
+		Short: "review privileged access",
+		Long:  "privileged access like fetching console passwords or connecting to firewalls requires a reason, which is sent to the api and recorded locally.",
+	}
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "shows the privileged access of a user correlated with the audit traces of the api",
+		Long: `shows the privileged access of a user from the audit traces of the api.
+
+accesses performed from this machine are recorded locally along with the reason, the context, the user and the session transcript. the records of the current context and the given user are matched with the audit traces by method and time.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.history()
+		},
+	}
+	historyCmd.Flags().String("from", "24h", "start of range of the access history. e.g. 1h, 10m, 2006-01-02 15:04:05")
+	historyCmd.Flags().String("to", "", "end of range of the access history. e.g. 1h, 10m, 2006-01-02 15:04:05")
+	historyCmd.Flags().String("user", "", "user of the access history, defaults to the current user")
+	historyCmd.Flags().String("method", "", "only shows the access history of the given api method")
+	historyCmd.Flags().Duration("tolerance", time.Minute, "the maximum time difference between a local record and an audit trace to be correlated")
+	genericcli.Must(historyCmd.RegisterFlagCompletionFunc("method", cobra.FixedCompletions(privilegedMethods, cobra.ShellCompDirectiveNoFileComp)))
+
+	accessCmd.AddCommand(historyCmd)
+
+	return accessCmd
+}
+
+// addReasonFlags adds the flags for giving a reason for privileged access to a command.
+func addReasonFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().String("reason", "", usage)
+	cmd.Flags().String("ticket", "", "the id of the ticket which requires the access, it is prepended to the reason")
+}
+
+// accessReason returns the reason given for a privileged access, validated against the reason pattern of the current context.
+func accessReason(c *config.Config) (string, error) {
+	return helpers.AccessReason(viper.GetString("reason"), viper.GetString("ticket"), c.Context.ReasonPattern)
+}
+
+func accessHistoryPath() (string, error) {
+	configPath, err := config.ConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(path.Dir(configPath), accessHistoryFile), nil
+}
+
+// accessRecorder records privileged access to the local access history of the current context and user.
+type accessRecorder struct {
+	c    *config.Config
+	user string
+	file afero.File
+}
+
+// openAccessRecorder resolves the current user and opens the local access history. it is called before the privileged
+// access is performed, such that the access is not performed at all if it cannot be recorded.
+func openAccessRecorder(c *config.Config) (*accessRecorder, error) {
+	historyPath, err := accessHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.NewRequestContext()
+	defer cancel()
+
+	resp, err := c.Client.Apiv2().User().Get(ctx, &apiv2.UserServiceGetRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to record access, current user cannot be determined: %w", err)
+	}
+
+	err = c.Fs.MkdirAll(path.Dir(historyPath), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("unable to record access: %w", err)
+	}
+
+	f, err := c.Fs.OpenFile(historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to record access: %w", err)
+	}
+
+	return &accessRecorder{
+		c:    c,
+		user: resp.User.Login,
+		file: f,
+	}, nil
+}
+
+// record appends the given privileged access to the local access history, completed with the current context and user.
+// as the access was already performed, a failure to record it only results in a warning.
+func (r *accessRecorder) record(record *helpers.AccessRecord) {
+	record.Context = r.c.Context.Name
+	record.User = r.user
+
+	line, err := json.Marshal(record)
+	if err == nil {
+		_, err = fmt.Fprintf(r.file, "%s\n", line)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(r.c.PromptOut, "%s unable to record access in the local access history: %s\n", color.YellowString("⚠"), err)
+	}
+}
+
+func (r *accessRecorder) Close() error {
+	return r.file.Close()
+}
+
+func (a *access) history() error {
+	ctx, cancel := a.c.NewRequestContext()
+	defer cancel()
+
+	from, err := helpersaudit.RelativeDateTime(viper.GetString("from"))
+	if err != nil {
+		return err
+	}
+	to, err := helpersaudit.RelativeDateTime(viper.GetString("to"))
+	if err != nil {
+		return err
+	}
+
+	user := viper.GetString("user")
+	if user == "" {
+		resp, err := a.c.Client.Apiv2().User().Get(ctx, &apiv2.UserServiceGetRequest{})
+		if err != nil {
+			return fmt.Errorf("unable to determine current user: %w", err)
+		}
+
+		user = resp.User.Login
+	}
+
+	methods := privilegedMethods
+	if method := viper.GetString("method"); method != "" {
+		methods = []string{method}
+	}
+
+	var (
+		now    = time.Now()
+		traces []*apiv2.AuditTrace
+	)
+	for _, method := range methods {
+		methodTraces, err := helpersaudit.ListAll(&apiv2.AuditQuery{
+			From:   from,
+			To:     to,
+			User:   &user,
+			Method: &method,
+			Phase:  new(apiv2.AuditPhase_AUDIT_PHASE_REQUEST),
+		}, now, func(query *apiv2.AuditQuery) ([]*apiv2.AuditTrace, error) {
+			ctx, cancel := a.c.NewRequestContext()
+			defer cancel()
+
+			resp, err := a.c.Client.Adminv2().Audit().List(ctx, &adminv2.AuditServiceListRequest{
+				Query: query,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list audit traces: %w", err)
+			}
+
+			return resp.Traces, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		traces = append(traces, methodTraces...)
+	}
+
+	records, err := a.records(user, methods, from, to)
+	if err != nil {
+		return err
+	}
+
+	return a.c.ListPrinter.Print(helpers.CorrelateAccessHistory(records, traces, viper.GetDuration("tolerance")))
+}
+
+// records returns the locally recorded accesses of the given user and methods within the given time range,
+// which were performed with the current context.
+func (a *access) records(user string, methods []string, from, to *timestamppb.Timestamp) ([]*helpers.AccessRecord, error) {
+	historyPath, err := accessHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := a.c.Fs.Open(historyPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read access history: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var (
+		records []*helpers.AccessRecord
+		scanner = bufio.NewScanner(f)
+	)
+
+	for scanner.Scan() {
+		var record helpers.AccessRecord
+
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read access history: %w", err)
+		}
+
+		if record.Context != a.c.Context.Name || record.User != user {
+			continue
+		}
+		if !slices.Contains(methods, record.Method) {
+			continue
+		}
+		if from != nil && record.Time.Before(from.AsTime()) {
+			continue
+		}
+		if to != nil && record.Time.After(to.AsTime()) {
+			continue
+		}
+
+		records = append(records, &record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read access history: %w", err)
+	}
+
+	return records, nil
+}
@@ -6,6 +6,7 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	helpersaudit "github.com/metal-stack/cli/pkg/helpers/audit"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -73,7 +74,7 @@ func newAuditCmd(c *config.Config) *cobra.Command {
 		},
 	}
 
-	return genericcli.NewCmds(cmdsConfig)
+	return filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig))
 }
 
 func (a *adminAudit) Get(id string) (*apiv2.AuditTrace, error) {
@@ -14,8 +14,11 @@ func AddCmds(cmd *cobra.Command, c *config.Config) {
 		Hidden:       true,
 	}
 
+	adminCmd.AddCommand(newAccessCmd(c))
 	adminCmd.AddCommand(newAuditCmd(c))
 	adminCmd.AddCommand(newComponentCmd(c))
+	adminCmd.AddCommand(newDropCmd(c))
+	adminCmd.AddCommand(newElevateCmd(c))
 	adminCmd.AddCommand(newImageCmd(c))
 	adminCmd.AddCommand(newIPCmd(c))
 	adminCmd.AddCommand(newMachineCmd(c))
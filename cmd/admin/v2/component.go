@@ -8,6 +8,8 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
+	"github.com/metal-stack/cli/cmd/watch"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
@@ -53,7 +55,7 @@ func newComponentCmd(c *config.Config) *cobra.Command {
 	pruneCmd.Flags().String("type", "", "prune only component of this type")
 	genericcli.Must(pruneCmd.RegisterFlagCompletionFunc("type", c.Completion.ComponentTypes))
 
-	return genericcli.NewCmds(cmdsConfig, pruneCmd)
+	return watch.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, pruneCmd)))
 }
 
 func (c *component) Get(id string) (*apiv2.Component, error) {
@@ -0,0 +1,202 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/fatih/color"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type elevation struct {
+	c *config.Config
+}
+
+func newElevateCmd(c *config.Config) *cobra.Command {
+	w := &elevation{
+		c: c,
+	}
+
+	elevateCmd := &cobra.Command{
+		Use:   "elevate",
+		Short: "issues a short-lived admin token which is used for admin commands",
+		Long: `issues a short-lived admin token with the given admin role and stores it next to the token of the current context.
+
+the admin token is only used for admin commands, all other commands keep using the token of the context.
+when the admin token expires or the elevation is dropped with admin drop, admin commands fall back to the token of the context.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.elevate()
+		},
+	}
+
+	elevateCmd.Flags().String("admin-role", "", "the admin role of the issued admin token")
+	elevateCmd.Flags().Duration("duration", time.Hour, "the duration after which the admin token expires")
+	addReasonFlags(elevateCmd, "the reason for the elevation, it is put into the description of the admin token for auditing")
+
+	genericcli.Must(elevateCmd.MarkFlagRequired("admin-role"))
+	genericcli.Must(elevateCmd.RegisterFlagCompletionFunc("admin-role", c.Completion.TokenAdminRole))
+
+	return elevateCmd
+}
+
+func newDropCmd(c *config.Config) *cobra.Command {
+	w := &elevation{
+		c: c,
+	}
+
+	return &cobra.Command{
+		Use:   "drop",
+		Short: "revokes the admin token of the current context, admin commands use the token of the context again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.drop()
+		},
+	}
+}
+
+func (e *elevation) elevate() error {
+	role, err := helpers.ToAdminRole(viper.GetString("admin-role"))
+	if err != nil {
+		return err
+	}
+
+	duration := viper.GetDuration("duration")
+	if duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+
+	reason, err := accessReason(e.c)
+	if err != nil {
+		return err
+	}
+
+	ctxs, ctx, err := e.currentContext()
+	if err != nil {
+		return err
+	}
+
+	// an existing elevation is replaced, its admin token must not stay valid
+	err = e.dropAdminToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	reqCtx, cancel := e.c.NewRequestContext()
+	defer cancel()
+
+	resp, err := e.c.Client.Apiv2().Token().Create(reqCtx, &apiv2.TokenServiceCreateRequest{
+		Description: fmt.Sprintf("admin elevation issued by metal cli: %s", reason),
+		Expires:     durationpb.New(duration),
+		AdminRole:   role,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to issue admin token: %w", err)
+	}
+
+	expiry := time.Now().Add(duration)
+	if resp.Token != nil && resp.Token.Expires != nil {
+		expiry = resp.Token.Expires.AsTime()
+	}
+
+	ctx.AdminToken = resp.Secret
+	ctx.AdminTokenExpiry = &expiry
+
+	err = e.c.WriteContexts(ctxs)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(e.c.Out, "%s elevated context \"%s\" to %s until %s\n", color.GreenString("✔"), color.GreenString(ctx.Name), role.String(), expiry.Format(time.RFC3339))
+
+	return nil
+}
+
+func (e *elevation) drop() error {
+	ctxs, ctx, err := e.currentContext()
+	if err != nil {
+		return err
+	}
+
+	if ctx.AdminTokenExpiry == nil && ctx.AdminToken == "" {
+		_, _ = fmt.Fprintf(e.c.Out, "context \"%s\" is not elevated\n", ctx.Name)
+		return nil
+	}
+
+	err = e.dropAdminToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = e.c.WriteContexts(ctxs)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(e.c.Out, "%s dropped admin elevation of context \"%s\"\n", color.GreenString("✔"), color.GreenString(ctx.Name))
+
+	return nil
+}
+
+func (e *elevation) currentContext() (*config.Contexts, *config.Context, error) {
+	if viper.GetString("api-token") != "" {
+		return nil, nil, errors.New("admin elevation is stored in a context and can not be used with --api-token")
+	}
+
+	ctxs, err := e.c.GetContexts()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, ok := ctxs.Get(e.c.Context.Name)
+	if !ok {
+		return nil, nil, fmt.Errorf("admin elevation requires a context, please login first")
+	}
+
+	return ctxs, ctx, nil
+}
+
+// dropAdminToken revokes the admin token of the context unless it has already expired and removes it from the context.
+func (e *elevation) dropAdminToken(ctx *config.Context) error {
+	if ctx.Elevated(time.Now()) {
+		err := ctx.ResolveAdminToken()
+		if err != nil {
+			return err
+		}
+
+		id, err := config.TokenID(ctx.AdminToken)
+		if err != nil {
+			return fmt.Errorf("unable to determine id of admin token: %w", err)
+		}
+
+		reqCtx, cancel := e.c.NewRequestContext()
+		defer cancel()
+
+		_, err = e.c.Client.Apiv2().Token().Revoke(reqCtx, &apiv2.TokenServiceRevokeRequest{Uuid: id})
+		if err != nil && connect.CodeOf(err) != connect.CodeNotFound {
+			return fmt.Errorf("unable to revoke admin token: %w", err)
+		}
+	}
+
+	store, err := ctx.CredentialStore()
+	if err != nil {
+		return err
+	}
+	if store != nil && ctx.AdminTokenExpiry != nil {
+		err = store.Erase(ctx.AdminCredentialKey())
+		if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+			return fmt.Errorf("unable to remove admin token of context %q from credential store: %w", ctx.Name, err)
+		}
+	}
+
+	ctx.AdminToken = ""
+	ctx.AdminTokenExpiry = nil
+
+	return nil
+}
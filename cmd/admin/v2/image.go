@@ -8,6 +8,8 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
@@ -93,7 +95,7 @@ func newImageCmd(c *config.Config) *cobra.Command {
 
 	queryFlags(usageCmd)
 
-	return genericcli.NewCmds(cmdsConfig, usageCmd)
+	return dryrun.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, usageCmd)))
 }
 
 func (c *image) Get(id string) (*apiv2.Image, error) {
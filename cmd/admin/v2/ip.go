@@ -6,7 +6,9 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
+	"github.com/metal-stack/cli/cmd/watch"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
@@ -58,7 +60,7 @@ func newIPCmd(c *config.Config) *cobra.Command {
 		},
 	}
 
-	return genericcli.NewCmds(cmdsConfig)
+	return watch.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig)))
 }
 
 func (c *ip) Create(_ any) (*apiv2.IP, error) {
@@ -3,18 +3,25 @@ package v2
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/netip"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/metal-stack/api/go/errorutil"
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
+	"github.com/metal-stack/cli/cmd/terminal"
+	"github.com/metal-stack/cli/cmd/watch"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
@@ -112,6 +119,20 @@ Once created the machine installation can not be modified anymore.
 		},
 		DeleteCmdMutateFn: func(cmd *cobra.Command) {
 			cmd.Short = "Delete a machine from the database. This can only be done if the machine is offline and dead."
+
+			w.addBulkFlags(cmd)
+
+			run := cmd.RunE
+			cmd.RunE = func(cmd *cobra.Command, args []string) error {
+				if viper.IsSet("file") || !w.isBulk(args) {
+					return run(cmd, args)
+				}
+
+				return w.bulk(args, "delete", func(id string) error {
+					_, err := w.Delete(id)
+					return err
+				})
+			}
 		},
 		UpdateCmdMutateFn: func(cmd *cobra.Command) {
 			cmd.Flags().StringP("project", "p", "", "project from where machines should be listed")
@@ -142,13 +163,16 @@ If ~/.ssh/[id_ed25519.pub | id_rsa.pub | id_dsa.pub] is present it will be picke
 		Use:   "command",
 		Short: "send a command to the bmc of a machine",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return w.bmcCommand(args)
+			return w.bmcCommand(cmd.Context(), args)
 		},
 		ValidArgsFunction: c.Completion.AdminMachine,
 	}
 	bmcCommandCmd.Flags().String("command", "", "the actual command to send to the machine")
 	genericcli.Must(bmcCommandCmd.RegisterFlagCompletionFunc("command", c.Completion.BMCCommands))
 	genericcli.Must(bmcCommandCmd.MarkFlagRequired("command"))
+	bmcCommandCmd.Flags().Bool("wait", false, "waits until the machine reached the power state requested by the command, supported for power on, off, cycle and reset")
+	addWaitFlags(bmcCommandCmd, "machine", 10*time.Minute)
+	w.addBulkFlags(bmcCommandCmd)
 
 	bmcDescribeCmd := &cobra.Command{
 		Use:     "describe",
@@ -183,6 +207,7 @@ If ~/.ssh/[id_ed25519.pub | id_rsa.pub | id_dsa.pub] is present it will be picke
 	}
 	lockCmd.Flags().String("description", "", "description of why the machine was locked")
 	lockCmd.Flags().Bool("remove", false, "if set to true, machine will be unlocked")
+	w.addBulkFlags(lockCmd)
 
 	taintCmd := &cobra.Command{
 		Use:   "taint",
@@ -194,6 +219,46 @@ If ~/.ssh/[id_ed25519.pub | id_rsa.pub | id_dsa.pub] is present it will be picke
 	}
 	taintCmd.Flags().String("description", "", "description of why the machine was tainted")
 	taintCmd.Flags().Bool("remove", false, "if set to true, machine will be untainted")
+	w.addBulkFlags(taintCmd)
+
+	eventsCmd := &cobra.Command{
+		Use:     "events <id>",
+		Aliases: []string{"logs"},
+		Short:   "shows the provisioning events of a machine as a timeline",
+		Long:    "shows the provisioning events of a machine as a timeline\n" + machineEventsHelpText(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.events(cmd.Context(), args)
+		},
+		ValidArgsFunction: c.Completion.AdminMachine,
+	}
+	eventsCmd.Flags().BoolP("follow", "f", false, "follows the provisioning events, new events are printed as they occur")
+	eventsCmd.Flags().Duration("interval", 2*time.Second, "the polling interval used when following")
+	eventsCmd.Flags().Duration("last-event-error-threshold", 1*time.Hour, "the duration up to how long in the past a machine last event error will be highlighted")
+
+	issuesCmd := &cobra.Command{
+		Use:   "issues",
+		Short: "lists machines with issues, e.g. machines which are dead or not properly connected to the switches",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.issues()
+		},
+	}
+	issuesCmd.Flags().StringSlice("only", nil, "only evaluates the given issue types")
+	issuesCmd.Flags().StringSlice("omit", nil, "omits the given issue types from evaluation")
+	issuesCmd.Flags().Duration("last-event-error-threshold", 1*time.Hour, "the duration up to how long in the past a machine last event error will be reported as an issue")
+	helpers.AddMachineQueryFlags(issuesCmd, c.Completion)
+	genericcli.Must(issuesCmd.RegisterFlagCompletionFunc("only", cobra.FixedCompletions(helpers.AllIssueTypes(), cobra.ShellCompDirectiveNoFileComp)))
+	genericcli.Must(issuesCmd.RegisterFlagCompletionFunc("omit", cobra.FixedCompletions(helpers.AllIssueTypes(), cobra.ShellCompDirectiveNoFileComp)))
+
+	reprovisionCmd := &cobra.Command{
+		Use:   "reprovision <id>",
+		Short: "power cycles a machine into PXE boot and streams the provisioning events until it is waiting or phoned home again",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.reprovision(cmd.Context(), args)
+		},
+		ValidArgsFunction: c.Completion.AdminMachine,
+	}
+	reprovisionCmd.Flags().Bool("skip-security-prompts", false, "skips the confirmation prompt before power cycling the machine")
+	addWaitFlags(reprovisionCmd, "machine", 20*time.Minute)
 
 	consoleCmd := &cobra.Command{
 		Use:   "console",
@@ -203,8 +268,9 @@ If ~/.ssh/[id_ed25519.pub | id_rsa.pub | id_dsa.pub] is present it will be picke
 		},
 		ValidArgsFunction: c.Completion.AdminMachine,
 	}
-	consoleCmd.Flags().Bool("ipmi", false, "if set to true, the serial console will be opened using ipmitool (requires ipmitool to be present)")
+	consoleCmd.Flags().Bool("ipmi", false, "if set to true, the serial console will be opened using ipmitool, serial over lan is not supported natively so ipmitool must be installed")
 	consoleCmd.Flags().Int("metal-console-port", 5222, "port open on our control-plane to connect via ssh to get machine console access")
+	addSessionFlags(consoleCmd)
 
 	consolePasswordCmd := &cobra.Command{
 		Use:   "consolepassword",
@@ -214,7 +280,7 @@ If ~/.ssh/[id_ed25519.pub | id_rsa.pub | id_dsa.pub] is present it will be picke
 		},
 		ValidArgsFunction: c.Completion.AdminMachine,
 	}
-	consolePasswordCmd.Flags().String("reason", "", "a short description why access to the consolepassword is required")
+	addReasonFlags(consolePasswordCmd, "a short description why access to the consolepassword is required")
 
 	firewallSSHCmd := &cobra.Command{
 		Use:   "ssh <firewall ID>",
@@ -226,9 +292,10 @@ If ~/.ssh/[id_ed25519.pub | id_rsa.pub | id_dsa.pub] is present it will be picke
 		ValidArgsFunction: c.Completion.Firewall,
 	}
 	firewallSSHCmd.Flags().StringP("identity", "i", "~/.ssh/id_rsa", "specify identity file to SSH to the firewall like: -i path/to/id_rsa")
-	firewallSSHCmd.Flags().String("reason", "", "the reason why to connect to the firewall through SSH")
+	addReasonFlags(firewallSSHCmd, "the reason why to connect to the firewall through SSH")
+	addSessionFlags(firewallSSHCmd)
 
-	return genericcli.NewCmds(cmdsConfig, bmcCmd, lockCmd, taintCmd, consoleCmd, consolePasswordCmd, firewallSSHCmd)
+	return dryrun.Enable(c, cmdsConfig, watch.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, bmcCmd, lockCmd, taintCmd, eventsCmd, issuesCmd, reprovisionCmd, consoleCmd, consolePasswordCmd, firewallSSHCmd))))
 }
 
 func (c *machine) Create(rq *apiv2.MachineServiceCreateRequest) (*apiv2.Machine, error) {
@@ -327,39 +394,48 @@ func (c *machine) Convert(r *apiv2.Machine) (string, *apiv2.MachineServiceCreate
 }
 
 func (c *machine) lockOrTaint(args []string, state apiv2.MachineState) error {
-	id, err := genericcli.GetExactlyOneArg(args)
+	operation := "lock"
+	if state == apiv2.MachineState_MACHINE_STATE_TAINTED {
+		operation = "taint"
+	}
+
+	if viper.GetBool("remove") {
+		state = apiv2.MachineState_MACHINE_STATE_AVAILABLE
+		operation = "un" + operation
+	}
+
+	if c.isBulk(args) {
+		return c.bulk(args, operation, func(id string) error {
+			_, err := c.setState(id, state)
+			return err
+		})
+	}
+
+	m, err := c.setState(args[0], state)
 	if err != nil {
 		return err
 	}
 
+	return c.c.ListPrinter.Print(m)
+}
+
+func (c *machine) setState(id string, state apiv2.MachineState) (*apiv2.Machine, error) {
 	ctx, cancel := c.c.NewRequestContext()
 	defer cancel()
 
-	if viper.GetBool("remove") {
-		state = apiv2.MachineState_MACHINE_STATE_AVAILABLE
-	}
-
 	resp, err := c.c.Client.Adminv2().Machine().SetState(ctx, &adminv2.MachineServiceSetStateRequest{
 		Uuid:        id,
 		Description: viper.GetString("description"),
 		State:       state,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return c.c.ListPrinter.Print(resp.Machine)
+	return resp.Machine, nil
 }
 
-func (c *machine) bmcCommand(args []string) error {
-	id, err := genericcli.GetExactlyOneArg(args)
-	if err != nil {
-		return err
-	}
-
-	ctx, cancel := c.c.NewRequestContext()
-	defer cancel()
-
+func (c *machine) bmcCommand(ctx context.Context, args []string) error {
 	commandString := viper.GetString("command")
 
 	cmd, ok := apiv2.MachineBMCCommand_value[commandString]
@@ -367,9 +443,43 @@ func (c *machine) bmcCommand(args []string) error {
 		return fmt.Errorf("unknown bmc command: %s", commandString)
 	}
 
-	_, err = c.c.Client.Adminv2().Machine().BMCCommand(ctx, &adminv2.MachineServiceBMCCommandRequest{
+	command := apiv2.MachineBMCCommand(cmd)
+
+	if viper.GetBool("wait") {
+		if err := bmcCommandWaitable(command); err != nil {
+			return err
+		}
+	}
+
+	run := func(id string) error {
+		since := time.Now()
+
+		err := c.sendBMCCommand(id, command)
+		if err != nil {
+			return err
+		}
+
+		if !viper.GetBool("wait") {
+			return nil
+		}
+
+		return c.waitForBMCCommand(ctx, id, command, since)
+	}
+
+	if c.isBulk(args) {
+		return c.bulk(args, commandString, run)
+	}
+
+	return run(args[0])
+}
+
+func (c *machine) sendBMCCommand(id string, command apiv2.MachineBMCCommand) error {
+	ctx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	_, err := c.c.Client.Adminv2().Machine().BMCCommand(ctx, &adminv2.MachineServiceBMCCommandRequest{
 		Uuid:    id,
-		Command: apiv2.MachineBMCCommand(cmd),
+		Command: command,
 	})
 	if err != nil {
 		return err
@@ -423,15 +533,36 @@ func (c *machine) consolePassword(ctx context.Context, args []string) error {
 	if err != nil {
 		return err
 	}
+	reason, err := accessReason(c.c)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := openAccessRecorder(c.c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = recorder.Close()
+	}()
+
 	req := &adminv2.MachineServiceConsolePasswordRequest{
 		Uuid:   id,
-		Reason: viper.GetString("reason"),
+		Reason: reason,
 	}
 
 	resp, err := c.c.Client.Adminv2().Machine().ConsolePassword(ctx, req)
 	if err != nil {
 		return err
 	}
+
+	recorder.record(&helpers.AccessRecord{
+		Time:   time.Now(),
+		Method: adminv2connect.MachineServiceConsolePasswordProcedure,
+		Target: id,
+		Reason: reason,
+	})
+
 	_, err = fmt.Fprintln(c.c.Out, resp.Password)
 	return err
 }
@@ -452,7 +583,12 @@ func (c *machine) console(ctx context.Context, args []string) error {
 		return err
 	}
 
-	err = sshClient(id, viper.GetString("sshidentity"), parsedurl.Host, viper.GetInt("metal-console-port"), &c.c.Context.Token, true)
+	session, err := sshSession(c.c.Out, id, viper.GetString("sshidentity"), parsedurl.Host, viper.GetInt("metal-console-port"), &c.c.Context.Token, true)
+	if err != nil {
+		return fmt.Errorf("machine console error:%w", err)
+	}
+
+	err = attachSession(ctx, c.c, transcriptFile("console-"+id), session)
 	if err != nil {
 		return fmt.Errorf("machine console error:%w", err)
 	}
@@ -461,9 +597,10 @@ func (c *machine) console(ctx context.Context, args []string) error {
 }
 
 func (c *machine) impitool(ctx context.Context, id string) error {
+	// serial over lan is not implemented natively, so the external ipmitool binary is still required for this
 	path, err := exec.LookPath("ipmitool")
 	if err != nil {
-		return fmt.Errorf("unable to locate ipmitool in path")
+		return fmt.Errorf("the console with --ipmi requires ipmitool to be installed, use the console without --ipmi otherwise: %w", err)
 	}
 
 	resp, err := c.c.Client.Adminv2().Machine().GetBMC(context.Background(), &adminv2.MachineServiceGetBMCRequest{
@@ -503,22 +640,10 @@ func (c *machine) impitool(ctx context.Context, id string) error {
 		password = bmcpassword
 	}
 
-	err = os.Setenv("IPMITOOL_PASSWORD", password)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = os.Unsetenv("IPMITOOL_PASSWORD")
-	}()
-
 	args := []string{"-I", intf, "-H", hostAndPort[0], "-p", hostAndPort[1], "-U", usr, "-E", "sol", "activate"}
 	_, _ = fmt.Fprintf(c.c.Out, "connecting to console with:\n%s %s\nExit with ~.\n\n", path, strings.Join(args, " "))
-	cmd := exec.CommandContext(ctx, path, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stdout
 
-	return cmd.Run()
+	return attachSession(ctx, c.c, transcriptFile("console-"+id), terminal.NewCommandSession(ctx, []string{"IPMITOOL_PASSWORD=" + password}, path, args...))
 }
 
 func (c *machine) firewallSSH(ctx context.Context, args []string) (err error) {
@@ -527,6 +652,11 @@ func (c *machine) firewallSSH(ctx context.Context, args []string) (err error) {
 		return err
 	}
 
+	reason, err := accessReason(c.c)
+	if err != nil {
+		return err
+	}
+
 	machine, err := c.Get(id)
 	if err != nil {
 		return fmt.Errorf("failed to find firewall: %w", err)
@@ -540,17 +670,35 @@ func (c *machine) firewallSSH(ctx context.Context, args []string) (err error) {
 		return fmt.Errorf("ssh can only be used for connecting to firewalls")
 	}
 
+	recorder, err := openAccessRecorder(c.c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = recorder.Close()
+	}()
+
 	projectID := machine.Allocation.Project
 	_, _ = fmt.Fprintf(c.c.Out, "accessing firewall through vpn ")
 	authKeyResp, err := c.c.Client.Adminv2().VPN().AuthKey(ctx, &adminv2.VPNServiceAuthKeyRequest{
 		Project:   projectID,
 		Ephemeral: true,
-		Reason:    viper.GetString("reason"),
+		Reason:    reason,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to get VPN auth key: %w", err)
 	}
 
+	transcript := transcriptFile("ssh-" + machine.Uuid)
+
+	recorder.record(&helpers.AccessRecord{
+		Time:       time.Now(),
+		Method:     adminv2connect.VPNServiceAuthKeyProcedure,
+		Target:     projectID,
+		Reason:     reason,
+		Transcript: transcript,
+	})
+
 	var vpnopts = []metalvpn.ConnectOpt{}
 
 	if machine.Allocation.Vpn != nil {
@@ -590,12 +738,13 @@ func (c *machine) firewallSSH(ctx context.Context, args []string) (err error) {
 	if err != nil {
 		return err
 	}
-	return s.Connect(nil)
+
+	return attachSession(ctx, c.c, transcript, terminal.NewSSHSession(s.Client, nil))
 }
 
-// sshClient opens an interactive ssh session to the host on port with user, authenticated by the key.
-func sshClient(user, keyfile, host string, port int, idToken *string, passwordAuth bool) error {
-	var opts []metalssh.ConnectOpt
+// sshSession returns an interactive ssh session to the host on port with user, authenticated by the key or the token as password.
+func sshSession(out io.Writer, user, keyfile, host string, port int, idToken *string, passwordAuth bool) (terminal.Session, error) {
+	opts := []metalssh.ConnectOpt{metalssh.ConnectOptOutputWriter(out)}
 
 	if passwordAuth {
 		opts = append(opts, metalssh.ConnectOptOutputPassword(*idToken))
@@ -604,13 +753,13 @@ func sshClient(user, keyfile, host string, port int, idToken *string, passwordAu
 			var err error
 			keyfile, err = helpers.SearchSSHKey()
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 
 		privateKey, err := os.ReadFile(keyfile)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		opts = append(opts, metalssh.ConnectOptOutputPrivateKey(privateKey))
@@ -618,14 +767,14 @@ func sshClient(user, keyfile, host string, port int, idToken *string, passwordAu
 
 	s, err := metalssh.NewClient(user, host, port, opts...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var env *metalssh.Env
+	var env map[string]string
 
 	if idToken != nil {
-		env = &metalssh.Env{"LC_METAL_STACK_OIDC_TOKEN": *idToken}
+		env = map[string]string{"LC_METAL_STACK_OIDC_TOKEN": *idToken}
 	}
 
-	return s.Connect(env)
+	return terminal.NewSSHSession(s.Client, env), nil
 }
@@ -0,0 +1,151 @@
+package v2
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/tableprinters"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+)
+
+// addBulkFlags allows a machine command to run on many machines, selected either through the machine query flags, multiple ids or ids from stdin.
+func (c *machine) addBulkFlags(cmd *cobra.Command) {
+	cmd.Use = cmd.Name() + " [<id>... | -]"
+	cmd.Long = cmd.Short + `
+
+multiple machines can be passed as arguments, read from stdin by passing "-" or selected with the machine query flags (e.g. --partition, --rack, --size, --labels, --state).
+when running on more than one machine, the affected machines have to be confirmed and a summary is printed at the end.`
+
+	helpers.AddMachineQueryFlags(cmd, c.c.Completion)
+
+	cmd.Flags().Int("concurrency", 5, "the amount of machines which are processed concurrently when running on multiple machines")
+	if cmd.Flags().Lookup("skip-security-prompts") == nil {
+		cmd.Flags().Bool("skip-security-prompts", false, "skips the confirmation prompt when running on multiple machines")
+	}
+}
+
+// isBulk returns true if the given args do not denote exactly one machine.
+func (c *machine) isBulk(args []string) bool {
+	return len(args) != 1 || args[0] == "-"
+}
+
+// bulk runs the given operation on all selected machines with a bounded amount of workers and prints a summary.
+func (c *machine) bulk(args []string, operation string, fn func(id string) error) error {
+	ids, err := c.selectMachines(args)
+	if err != nil {
+		return err
+	}
+
+	if !viper.GetBool("skip-security-prompts") {
+		err = genericcli.PromptCustom(&genericcli.PromptConfig{
+			ShowAnswers: true,
+			Message:     fmt.Sprintf("%s %d machines:\n  %s\nDo you want to continue?", operation, len(ids), strings.Join(ids, "\n  ")),
+			In:          c.c.In,
+			Out:         c.c.PromptOut,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	results := make([]*tableprinters.BulkOperationResult, len(ids))
+
+	helpers.RunConcurrently(len(ids), viper.GetInt("concurrency"), func(i int) {
+		start := time.Now()
+
+		err := fn(ids[i])
+
+		results[i] = &tableprinters.BulkOperationResult{
+			ID:        ids[i],
+			Operation: operation,
+			Error:     err,
+			Duration:  time.Since(start),
+		}
+	})
+
+	err = c.c.ListPrinter.Print(results)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d machines", operation, failed, len(ids))
+	}
+
+	return nil
+}
+
+func (c *machine) selectMachines(args []string) ([]string, error) {
+	var ids []string
+
+	switch {
+	case len(args) == 1 && args[0] == "-":
+		if !viper.GetBool("skip-security-prompts") {
+			return nil, errors.New("reading machine ids from stdin requires --skip-security-prompts as the confirmation cannot be read from stdin")
+		}
+		if c.c.In == nil {
+			return nil, errors.New("no input on stdin")
+		}
+
+		scanner := bufio.NewScanner(c.c.In)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			ids = append(ids, strings.Fields(line)...)
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("unable to read machine ids from stdin: %w", err)
+		}
+
+	case len(args) > 0:
+		ids = args
+
+	default:
+		query, err := helpers.MachineQuery(true)
+		if err != nil {
+			return nil, err
+		}
+
+		if proto.Equal(query, &apiv2.MachineQuery{}) {
+			return nil, errors.New("either provide machine ids, - for reading ids from stdin or select machines with the query flags")
+		}
+
+		machines, err := c.List()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range machines {
+			ids = append(ids, m.Uuid)
+		}
+	}
+
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	if len(ids) == 0 {
+		return nil, errors.New("no machines selected")
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,84 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/viper"
+)
+
+func machineEventsHelpText() string {
+	return `
+the duration of an event is the time until the next event occurred, for the latest event it is the time passed since then.
+
+Meaning of the emojis:
+
+❗ The event is the last error event of the machine and occurred within the last event error threshold.
+⭕ The machine is in a provisioning crash loop. Flag can be reset through an API-triggered reboot or when the machine reaches the phoned home state.
+🚑 Machine reclaim has failed. The machine was deleted but it is not going back into the available machine pool.
+`
+}
+
+func (c *machine) events(ctx context.Context, args []string) error {
+	id, err := genericcli.GetExactlyOneArg(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+
+	recent := m.RecentProvisioningEvents
+	if recent == nil {
+		recent = &apiv2.MachineRecentProvisioningEvents{}
+	}
+
+	err = c.c.ListPrinter.Print(recent)
+	if err != nil {
+		return err
+	}
+
+	if !viper.GetBool("follow") {
+		return nil
+	}
+
+	var (
+		last      time.Time
+		crashLoop = isCrashLoop(recent)
+	)
+
+	if events := helpers.ProvisioningEventsSince(recent, time.Time{}); len(events) > 0 {
+		last = events[len(events)-1].Time.AsTime()
+	}
+
+	return poll(ctx, viper.GetDuration("interval"), 0, "", func() (bool, error) {
+		m, err := c.Get(id)
+		if err != nil {
+			return false, err
+		}
+
+		for _, e := range helpers.ProvisioningEventsSince(m.RecentProvisioningEvents, last) {
+			last = e.Time.AsTime()
+
+			c.printProvisioningEvent(m.RecentProvisioningEvents, e)
+		}
+
+		if isCrashLoop(m.RecentProvisioningEvents) && !crashLoop {
+			_, _ = fmt.Fprintln(c.c.Out, color.RedString("%s machine %q is in a provisioning crash loop", helpers.Loop, id))
+		}
+		crashLoop = isCrashLoop(m.RecentProvisioningEvents)
+
+		return false, nil
+	})
+}
+
+func isCrashLoop(recent *apiv2.MachineRecentProvisioningEvents) bool {
+	return recent != nil && recent.State == apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_CRASHLOOP
+}
@@ -0,0 +1,74 @@
+package v2
+
+import (
+	"slices"
+
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/spf13/viper"
+)
+
+func (c *machine) issues() error {
+	checks, err := helpers.SelectIssueChecks(viper.GetStringSlice("only"), viper.GetStringSlice("omit"))
+	if err != nil {
+		return err
+	}
+
+	machines, err := c.List()
+	if err != nil {
+		return err
+	}
+
+	var (
+		bmcDetails []*apiv2.MachineBMCDetails
+		switches   []*apiv2.Switch
+	)
+
+	// bmc details and switches are only fetched if one of the selected checks requires them
+	if slices.ContainsFunc(checks, func(check helpers.IssueCheck) bool { return check.Spec().NeedsBMC }) {
+		query, err := helpers.MachineQuery(true)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := c.c.NewRequestContext()
+		defer cancel()
+
+		resp, err := c.c.Client.Adminv2().Machine().ListBMC(ctx, &adminv2.MachineServiceListBMCRequest{
+			Query: query,
+		})
+		if err != nil {
+			return err
+		}
+
+		bmcDetails = resp.BmcDetails
+		if bmcDetails == nil {
+			bmcDetails = []*apiv2.MachineBMCDetails{}
+		}
+	}
+
+	if slices.ContainsFunc(checks, func(check helpers.IssueCheck) bool { return check.Spec().NeedsSwitches }) {
+		ctx, cancel := c.c.NewRequestContext()
+		defer cancel()
+
+		resp, err := c.c.Client.Adminv2().Switch().List(ctx, &adminv2.SwitchServiceListRequest{
+			Query: &apiv2.SwitchQuery{
+				Partition: pointer.PointerOrNil(viper.GetString("partition")),
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		switches = resp.Switches
+		if switches == nil {
+			switches = []*apiv2.Switch{}
+		}
+	}
+
+	ic := helpers.NewIssueContext(machines, bmcDetails, switches, viper.GetDuration("last-event-error-threshold"))
+
+	return c.c.ListPrinter.Print(helpers.EvaluateIssues(ic, checks))
+}
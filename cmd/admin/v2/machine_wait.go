@@ -0,0 +1,183 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/metal-stack/api/go/enum"
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/viper"
+)
+
+// waitUntil polls the given condition with the wait flags until it is fulfilled or the wait timeout expires.
+func (c *machine) waitUntil(ctx context.Context, description string, condition func() (bool, error)) error {
+	return poll(ctx, viper.GetDuration("wait-interval"), viper.GetDuration("wait-timeout"), "machine to reach "+description, condition)
+}
+
+// bmcCommandWaitable returns an error if it is unknown how to detect that the given bmc command took effect.
+func bmcCommandWaitable(command apiv2.MachineBMCCommand) error {
+	switch command {
+	case apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_ON,
+		apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_OFF,
+		apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_CYCLE,
+		apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_RESET:
+		return nil
+	default:
+		return fmt.Errorf("waiting is not supported for bmc command %s", command.String())
+	}
+}
+
+// waitForBMCCommand waits until the bmc of the machine reports the power state requested by the command.
+// power cycles and resets additionally require the machine to report a provisioning event after the command was sent.
+func (c *machine) waitForBMCCommand(ctx context.Context, id string, command apiv2.MachineBMCCommand, since time.Time) error {
+	var (
+		powerState = "ON"
+		rebooted   = command == apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_CYCLE || command == apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_RESET
+	)
+
+	if command == apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_OFF {
+		powerState = "OFF"
+	}
+
+	description := "power state " + powerState
+	if rebooted {
+		description = "power state " + powerState + " with a new provisioning event"
+	}
+
+	return c.waitUntil(ctx, description, func() (bool, error) {
+		bmc, err := c.getBMC(id)
+		if err != nil {
+			return false, err
+		}
+
+		report := bmc.BmcReport
+		if report == nil || report.Bmc == nil || report.UpdatedAt == nil {
+			return false, nil
+		}
+
+		// reports from before the command do not reflect its outcome
+		if !report.UpdatedAt.AsTime().After(since) || !strings.EqualFold(report.Bmc.PowerState, powerState) {
+			return false, nil
+		}
+
+		if !rebooted {
+			return true, nil
+		}
+
+		m, err := c.Get(id)
+		if err != nil {
+			return false, err
+		}
+
+		return len(helpers.ProvisioningEventsSince(m.RecentProvisioningEvents, since)) > 0, nil
+	})
+}
+
+func (c *machine) getBMC(id string) (*apiv2.MachineBMCDetails, error) {
+	ctx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	resp, err := c.c.Client.Adminv2().Machine().GetBMC(ctx, &adminv2.MachineServiceGetBMCRequest{
+		Uuid: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.BmcDetails, nil
+}
+
+// followProvisioningEvents prints the provisioning events of a machine which occur after the given time
+// until the done func returns true for one of them.
+func (c *machine) followProvisioningEvents(ctx context.Context, id string, since time.Time, description string, done func(e *apiv2.MachineProvisioningEvent) bool) error {
+	last := since
+
+	return c.waitUntil(ctx, description, func() (bool, error) {
+		m, err := c.Get(id)
+		if err != nil {
+			return false, err
+		}
+
+		for _, e := range helpers.ProvisioningEventsSince(m.RecentProvisioningEvents, last) {
+			last = e.Time.AsTime()
+
+			c.printProvisioningEvent(m.RecentProvisioningEvents, e)
+
+			if done(e) {
+				return true, nil
+			}
+		}
+
+		if isCrashLoop(m.RecentProvisioningEvents) {
+			return false, fmt.Errorf("machine %q is in a provisioning crash loop", id)
+		}
+
+		return false, nil
+	})
+}
+
+// printProvisioningEvent prints a single event as a line, recent error events are highlighted.
+func (c *machine) printProvisioningEvent(recent *apiv2.MachineRecentProvisioningEvents, e *apiv2.MachineProvisioningEvent) {
+	event := e.Event.String()
+	if name, err := enum.GetStringValue(e.Event); err == nil {
+		event = *name
+	}
+
+	line := fmt.Sprintf("%s  %-12s  %s", e.Time.AsTime().Format(time.RFC3339), event, e.Message)
+	if helpers.IsRecentProvisioningErrorEvent(recent, e, viper.GetDuration("last-event-error-threshold")) {
+		line = color.RedString("%s %s", line, helpers.Exclamation)
+	}
+
+	_, _ = fmt.Fprintln(c.c.Out, line)
+}
+
+func (c *machine) reprovision(ctx context.Context, args []string) error {
+	id, err := genericcli.GetExactlyOneArg(args)
+	if err != nil {
+		return err
+	}
+
+	if !viper.GetBool("skip-security-prompts") {
+		err = genericcli.PromptCustom(&genericcli.PromptConfig{
+			ShowAnswers: true,
+			Message:     fmt.Sprintf("machine %q will be power cycled into PXE boot, do you want to continue?", id),
+			In:          c.c.In,
+			Out:         c.c.PromptOut,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	since := time.Now()
+
+	for _, command := range []apiv2.MachineBMCCommand{
+		apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_BOOT_FROM_PXE,
+		apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_CYCLE,
+	} {
+		err = c.sendBMCCommand(id, command)
+		if err != nil {
+			return fmt.Errorf("unable to send %s to machine %q: %w", command.String(), id, err)
+		}
+
+		_, _ = fmt.Fprintf(c.c.Out, "%s sent %s to machine \"%s\"\n", color.GreenString("✔"), command.String(), id)
+	}
+
+	err = c.followProvisioningEvents(ctx, id, since, "the phoned home or waiting event", func(e *apiv2.MachineProvisioningEvent) bool {
+		return e.Event == apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_PHONED_HOME ||
+			e.Event == apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(c.c.Out, "%s machine \"%s\" was reprovisioned\n", color.GreenString("✔"), id)
+
+	return nil
+}
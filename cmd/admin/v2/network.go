@@ -6,6 +6,8 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -103,7 +105,7 @@ func newNetworkCmd(c *config.Config) *cobra.Command {
 		},
 	}
 
-	return genericcli.NewCmds(cmdsConfig)
+	return dryrun.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig)))
 }
 
 func (c *networkCmd) Get(id string) (*apiv2.Network, error) {
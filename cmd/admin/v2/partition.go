@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -84,6 +87,9 @@ func newPartitionCmd(c *config.Config) *cobra.Command {
 	capacityCmd := &cobra.Command{
 		Use:   "capacity",
 		Short: "show partition capacity",
+		Long: `show partition capacity.
+
+with --forecast the machine allocations and releases within the forecast window are derived from the audit traces and used for projecting when each size runs out of free machines. sizes below the capacity thresholds of the current context are flagged, they can be configured with metalctlv2 context update --capacity-min-free and --capacity-min-runway.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return w.capacity()
 		},
@@ -92,13 +98,15 @@ func newPartitionCmd(c *config.Config) *cobra.Command {
 	capacityCmd.Flags().StringP("id", "", "", "filter on partition id.")
 	capacityCmd.Flags().StringP("size", "", "", "filter on size id.")
 	capacityCmd.Flags().StringP("project", "", "", "consider project-specific counts, e.g. size reservations.")
+	capacityCmd.Flags().Bool("forecast", false, "projects when each size runs out of free machines based on the allocation trend within the forecast window")
+	capacityCmd.Flags().Duration("forecast-window", 30*24*time.Hour, "the duration in the past from which the allocation trend is derived")
 	capacityCmd.Flags().StringSlice("sort-by", []string{}, fmt.Sprintf("order by (comma separated) column(s), sort direction can be changed by appending :asc or :desc behind the column identifier. possible values: %s", strings.Join(sorters.PartitionCapacitySorter().AvailableKeys(), "|")))
 	genericcli.Must(capacityCmd.RegisterFlagCompletionFunc("id", c.Completion.Partition))
 	genericcli.Must(capacityCmd.RegisterFlagCompletionFunc("project", c.Completion.Project))
 	genericcli.Must(capacityCmd.RegisterFlagCompletionFunc("size", c.Completion.Size))
 	genericcli.Must(capacityCmd.RegisterFlagCompletionFunc("sort-by", cobra.FixedCompletions(sorters.PartitionCapacitySorter().AvailableKeys(), cobra.ShellCompDirectiveNoFileComp)))
 
-	return genericcli.NewCmds(cmdsConfig, capacityCmd)
+	return dryrun.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, capacityCmd)))
 }
 
 func (c *partition) capacity() error {
@@ -125,6 +133,10 @@ func (c *partition) capacity() error {
 		return err
 	}
 
+	if viper.GetBool("forecast") {
+		return c.capacityForecast(resp.PartitionCapacity)
+	}
+
 	return c.c.ListPrinter.Print(resp.PartitionCapacity)
 }
 
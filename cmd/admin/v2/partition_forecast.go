@@ -0,0 +1,62 @@
+package v2
+
+import (
+	"fmt"
+	"time"
+
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	helpersaudit "github.com/metal-stack/cli/pkg/helpers/audit"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// capacityForecast projects the partition capacity using the machine allocations and releases of the audit traces within the forecast window.
+func (c *partition) capacityForecast(capacities []*adminv2.PartitionCapacity) error {
+	window := viper.GetDuration("forecast-window")
+	if window <= 0 {
+		return fmt.Errorf("forecast window must be a positive duration")
+	}
+
+	now := time.Now()
+
+	var traces []*apiv2.AuditTrace
+	for _, method := range helpers.CapacityEventMethods {
+		methodTraces, err := helpersaudit.ListAll(&apiv2.AuditQuery{
+			From:   timestamppb.New(now.Add(-window)),
+			Method: &method,
+			Phase:  new(apiv2.AuditPhase_AUDIT_PHASE_RESPONSE),
+		}, now, func(query *apiv2.AuditQuery) ([]*apiv2.AuditTrace, error) {
+			ctx, cancel := c.c.NewRequestContext()
+			defer cancel()
+
+			resp, err := c.c.Client.Adminv2().Audit().List(ctx, &adminv2.AuditServiceListRequest{
+				Query: query,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list audit traces: %w", err)
+			}
+
+			return resp.Traces, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		traces = append(traces, methodTraces...)
+	}
+
+	forecast := helpers.ForecastCapacity(capacities, helpers.CapacityEventsFromTraces(traces), window, now, c.c.Context.CapacityThresholds)
+
+	// the forecast is no api entity, so it cannot be printed by the proto printers
+	switch viper.GetString("output-format") {
+	case "json":
+		return printers.NewJSONPrinter().WithOut(c.c.Out).Print(forecast)
+	case "yaml":
+		return printers.NewYAMLPrinter().WithOut(c.c.Out).Print(forecast)
+	default:
+		return c.c.ListPrinter.Print(forecast)
+	}
+}
@@ -0,0 +1,95 @@
+package v2
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/terminal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func addSessionFlags(cmd *cobra.Command) {
+	cmd.Flags().String("record", "", "records the output of the session in asciicast v2 format to the given file, it can be replayed with asciinema")
+	cmd.Flags().String("transcript-dir", "", "captures a transcript of the session in asciicast v2 format into a timestamped file in the given directory")
+	cmd.Flags().Bool("compress-transcript", false, "compresses the transcript of the session with gzip")
+}
+
+// transcriptFile returns the timestamped path of the session transcript, it is empty when no transcript is captured.
+func transcriptFile(name string) string {
+	dir := viper.GetString("transcript-dir")
+	if dir == "" {
+		return ""
+	}
+
+	file := fmt.Sprintf("%s-%s.cast", name, time.Now().UTC().Format("20060102T150405Z"))
+	if viper.GetBool("compress-transcript") {
+		file += ".gz"
+	}
+
+	return filepath.Join(dir, file)
+}
+
+// attachSession attaches the in- and output of the cli to the given session until it terminates or the user exits with ~.
+// the output is recorded to the given transcript file if it is not empty.
+func attachSession(ctx context.Context, c *config.Config, transcript string, s terminal.Session) (err error) {
+	var (
+		opts       = []terminal.Opt{terminal.WithEscapeSequence()}
+		recordings []io.Writer
+		closers    []io.Closer
+	)
+
+	defer func() {
+		// closing in reverse order such that a compressed transcript is flushed before its file is closed
+		for i := len(closers) - 1; i >= 0; i-- {
+			err = errors.Join(err, closers[i].Close())
+		}
+	}()
+
+	if file := viper.GetString("record"); file != "" {
+		f, err := c.Fs.Create(file)
+		if err != nil {
+			return fmt.Errorf("unable to create recording: %w", err)
+		}
+
+		closers = append(closers, f)
+		recordings = append(recordings, f)
+	}
+
+	if transcript != "" {
+		err := c.Fs.MkdirAll(filepath.Dir(transcript), 0700)
+		if err != nil {
+			return fmt.Errorf("unable to create transcript directory: %w", err)
+		}
+
+		f, err := c.Fs.OpenFile(transcript, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to create transcript: %w", err)
+		}
+
+		closers = append(closers, f)
+
+		if filepath.Ext(transcript) == ".gz" {
+			zw := gzip.NewWriter(f)
+			closers = append(closers, zw)
+			recordings = append(recordings, zw)
+		} else {
+			recordings = append(recordings, f)
+		}
+
+		_, _ = fmt.Fprintf(c.PromptOut, "capturing session transcript to %s\n", transcript)
+	}
+
+	if len(recordings) > 0 {
+		opts = append(opts, terminal.WithRecording(io.MultiWriter(recordings...)))
+	}
+
+	return terminal.New(c.In, c.Out, opts...).Attach(ctx, s)
+}
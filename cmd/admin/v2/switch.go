@@ -1,9 +1,10 @@
 package v2
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -11,8 +12,12 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/cmd/tableprinters"
+	"github.com/metal-stack/cli/cmd/terminal"
+	"github.com/metal-stack/cli/cmd/watch"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
@@ -91,15 +96,32 @@ func newSwitchCmd(c *config.Config) *cobra.Command {
 	// genericcli.Must(switchMachinesCmd.RegisterFlagCompletionFunc("size", c.Completion.SizeListCompletion))
 	// genericcli.Must(switchMachinesCmd.RegisterFlagCompletionFunc("machine-id", c.Completion.MachineListCompletion))
 
+	switchCheckCmd := &cobra.Command{
+		Use:   "check",
+		Short: "checks the leaf switch pairs of racks for inconsistencies",
+		Long:  "checks the leaf switch pairs of racks for inconsistencies like machines connected to only one leaf, differing port configurations for the same machine, differing switch os versions and outdated or failed switch syncs. exits with a non-zero exit code if problems were found.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sw.switchCheck()
+		},
+	}
+
+	switchCheckCmd.Flags().String("partition", "", "Partition of the switches.")
+	switchCheckCmd.Flags().String("rack", "", "Rack of the switches.")
+	switchCheckCmd.Flags().Duration("sync-threshold", 10*time.Minute, "the duration after which the last sync of a switch is considered outdated")
+
+	genericcli.Must(switchCheckCmd.RegisterFlagCompletionFunc("partition", c.Completion.SwitchPartition))
+	genericcli.Must(switchCheckCmd.RegisterFlagCompletionFunc("rack", c.Completion.SwitchRack))
+
 	switchConsoleCmd := &cobra.Command{
 		Use:   "console <id>",
 		Short: "connect to the switch console",
-		Long:  "this requires a network connectivity to the ip address of the console server this switch is connected to.",
+		Long:  "this requires a network connectivity to the ip address of the console server this switch is connected to. telnet console commands are handled by the cli itself, other console commands are executed locally and require their binary to be installed. exit the console with ~.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return sw.switchConsole(args)
+			return sw.switchConsole(cmd.Context(), args)
 		},
 		ValidArgsFunction: c.Completion.Switch,
 	}
+	addSessionFlags(switchConsoleCmd)
 
 	switchDetailCmd := &cobra.Command{
 		Use:   "detail <id>",
@@ -123,40 +145,65 @@ func newSwitchCmd(c *config.Config) *cobra.Command {
 	switchMigrateCmd := &cobra.Command{
 		Use:               "migrate <oldSwitchID> <newSwitchID>",
 		Short:             "migrate machine connections and other configuration from one switch to another",
+		Long:              "migrate machine connections and other configuration from one switch to another. before the migration a snapshot of the ports and machine connections of the old switch is stored in a file, after the new switch has synced its ports and connections are compared against the snapshot. with --no-wait the command does not wait for the new switch to sync, the comparison is then only done if it has already synced and can be done later with --resume. if the command gets interrupted, it can be continued with --resume.",
 		ValidArgsFunction: c.Completion.Switch,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return sw.switchMigrate(args)
+			return sw.switchMigrate(cmd.Context(), args)
 		},
 	}
 
+	addSwitchSnapshotFlags(switchMigrateCmd, 10*time.Minute)
+
 	switchPortCmd := &cobra.Command{
 		Use:   "port",
 		Short: "sets the given switch port state up or down",
 	}
-	switchPortCmd.PersistentFlags().String("port", "", "the port to be changed.")
+	switchPortCmd.PersistentFlags().String("port", "", "the port to be changed, may contain a pattern like swp1s* to select multiple ports.")
+	switchPortCmd.PersistentFlags().String("machine", "", "selects the ports the given machine is connected to, if no switch id is given the ports of all switches are selected.")
 	genericcli.Must(switchPortCmd.RegisterFlagCompletionFunc("port", c.Completion.SwitchPorts))
+	genericcli.Must(switchPortCmd.RegisterFlagCompletionFunc("machine", c.Completion.AdminMachine))
 
 	switchPortUpCmd := &cobra.Command{
-		Use:   "up <switch ID>",
+		Use:   "up [<switch ID>]",
 		Short: "sets the given switch port state up",
-		Long:  "sets the port status to UP so the connected machine will be able to connect to the switch.",
+		Long:  "sets the port status to UP so the connected machine will be able to connect to the switch. when multiple ports are selected by pattern or by machine, every port is changed after a confirmation and the command waits until the actual port state is UP.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if isPortSelection() {
+				return sw.portMany(cmd.Context(), args, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_UP)
+			}
 			return sw.port(args, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_UP)
 		},
 		ValidArgsFunction: c.Completion.Switch,
 	}
 
 	switchPortDownCmd := &cobra.Command{
-		Use:   "down <switch ID>",
+		Use:   "down [<switch ID>]",
 		Short: "sets the given switch port state down",
-		Long:  "sets the port status to DOWN so the connected machine will not be able to connect to the switch.",
+		Long:  "sets the port status to DOWN so the connected machine will not be able to connect to the switch. when multiple ports are selected by pattern or by machine, every port is changed after a confirmation and the command waits until the actual port state is DOWN.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if isPortSelection() {
+				return sw.portMany(cmd.Context(), args, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN)
+			}
 			return sw.port(args, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN)
 		},
 		ValidArgsFunction: c.Completion.Switch,
 	}
 
-	switchPortCmd.AddCommand(switchPortUpCmd, switchPortDownCmd)
+	for _, cmd := range []*cobra.Command{switchPortUpCmd, switchPortDownCmd} {
+		cmd.Flags().Bool("skip-security-prompts", false, "skips the confirmation prompt before changing multiple ports")
+		addWaitFlags(cmd, "switch port", 2*time.Minute)
+	}
+
+	switchPortListCmd := &cobra.Command{
+		Use:   "list <switch ID>",
+		Short: "lists the ports of a switch with their desired and actual state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sw.portList(args)
+		},
+		ValidArgsFunction: c.Completion.Switch,
+	}
+
+	switchPortCmd.AddCommand(switchPortListCmd, switchPortUpCmd, switchPortDownCmd)
 
 	switchReplaceCmd := &cobra.Command{
 		Use:   "replace <switchID>",
@@ -172,24 +219,49 @@ Operational steps to replace a switch:
 - Replace the switch physically. Be careful to ensure that the cabling mirrors the remaining leaf exactly because the new switch information will be cloned from the remaining switch! Also make sure to have console access to the switch so you can start and monitor the install process
 - If the switch is not in onie install mode but already has an operating system installed, put it into install mode with "sudo onie-select -i -f -v" and reboot it. Now the switch should be provisioned with a management IP from a management server, install itself with the right software image and receive license and ssh keys through ZTP. You can check whether that process has completed successfully with the command "sudo ztp -s". The ZTP state should be disabled and the result should be success.
 - Deploy the switch plane and metal-core through metal-stack deployment CI job
-- The switch will now register with its metal-api, and the metal-core service will receive the cloned interface and routing information. You can verify successful switch replacement by checking the interface and BGP configuration, and checking the switch status with "metalctlv2 switch ls -o wide"; it should now be operational again`,
+- The switch will now register with its metal-api, and the metal-core service will receive the cloned interface and routing information. You can verify successful switch replacement by checking the interface and BGP configuration, and checking the switch status with "metalctlv2 switch ls -o wide"; it should now be operational again
+
+Before the replace mode is set, this command stores a snapshot of the ports and machine connections of the switch in a file. It then waits for the new switch to register and compares its ports and connections against the snapshot, machines which lost connectivity are reported. With --no-wait the command does not wait for the new switch to register, the comparison can then be done later with --resume. If the command gets interrupted, it can be continued with --resume.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return sw.switchReplace(args)
+			return sw.switchReplace(cmd.Context(), args)
 		},
 		ValidArgsFunction: c.Completion.Switch,
 	}
 
+	addSwitchSnapshotFlags(switchReplaceCmd, 2*time.Hour)
+
 	switchSSHCmd := &cobra.Command{
 		Use:   "ssh <id>",
 		Short: "connect to the switch via ssh",
-		Long:  "this requires a network connectivity to the management ip address of the switch.",
+		Long:  "this requires a network connectivity to the management ip address of the switch. the host key of the switch is verified against ~/.ssh/known_hosts. if no identity file is given, the identity files configured for the management ip in ~/.ssh/config and the keys of the running ssh agent are used, finally the password is asked for. exit the session with ~.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return sw.switchSSH(args)
+			return sw.switchSSH(cmd.Context(), args)
 		},
 		ValidArgsFunction: c.Completion.Switch,
 	}
+	switchSSHCmd.Flags().StringP("identity", "i", "", "specify identity file to SSH to the switch like: -i path/to/id_rsa")
+	switchSSHCmd.Flags().Int("ssh-port", 0, "the ssh port of the switch, defaults to the port configured for the management ip in ~/.ssh/config or 22")
+	addSessionFlags(switchSSHCmd)
+
+	switchTopologyCmd := &cobra.Command{
+		Use:   "topology",
+		Short: "exports the topology of partitions, racks, switch pairs, switches, ports and connected machines as a graph",
+		Long:  "exports the topology of partitions, racks, switch pairs, switches, ports and connected machines as a graph. all switch nics are shown as ports, ports which are down, whose bgp session is not established, which have no machine connected or whose machine connection differs from the desired switch nic in identifier, mac or vrf are highlighted, as well as racks without a second leaf switch.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sw.switchTopology()
+		},
+		Example: `metalctlv2 admin switch topology --partition fra-equ01 --rack rack-1 | dot -Tsvg > rack-1.svg`,
+	}
+
+	switchTopologyCmd.Flags().String("partition", "", "Partition of the switches.")
+	switchTopologyCmd.Flags().String("rack", "", "Rack of the switches.")
+	switchTopologyCmd.Flags().String("format", "dot", "the format of the graph, can be one of dot|mermaid|json")
 
-	return genericcli.NewCmds(cmdsConfig, switchConnectedMachinesCmd, switchConsoleCmd, switchDetailCmd, switchMigrateCmd, switchPortCmd, switchReplaceCmd, switchSSHCmd)
+	genericcli.Must(switchTopologyCmd.RegisterFlagCompletionFunc("partition", c.Completion.SwitchPartition))
+	genericcli.Must(switchTopologyCmd.RegisterFlagCompletionFunc("rack", c.Completion.SwitchRack))
+	genericcli.Must(switchTopologyCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"dot", "mermaid", "json"}, cobra.ShellCompDirectiveNoFileComp)))
+
+	return dryrun.Enable(c, cmdsConfig, watch.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, switchCheckCmd, switchConnectedMachinesCmd, switchConsoleCmd, switchDetailCmd, switchMigrateCmd, switchPortCmd, switchReplaceCmd, switchSSHCmd, switchTopologyCmd))))
 }
 
 func (c *switchCmd) Get(id string) (*apiv2.Switch, error) {
@@ -303,7 +375,7 @@ func (c *switchCmd) switchConnectedMachines() error {
 	return c.c.ListPrinter.Print(res)
 }
 
-func (c *switchCmd) switchConsole(args []string) error {
+func (c *switchCmd) switchConsole(ctx context.Context, args []string) error {
 	id, err := genericcli.GetExactlyOneArg(args)
 	if err != nil {
 		return err
@@ -323,20 +395,12 @@ func (c *switchCmd) switchConsole(args []string) error {
 	telnet console-server 7008`)
 	}
 
-	parts := strings.Fields(*resp.ConsoleCommand)
-
-	// nolint: gosec
-	cmd := exec.Command(parts[0])
-
-	if len(parts) > 1 {
-		// nolint: gosec
-		cmd = exec.Command(parts[0], parts[1:]...)
+	session, err := terminal.NewConsoleSession(ctx, *resp.ConsoleCommand)
+	if err != nil {
+		return err
 	}
 
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stdout
-	return cmd.Run()
+	return attachSession(ctx, c.c, transcriptFile("switch-console-"+id), session)
 }
 
 func (c *switchCmd) switchDetail() error {
@@ -355,25 +419,6 @@ func (c *switchCmd) switchDetail() error {
 	return c.c.ListPrinter.Print(switchDetails)
 }
 
-func (c *switchCmd) switchMigrate(args []string) error {
-	ctx, cancel := c.c.NewRequestContext()
-	defer cancel()
-
-	if count := len(args); count != 2 {
-		return fmt.Errorf("invalid number of arguments were provided; 2 are required, %d were passed", count)
-	}
-
-	resp, err := c.c.Client.Adminv2().Switch().Migrate(ctx, &adminv2.SwitchServiceMigrateRequest{
-		OldSwitch: args[0],
-		NewSwitch: args[1],
-	})
-	if err != nil {
-		return err
-	}
-
-	return c.c.DescribePrinter.Print(resp)
-}
-
 func (c *switchCmd) port(args []string, status apiv2.SwitchPortStatus) error {
 	ctx, cancel := c.c.NewRequestContext()
 	defer cancel()
@@ -400,52 +445,45 @@ func (c *switchCmd) port(args []string, status apiv2.SwitchPortStatus) error {
 	return c.dumpPortState(resp.Switch, portid)
 }
 
-func (c *switchCmd) switchReplace(args []string) error {
+func (c *switchCmd) switchSSH(ctx context.Context, args []string) error {
 	id, err := genericcli.GetExactlyOneArg(args)
 	if err != nil {
 		return err
 	}
 
-	sw, err := c.Get(id)
+	resp, err := c.Get(id)
 	if err != nil {
 		return err
 	}
-
-	resp, err := c.Update(&adminv2.SwitchServiceUpdateRequest{
-		Id: id,
-		UpdateMeta: &apiv2.UpdateMeta{
-			UpdatedAt:       sw.Meta.UpdatedAt,
-			LockingStrategy: apiv2.OptimisticLockingStrategy_OPTIMISTIC_LOCKING_STRATEGY_CLIENT,
-		},
-		ReplaceMode: apiv2.SwitchReplaceMode_SWITCH_REPLACE_MODE_REPLACE.Enum(),
-	})
-	if err != nil {
-		return err
+	if resp.ManagementIp == "" || resp.ManagementUser == nil {
+		return fmt.Errorf("unable to connect to switch by ssh because no ip and user was stored for this switch, please restart metal-core on this switch")
 	}
 
-	return c.c.DescribePrinter.Print(resp)
-}
+	var privateKey []byte
+	if identity := viper.GetString("identity"); identity != "" {
+		if strings.HasPrefix(identity, "~/") {
+			home, _ := os.UserHomeDir()
+			identity = filepath.Join(home, identity[2:])
+		}
 
-func (c *switchCmd) switchSSH(args []string) error {
-	id, err := genericcli.GetExactlyOneArg(args)
-	if err != nil {
-		return err
+		privateKey, err = c.c.Fs.ReadFile(identity)
+		if err != nil {
+			return fmt.Errorf("unable to read identity file: %w", err)
+		}
 	}
 
-	resp, err := c.Get(id)
+	client, err := terminal.DialSSH(&terminal.SSHConfig{
+		User:       pointer.SafeDeref(resp.ManagementUser),
+		Host:       resp.ManagementIp,
+		Port:       viper.GetInt("ssh-port"),
+		PrivateKey: privateKey,
+		Password:   terminal.PasswordPrompt(c.c.In, c.c.PromptOut, fmt.Sprintf("password of %s@%s: ", pointer.SafeDeref(resp.ManagementUser), resp.ManagementIp)),
+	})
 	if err != nil {
-		return err
-	}
-	if resp.ManagementIp == "" || resp.ManagementUser == nil {
-		return fmt.Errorf("unable to connect to switch by ssh because no ip and user was stored for this switch, please restart metal-core on this switch")
+		return fmt.Errorf("unable to connect to switch %q: %w", id, err)
 	}
 
-	// nolint: gosec
-	cmd := exec.Command("ssh", fmt.Sprintf("%s@%s", pointer.SafeDeref(resp.ManagementUser), resp.ManagementIp))
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stdout
-	return cmd.Run()
+	return attachSession(ctx, c.c, transcriptFile("switch-ssh-"+id), terminal.NewSSHSession(client, nil))
 }
 
 func (c *switchCmd) dumpPortState(sw *apiv2.Switch, portid string) error {
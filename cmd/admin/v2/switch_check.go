@@ -0,0 +1,41 @@
+package v2
+
+import (
+	"fmt"
+	"time"
+
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/spf13/viper"
+)
+
+func (c *switchCmd) switchCheck() error {
+	ctx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	resp, err := c.c.Client.Adminv2().Switch().List(ctx, &adminv2.SwitchServiceListRequest{
+		Query: &apiv2.SwitchQuery{
+			Partition: pointer.PointerOrNil(viper.GetString("partition")),
+			Rack:      pointer.PointerOrNil(viper.GetString("rack")),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	findings := helpers.CheckSwitchPairs(resp.Switches, viper.GetDuration("sync-threshold"), time.Now())
+
+	err = c.c.ListPrinter.Print(findings)
+	if err != nil {
+		return err
+	}
+
+	// a non-zero exit code allows running the check periodically, e.g. in a ci pipeline
+	if len(findings) > 0 {
+		return fmt.Errorf("found %d problem(s) in the switch configuration", len(findings))
+	}
+
+	return nil
+}
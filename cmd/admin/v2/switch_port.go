@@ -0,0 +1,219 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/api/go/enum"
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/tableprinters"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/viper"
+)
+
+type switchPortTarget struct {
+	switchID  string
+	port      string
+	machineID string
+}
+
+func (t *switchPortTarget) String() string {
+	return t.switchID + "/" + t.port
+}
+
+// isPortSelection returns true if the port flags select potentially many ports instead of a single one.
+func isPortSelection() bool {
+	return viper.GetString("machine") != "" || strings.ContainsAny(viper.GetString("port"), "*?[")
+}
+
+// portMany sets the status of all selected ports one after another and waits until each port has converged to the desired status.
+func (c *switchCmd) portMany(ctx context.Context, args []string, status apiv2.SwitchPortStatus) error {
+	targets, err := c.selectPorts(args)
+	if err != nil {
+		return err
+	}
+
+	operation := status.String()
+	if s, err := enum.GetStringValue(status); err == nil {
+		operation = *s
+	}
+
+	if !viper.GetBool("skip-security-prompts") {
+		var ports []string
+		for _, t := range targets {
+			port := t.String()
+			if t.machineID != "" {
+				port = fmt.Sprintf("%s (machine %s)", port, t.machineID)
+			}
+			ports = append(ports, port)
+		}
+
+		err = genericcli.PromptCustom(&genericcli.PromptConfig{
+			ShowAnswers: true,
+			Message:     fmt.Sprintf("set %d ports %s:\n  %s\nDo you want to continue?", len(targets), operation, strings.Join(ports, "\n  ")),
+			In:          c.c.In,
+			Out:         c.c.PromptOut,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var (
+		results []*tableprinters.BulkOperationResult
+		failed  int
+	)
+
+	for _, t := range targets {
+		start := time.Now()
+
+		err := c.setPortStatus(ctx, t, status, operation)
+		if err != nil {
+			failed++
+		}
+
+		results = append(results, &tableprinters.BulkOperationResult{
+			ID:        t.String(),
+			Operation: operation,
+			Error:     err,
+			Duration:  time.Since(start),
+		})
+	}
+
+	err = c.c.ListPrinter.Print(results)
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d ports", operation, failed, len(targets))
+	}
+
+	return nil
+}
+
+func (c *switchCmd) setPortStatus(ctx context.Context, t *switchPortTarget, status apiv2.SwitchPortStatus, operation string) error {
+	reqCtx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	_, err := c.c.Client.Adminv2().Switch().Port(reqCtx, &adminv2.SwitchServicePortRequest{
+		Id:      t.switchID,
+		NicName: t.port,
+		Status:  status,
+	})
+	if err != nil {
+		return err
+	}
+
+	return poll(ctx, viper.GetDuration("wait-interval"), viper.GetDuration("wait-timeout"), fmt.Sprintf("port %s to become %s", t, operation), func() (bool, error) {
+		sw, err := c.Get(t.switchID)
+		if err != nil {
+			return false, err
+		}
+
+		nic := findSwitchPort(sw, t.port)
+		if nic == nil {
+			return false, fmt.Errorf("port %s does not exist anymore", t)
+		}
+
+		return nic.State != nil && nic.State.Actual == status, nil
+	})
+}
+
+// selectPorts resolves the switch ports selected by the port pattern and the machine flag.
+func (c *switchCmd) selectPorts(args []string) ([]*switchPortTarget, error) {
+	var (
+		pattern   = viper.GetString("port")
+		machineID = viper.GetString("machine")
+		switches  []*apiv2.Switch
+	)
+
+	switch len(args) {
+	case 0:
+		if machineID == "" {
+			return nil, fmt.Errorf("a switch id is required when selecting ports by pattern")
+		}
+
+		ctx, cancel := c.c.NewRequestContext()
+		defer cancel()
+
+		resp, err := c.c.Client.Adminv2().Switch().List(ctx, &adminv2.SwitchServiceListRequest{
+			Query: &apiv2.SwitchQuery{},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		switches = resp.Switches
+	case 1:
+		sw, err := c.Get(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		switches = append(switches, sw)
+	default:
+		return nil, fmt.Errorf("expected at most one switch id, got %d", len(args))
+	}
+
+	var targets []*switchPortTarget
+
+	for _, sw := range switches {
+		for _, p := range helpers.SwitchPorts(sw) {
+			if machineID != "" && p.MachineID != machineID {
+				continue
+			}
+
+			if pattern != "" {
+				matches, err := path.Match(pattern, p.Nic.Name)
+				if err != nil {
+					return nil, fmt.Errorf("invalid port pattern %q: %w", pattern, err)
+				}
+				if !matches {
+					continue
+				}
+			}
+
+			targets = append(targets, &switchPortTarget{
+				switchID:  sw.Id,
+				port:      p.Nic.Name,
+				machineID: p.MachineID,
+			})
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no switch ports matched the selection")
+	}
+
+	return targets, nil
+}
+
+func findSwitchPort(sw *apiv2.Switch, name string) *apiv2.SwitchNic {
+	for _, p := range helpers.SwitchPorts(sw) {
+		if p.Nic.Name == name {
+			return p.Nic
+		}
+	}
+
+	return nil
+}
+
+func (c *switchCmd) portList(args []string) error {
+	id, err := genericcli.GetExactlyOneArg(args)
+	if err != nil {
+		return err
+	}
+
+	sw, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return c.c.ListPrinter.Print(helpers.SwitchPorts(sw))
+}
@@ -0,0 +1,290 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	switchOperationReplace = "replace"
+	switchOperationMigrate = "migrate"
+
+	// the phases of a guided switch operation, they are stored in the snapshot to allow resuming the operation
+	switchPhaseSnapshotTaken = "snapshot-taken"
+	switchPhaseReplaceMode   = "replace-mode"
+	switchPhaseMigrated      = "migrated"
+)
+
+func addSwitchSnapshotFlags(cmd *cobra.Command, timeout time.Duration) {
+	cmd.Flags().String("snapshot-file", "", "the file where the snapshot of the switch ports is stored, defaults to switch-<id>-snapshot.yaml in the current directory")
+	cmd.Flags().Bool("resume", false, "resumes an interrupted operation from the snapshot file")
+	cmd.Flags().Bool("skip-security-prompts", false, "skips the confirmation prompt before changing the switch")
+	cmd.Flags().Bool("no-wait", false, "does not wait for the switch to sync, its ports are then only compared against the snapshot if it has already synced")
+	addWaitFlags(cmd, "switch", timeout)
+}
+
+func (c *switchCmd) switchReplace(ctx context.Context, args []string) error {
+	id, err := genericcli.GetExactlyOneArg(args)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := c.loadOrTakeSnapshot(switchOperationReplace, id, "")
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Phase == switchPhaseSnapshotTaken {
+		err = c.prompt(fmt.Sprintf("switch %q will be put into replace mode, do you want to continue?", id))
+		if err != nil {
+			return err
+		}
+
+		sw, err := c.Get(id)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.Update(&adminv2.SwitchServiceUpdateRequest{
+			Id: id,
+			UpdateMeta: &apiv2.UpdateMeta{
+				UpdatedAt:       sw.Meta.UpdatedAt,
+				LockingStrategy: apiv2.OptimisticLockingStrategy_OPTIMISTIC_LOCKING_STRATEGY_CLIENT,
+			},
+			ReplaceMode: apiv2.SwitchReplaceMode_SWITCH_REPLACE_MODE_REPLACE.Enum(),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = c.advanceSnapshot(snapshot, switchPhaseReplaceMode)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(c.c.Out, "%s switch \"%s\" is in replace mode, the switch can now be replaced physically\n", color.GreenString("✔"), id)
+	}
+
+	synced, err := c.awaitSync(ctx, id, snapshot.PhaseChangedAt, "the new switch to register", func(sw *apiv2.Switch) bool {
+		return sw.ReplaceMode != apiv2.SwitchReplaceMode_SWITCH_REPLACE_MODE_REPLACE
+	})
+	if err != nil || !synced {
+		return err
+	}
+
+	return c.verifySnapshot(snapshot, id)
+}
+
+func (c *switchCmd) switchMigrate(ctx context.Context, args []string) error {
+	if count := len(args); count != 2 {
+		return fmt.Errorf("invalid number of arguments were provided; 2 are required, %d were passed", count)
+	}
+
+	oldID, newID := args[0], args[1]
+
+	snapshot, err := c.loadOrTakeSnapshot(switchOperationMigrate, oldID, newID)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Phase == switchPhaseSnapshotTaken {
+		err = c.prompt(fmt.Sprintf("machine connections and configuration of switch %q will be migrated to switch %q, do you want to continue?", oldID, newID))
+		if err != nil {
+			return err
+		}
+
+		reqCtx, cancel := c.c.NewRequestContext()
+		defer cancel()
+
+		_, err = c.c.Client.Adminv2().Switch().Migrate(reqCtx, &adminv2.SwitchServiceMigrateRequest{
+			OldSwitch: oldID,
+			NewSwitch: newID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = c.advanceSnapshot(snapshot, switchPhaseMigrated)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(c.c.Out, "%s migrated switch \"%s\" to \"%s\"\n", color.GreenString("✔"), oldID, newID)
+	}
+
+	synced, err := c.awaitSync(ctx, newID, snapshot.PhaseChangedAt, fmt.Sprintf("switch %q to sync", newID), nil)
+	if err != nil || !synced {
+		return err
+	}
+
+	return c.verifySnapshot(snapshot, newID)
+}
+
+func (c *switchCmd) prompt(message string) error {
+	if viper.GetBool("skip-security-prompts") {
+		return nil
+	}
+
+	return genericcli.PromptCustom(&genericcli.PromptConfig{
+		ShowAnswers: true,
+		Message:     message,
+		In:          c.c.In,
+		Out:         c.c.PromptOut,
+	})
+}
+
+func snapshotFile(id string) string {
+	if file := viper.GetString("snapshot-file"); file != "" {
+		return file
+	}
+
+	return fmt.Sprintf("switch-%s-snapshot.yaml", id)
+}
+
+// loadOrTakeSnapshot reads the snapshot file when resuming, otherwise a new snapshot of the switch is taken and stored.
+func (c *switchCmd) loadOrTakeSnapshot(operation, id, targetID string) (*helpers.SwitchSnapshot, error) {
+	file := snapshotFile(id)
+
+	if viper.GetBool("resume") {
+		raw, err := c.c.Fs.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read snapshot for resuming: %w", err)
+		}
+
+		var snapshot helpers.SwitchSnapshot
+		err = yaml.Unmarshal(raw, &snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse snapshot %s: %w", file, err)
+		}
+
+		if snapshot.Operation != operation || snapshot.SwitchID != id || snapshot.TargetSwitchID != targetID {
+			return nil, fmt.Errorf("snapshot %s was taken for the %s of switch %q and cannot be resumed with this command", file, snapshot.Operation, snapshot.SwitchID)
+		}
+
+		_, _ = fmt.Fprintf(c.c.Out, "%s resuming %s of switch \"%s\" from snapshot taken at %s\n", color.GreenString("✔"), operation, id, snapshot.TakenAt.Format(time.RFC3339))
+
+		return &snapshot, nil
+	}
+
+	if exists, err := c.c.Fs.Exists(file); err == nil && exists {
+		return nil, fmt.Errorf("snapshot %s already exists, use --resume to continue the operation or remove the file to start over", file)
+	}
+
+	sw, err := c.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := helpers.NewSwitchSnapshot(operation, sw, time.Now())
+	snapshot.TargetSwitchID = targetID
+
+	err = c.advanceSnapshot(snapshot, switchPhaseSnapshotTaken)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _ = fmt.Fprintf(c.c.Out, "%s stored snapshot of %d port(s) of switch \"%s\" in %s\n", color.GreenString("✔"), len(snapshot.Ports), id, file)
+
+	return snapshot, nil
+}
+
+// advanceSnapshot sets the phase of the snapshot and persists it.
+func (c *switchCmd) advanceSnapshot(snapshot *helpers.SwitchSnapshot, phase string) error {
+	snapshot.Phase = phase
+	snapshot.PhaseChangedAt = time.Now()
+
+	raw, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = c.c.Fs.WriteFile(snapshotFile(snapshot.SwitchID), raw, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to store snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// awaitSync returns whether the switch has synced after the given time and fulfills the optional condition.
+// it polls until this is the case, with --no-wait the switch is only checked once and a hint for resuming is printed.
+func (c *switchCmd) awaitSync(ctx context.Context, id string, since time.Time, description string, condition func(sw *apiv2.Switch) bool) (bool, error) {
+	if !viper.GetBool("no-wait") {
+		_, _ = fmt.Fprintf(c.c.Out, "waiting for %s, use --resume to continue if this gets interrupted\n", description)
+
+		err := poll(ctx, viper.GetDuration("wait-interval"), viper.GetDuration("wait-timeout"), description, func() (bool, error) {
+			return c.switchSynced(id, since, condition)
+		})
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	synced, err := c.switchSynced(id, since, condition)
+	if err != nil {
+		return false, err
+	}
+
+	if !synced {
+		_, _ = fmt.Fprintf(c.c.Out, "switch \"%s\" has not synced yet, use --resume to wait for it and compare its ports against the snapshot\n", id)
+	}
+
+	return synced, nil
+}
+
+// switchSynced returns true if the switch has synced after the given time and fulfills the optional condition.
+func (c *switchCmd) switchSynced(id string, since time.Time, condition func(sw *apiv2.Switch) bool) (bool, error) {
+	sw, err := c.Get(id)
+	if err != nil {
+		return false, err
+	}
+
+	if sw.LastSync == nil || sw.LastSync.Time == nil || !sw.LastSync.Time.AsTime().After(since) {
+		return false, nil
+	}
+
+	return condition == nil || condition(sw), nil
+}
+
+// verifySnapshot compares the switch against the snapshot, the snapshot is removed if no machine lost connectivity.
+func (c *switchCmd) verifySnapshot(snapshot *helpers.SwitchSnapshot, id string) error {
+	sw, err := c.Get(id)
+	if err != nil {
+		return err
+	}
+
+	changes := helpers.DiffSwitchSnapshot(snapshot, sw)
+
+	if len(changes) > 0 {
+		err = c.c.ListPrinter.Print(changes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if lost := helpers.MachinesWithLostConnectivity(changes); len(lost) > 0 {
+		return fmt.Errorf("%d machine(s) lost connectivity: %s, the snapshot is kept in %s", len(lost), strings.Join(lost, ", "), snapshotFile(snapshot.SwitchID))
+	}
+
+	err = c.c.Fs.Remove(snapshotFile(snapshot.SwitchID))
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(c.c.Out, "%s all machines of switch \"%s\" are still connected\n", color.GreenString("✔"), snapshot.SwitchID)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/spf13/viper"
+)
+
+func (c *switchCmd) switchTopology() error {
+	format := viper.GetString("format")
+	if !slices.Contains([]string{"dot", "mermaid", "json"}, format) {
+		return fmt.Errorf("unsupported topology format %q, possible values: dot|mermaid|json", format)
+	}
+
+	ctx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	query := &apiv2.SwitchQuery{
+		Partition: pointer.PointerOrNil(viper.GetString("partition")),
+		Rack:      pointer.PointerOrNil(viper.GetString("rack")),
+	}
+
+	switches, err := c.c.Client.Adminv2().Switch().List(ctx, &adminv2.SwitchServiceListRequest{
+		Query: query,
+	})
+	if err != nil {
+		return err
+	}
+
+	connected, err := c.c.Client.Adminv2().Switch().ConnectedMachines(ctx, &adminv2.SwitchServiceConnectedMachinesRequest{
+		Query: query,
+		MachineQuery: &apiv2.MachineQuery{
+			Partition: pointer.PointerOrNil(viper.GetString("partition")),
+			Rack:      pointer.PointerOrNil(viper.GetString("rack")),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	topology := helpers.NewSwitchTopology(switches.Switches, connected.SwitchesWithMachines)
+
+	switch format {
+	case "dot":
+		_, err = fmt.Fprint(c.c.Out, topology.DOT())
+	case "mermaid":
+		_, err = fmt.Fprint(c.c.Out, topology.Mermaid())
+	case "json":
+		enc := json.NewEncoder(c.c.Out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(topology)
+	}
+
+	return err
+}
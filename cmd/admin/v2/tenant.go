@@ -7,6 +7,7 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -58,7 +59,7 @@ func newTenantCmd(c *config.Config) *cobra.Command {
 		ValidArgsFn: w.c.Completion.AdminTenant,
 	}
 
-	return genericcli.NewCmds(cmdsConfig, newAddMemberCmd(c))
+	return filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, newAddMemberCmd(c)))
 }
 
 func (c *tenant) Get(id string) (*apiv2.Tenant, error) {
@@ -9,6 +9,7 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -130,7 +131,7 @@ func newTokenCmd(c *config.Config) *cobra.Command {
 
 		ValidArgsFn: w.c.Completion.Token,
 	}
-	return genericcli.NewCmds(cmdsConfig)
+	return filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig))
 }
 
 func (t *token) Get(id string) (*apiv2.Token, error) {
@@ -6,9 +6,13 @@ import (
 	"time"
 
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
+	"github.com/metal-stack/cli/cmd/watch"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
@@ -52,24 +56,37 @@ func newVPNCmd(c *config.Config) *cobra.Command {
 		ValidArgsFunction: c.Completion.Project,
 	}
 	authKeyCmd.Flags().String("project", "", "the project for which the authkey should be generated")
-	authKeyCmd.Flags().String("reason", "", "the reason why the authkey should be generated")
+	addReasonFlags(authKeyCmd, "the reason why the authkey should be generated")
 	authKeyCmd.Flags().Bool("ephemeral", true, "ephemeral defines if the key can only be used once")
 	authKeyCmd.Flags().Duration("expires", 1*time.Hour, "the duration after the generated key is not valid anymore")
 	genericcli.Must(authKeyCmd.MarkFlagRequired("project"))
 	genericcli.Must(authKeyCmd.RegisterFlagCompletionFunc("project", c.Completion.Project))
 
-	return genericcli.NewCmds(cmdsConfig, authKeyCmd)
+	return watch.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, authKeyCmd)))
 }
 
 func (v *vpn) authKey() error {
 	ctx, cancel := v.c.NewRequestContext()
 	defer cancel()
 
+	reason, err := accessReason(v.c)
+	if err != nil {
+		return err
+	}
+
+	recorder, err := openAccessRecorder(v.c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = recorder.Close()
+	}()
+
 	req := &adminv2.VPNServiceAuthKeyRequest{
 		Project:   viper.GetString("project"),
 		Ephemeral: viper.GetBool("ephemeral"),
 		Expires:   durationpb.New(viper.GetDuration("expires")),
-		Reason:    viper.GetString("reason"),
+		Reason:    reason,
 	}
 
 	resp, err := v.c.Client.Adminv2().VPN().AuthKey(ctx, req)
@@ -77,6 +94,13 @@ func (v *vpn) authKey() error {
 		return err
 	}
 
+	recorder.record(&helpers.AccessRecord{
+		Time:   time.Now(),
+		Method: adminv2connect.VPNServiceAuthKeyProcedure,
+		Target: req.Project,
+		Reason: reason,
+	})
+
 	_, _ = fmt.Fprintf(v.c.Out, "auth-key: %s\n", resp.AuthKey)
 	_, _ = fmt.Fprintf(v.c.Out, "vpn-endpoint: %s\n", resp.Address)
 	_, _ = fmt.Fprintf(v.c.Out, "ephemeral: %s\n", strconv.FormatBool(resp.Ephemeral))
@@ -0,0 +1,47 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func addWaitFlags(cmd *cobra.Command, subject string, timeout time.Duration) {
+	cmd.Flags().Duration("wait-timeout", timeout, "the maximum amount of time to wait for the "+subject)
+	cmd.Flags().Duration("wait-interval", 5*time.Second, "the interval in which the state of the "+subject+" is polled while waiting")
+}
+
+// poll evaluates the given condition in the given interval until it is fulfilled or the timeout expires.
+// a timeout of zero polls until the context is done, which is not considered an error.
+func poll(ctx context.Context, interval, timeout time.Duration, description string, condition func() (bool, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		done, err := condition()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if timeout <= 0 {
+				return nil
+			}
+			return fmt.Errorf("timed out after %s waiting for %s", timeout, description)
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"buf.build/go/protoyaml"
+	"github.com/fatih/color"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/tableprinters"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+)
+
+type applyCmd struct {
+	c *config.Config
+}
+
+type applyDocument struct {
+	kind   int
+	source string
+	raw    []byte
+}
+
+// applier applies and prunes the resources of a single kind through the generic cli implementation of its command.
+type applier interface {
+	kind() string
+	detect(doc map[string]any) bool
+	apply(doc *applyDocument) *tableprinters.ApplyResult
+	pruneCandidates(selector map[string]string, keep map[string]bool) ([]string, error)
+	delete(id string) *tableprinters.ApplyResult
+}
+
+type resourceApplier[C, U any, R proto.Message] struct {
+	name     string
+	crud     genericcli.CRUD[C, U, R]
+	detectFn func(doc map[string]any) bool
+}
+
+func newApplyCmd(c *config.Config) *cobra.Command {
+	w := &applyCmd{
+		c: c,
+	}
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "applies resources of different kinds from manifest files",
+		Long: `applies resources of different kinds from manifest files.
+
+The manifests can be given as a single file, a directory containing yaml files or "-" for reading from stdin. Every file may contain multiple yaml documents.
+The kind of a document is read from an optional "kind" field (project, network, ip or machine), otherwise it is derived from the fields of the document.
+
+Resources are applied in order of their dependencies: projects, networks, ips and machines. Resources that do not exist yet are created, existing ones are updated.
+
+With --prune, resources carrying the labels given by --selector that are not contained in the manifests anymore are deleted in reverse order.
+Only kinds which are contained in the manifests are considered for pruning.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.apply()
+		},
+	}
+
+	applyCmd.Flags().StringP("file", "f", "", "filename, directory or - for stdin containing the manifests to apply")
+	applyCmd.Flags().Bool("prune", false, "deletes resources matching the selector which are not contained in the manifests")
+	applyCmd.Flags().StringSliceP("selector", "l", nil, "labels identifying the resources managed by the manifests, required for pruning")
+	applyCmd.Flags().StringP("project", "p", "", "project in which resources are pruned, defaults to the project of the current context")
+	applyCmd.Flags().Bool("skip-security-prompts", false, "skips the confirmation prompt before pruning resources")
+
+	genericcli.Must(applyCmd.MarkFlagRequired("file"))
+	genericcli.Must(applyCmd.RegisterFlagCompletionFunc("project", c.Completion.Project))
+
+	return applyCmd
+}
+
+// appliers returns the supported kinds in order of their dependencies.
+func (c *applyCmd) appliers() []applier {
+	return []applier{
+		&resourceApplier[*apiv2.ProjectServiceCreateRequest, *apiv2.ProjectServiceUpdateRequest, *apiv2.Project]{
+			name: "project",
+			crud: &project{c: c.c},
+			detectFn: func(doc map[string]any) bool {
+				return hasKey(doc, "tenant")
+			},
+		},
+		&resourceApplier[*apiv2.NetworkServiceCreateRequest, *apiv2.NetworkServiceUpdateRequest, *apiv2.Network]{
+			name: "network",
+			crud: &networkCmd{c: c.c},
+			detectFn: func(doc map[string]any) bool {
+				return hasKey(doc, "prefixes")
+			},
+		},
+		&resourceApplier[*apiv2.IPServiceCreateRequest, *apiv2.IPServiceUpdateRequest, *apiv2.IP]{
+			name: "ip",
+			crud: &ip{c: c.c},
+			detectFn: func(doc map[string]any) bool {
+				return hasKey(doc, "ip") && hasKey(doc, "network")
+			},
+		},
+		&resourceApplier[*apiv2.MachineServiceCreateRequest, *apiv2.MachineServiceUpdateRequest, *apiv2.Machine]{
+			name: "machine",
+			crud: &machine{c: c.c},
+			detectFn: func(doc map[string]any) bool {
+				return hasKey(doc, "allocation") || hasKey(doc, "hardware")
+			},
+		},
+	}
+}
+
+func (c *applyCmd) apply() error {
+	var (
+		appliers = c.appliers()
+		prune    = viper.GetBool("prune")
+		results  []*tableprinters.ApplyResult
+		failed   int
+	)
+
+	selector, err := genericcli.LabelsToMap(viper.GetStringSlice("selector"))
+	if err != nil {
+		return err
+	}
+
+	if prune && len(selector) == 0 {
+		return fmt.Errorf("pruning requires a label selector, please provide --selector")
+	}
+
+	docs, err := c.readDocuments(viper.GetString("file"), appliers)
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(docs, func(a, b *applyDocument) int {
+		return a.kind - b.kind
+	})
+
+	keep := make([]map[string]bool, len(appliers))
+	for i := range keep {
+		keep[i] = map[string]bool{}
+	}
+
+	for _, doc := range docs {
+		result := appliers[doc.kind].apply(doc)
+		if result.Error != nil {
+			failed++
+		}
+
+		keep[doc.kind][result.ID] = true
+		results = append(results, result)
+	}
+
+	if prune {
+		pruned, pruneFailed, err := c.prune(appliers, selector, keep, failed > 0)
+		if err != nil {
+			return err
+		}
+
+		failed += pruneFailed
+		results = append(results, pruned...)
+	}
+
+	err = c.c.ListPrinter.Print(results)
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d resources could not be applied", failed, len(results))
+	}
+
+	return nil
+}
+
+func (c *applyCmd) prune(appliers []applier, selector map[string]string, keep []map[string]bool, applyFailed bool) ([]*tableprinters.ApplyResult, int, error) {
+	if applyFailed {
+		_, _ = fmt.Fprintf(c.c.PromptOut, "%s not all resources could be applied, skipping prune\n", color.YellowString("⚠"))
+		return nil, 0, nil
+	}
+
+	type candidate struct {
+		applier applier
+		id      string
+	}
+
+	var candidates []candidate
+
+	for i := len(appliers) - 1; i >= 0; i-- {
+		// only kinds contained in the manifests are pruned
+		if len(keep[i]) == 0 {
+			continue
+		}
+
+		ids, err := appliers[i].pruneCandidates(selector, keep[i])
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to determine %ss to prune: %w", appliers[i].kind(), err)
+		}
+
+		for _, id := range ids {
+			candidates = append(candidates, candidate{applier: appliers[i], id: id})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, 0, nil
+	}
+
+	if !viper.GetBool("skip-security-prompts") {
+		var names []string
+		for _, cand := range candidates {
+			names = append(names, fmt.Sprintf("  %s %s", cand.applier.kind(), cand.id))
+		}
+
+		err := genericcli.PromptCustom(&genericcli.PromptConfig{
+			ShowAnswers: true,
+			Message:     fmt.Sprintf("The following resources are not contained in the manifests anymore and will be deleted:\n%s\nDo you want to continue?", strings.Join(names, "\n")),
+			In:          c.c.In,
+			Out:         c.c.PromptOut,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var (
+		results []*tableprinters.ApplyResult
+		failed  int
+	)
+
+	for _, cand := range candidates {
+		result := cand.applier.delete(cand.id)
+		if result.Error != nil {
+			failed++
+		}
+
+		results = append(results, result)
+	}
+
+	return results, failed, nil
+}
+
+func (c *applyCmd) readDocuments(from string, appliers []applier) ([]*applyDocument, error) {
+	if from == "-" {
+		data, err := io.ReadAll(c.c.In)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read from stdin: %w", err)
+		}
+
+		return splitDocuments("stdin", data, appliers)
+	}
+
+	files := []string{from}
+
+	isDir, err := c.c.Fs.IsDir(from)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %q: %w", from, err)
+	}
+
+	if isDir {
+		infos, err := c.c.Fs.ReadDir(from)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read directory %q: %w", from, err)
+		}
+
+		files = nil
+		for _, info := range infos {
+			switch filepath.Ext(info.Name()) {
+			case ".yaml", ".yml", ".json":
+				if !info.IsDir() {
+					files = append(files, filepath.Join(from, info.Name()))
+				}
+			}
+		}
+	}
+
+	var docs []*applyDocument
+
+	for _, file := range files {
+		data, err := c.c.Fs.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %w", file, err)
+		}
+
+		fileDocs, err := splitDocuments(file, data, appliers)
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, fileDocs...)
+	}
+
+	return docs, nil
+}
+
+func splitDocuments(source string, data []byte, appliers []applier) ([]*applyDocument, error) {
+	raws, err := helpers.SplitYAMLDocuments(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", source, err)
+	}
+
+	var docs []*applyDocument
+
+	for index, raw := range raws {
+		doc, err := detectDocument(fmt.Sprintf("%s[%d]", source, index), raw, appliers)
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func detectDocument(source string, raw []byte, appliers []applier) (*applyDocument, error) {
+	var fields map[string]any
+	err := yaml.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse document %s: %w", source, err)
+	}
+
+	if kind, ok := fields["kind"]; ok {
+		name, _ := kind.(string)
+
+		for i, a := range appliers {
+			if strings.EqualFold(a.kind(), name) {
+				delete(fields, "kind")
+
+				raw, err = yaml.Marshal(fields)
+				if err != nil {
+					return nil, err
+				}
+
+				return &applyDocument{kind: i, source: source, raw: raw}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unsupported kind %q in document %s", name, source)
+	}
+
+	for i, a := range appliers {
+		if a.detect(fields) {
+			return &applyDocument{kind: i, source: source, raw: raw}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unable to detect the kind of document %s, please provide a kind field", source)
+}
+
+func hasKey(doc map[string]any, key string) bool {
+	_, ok := doc[key]
+	return ok
+}
+
+func (a *resourceApplier[C, U, R]) kind() string {
+	return a.name
+}
+
+func (a *resourceApplier[C, U, R]) detect(doc map[string]any) bool {
+	return a.detectFn(doc)
+}
+
+func (a *resourceApplier[C, U, R]) apply(doc *applyDocument) *tableprinters.ApplyResult {
+	var (
+		start  = time.Now()
+		result = &tableprinters.ApplyResult{
+			Kind:   a.name,
+			Source: doc.source,
+		}
+		zero R
+	)
+
+	r := zero.ProtoReflect().Type().New().Interface().(R)
+
+	err := protoyaml.Unmarshal(doc.raw, r)
+	if err != nil {
+		result.Action = genericcli.BulkErrorOnCreate
+		result.Error = fmt.Errorf("unable to unmarshal %s: %w", a.name, err)
+		return result
+	}
+
+	id, createRq, updateRq, err := a.crud.Convert(r)
+	if err != nil {
+		result.Action = genericcli.BulkErrorOnCreate
+		result.Error = err
+		return result
+	}
+
+	result.ID = id
+
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	created, err := a.crud.Create(createRq)
+	if err == nil {
+		result.Action = genericcli.BulkCreated
+		result.ID = a.id(created, id)
+		return result
+	}
+
+	if !errors.Is(err, genericcli.AlreadyExistsError()) {
+		result.Action = genericcli.BulkErrorOnCreate
+		result.Error = err
+		return result
+	}
+
+	updated, err := a.crud.Update(updateRq)
+	if err != nil {
+		result.Action = genericcli.BulkErrorOnUpdate
+		result.Error = err
+		return result
+	}
+
+	result.Action = genericcli.BulkUpdated
+	result.ID = a.id(updated, id)
+
+	return result
+}
+
+// id returns the id of the given entity as returned by convert, falling back to the given id.
+func (a *resourceApplier[C, U, R]) id(r R, fallback string) string {
+	id, _, _, err := a.crud.Convert(r)
+	if err != nil || id == "" {
+		return fallback
+	}
+
+	return id
+}
+
+func (a *resourceApplier[C, U, R]) pruneCandidates(selector map[string]string, keep map[string]bool) ([]string, error) {
+	items, err := a.crud.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	for _, item := range items {
+		if !matchesSelector(item, selector) {
+			continue
+		}
+
+		id, _, _, err := a.crud.Convert(item)
+		if err != nil {
+			return nil, err
+		}
+
+		if !keep[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (a *resourceApplier[C, U, R]) delete(id string) *tableprinters.ApplyResult {
+	var (
+		start  = time.Now()
+		result = &tableprinters.ApplyResult{
+			Kind:   a.name,
+			ID:     id,
+			Action: genericcli.BulkDeleted,
+		}
+	)
+
+	_, err := a.crud.Delete(id)
+	if err != nil {
+		result.Action = genericcli.BulkErrorOnDelete
+		result.Error = err
+	}
+
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+func matchesSelector(m proto.Message, selector map[string]string) bool {
+	withMeta, ok := m.(interface{ GetMeta() *apiv2.Meta })
+	if !ok {
+		return false
+	}
+
+	labels := withMeta.GetMeta().GetLabels().GetLabels()
+
+	for k, v := range selector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+
+	return true
+}
@@ -6,6 +6,7 @@ import (
 )
 
 func AddCmds(cmd *cobra.Command, c *config.Config) {
+	cmd.AddCommand(newApplyCmd(c))
 	cmd.AddCommand(newAuditCmd(c))
 	cmd.AddCommand(newHealthCmd(c))
 	cmd.AddCommand(newImageCmd(c))
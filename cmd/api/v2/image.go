@@ -5,6 +5,7 @@ import (
 
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
@@ -57,7 +58,7 @@ func newImageCmd(c *config.Config) *cobra.Command {
 
 	latestCmd.Flags().StringP("os", "", "", "find latest image for this os")
 
-	return genericcli.NewCmds(cmdsConfig, latestCmd)
+	return filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, latestCmd))
 }
 
 func (c *image) Get(id string) (*apiv2.Image, error) {
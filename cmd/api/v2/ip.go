@@ -4,7 +4,10 @@ import (
 	"github.com/metal-stack/api/go/errorutil"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
+	"github.com/metal-stack/cli/cmd/watch"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
@@ -75,7 +78,7 @@ func newIPCmd(c *config.Config) *cobra.Command {
 		ValidArgsFn:          c.Completion.Ip,
 	}
 
-	return genericcli.NewCmds(cmdsConfig)
+	return dryrun.Enable(c, cmdsConfig, watch.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig))))
 }
 
 func (c *ip) createFromCLI() (*apiv2.IPServiceCreateRequest, error) {
@@ -173,10 +176,20 @@ func (c *ip) Get(id string) (*apiv2.IP, error) {
 	ctx, cancel := c.c.NewRequestContext()
 	defer cancel()
 
-	resp, err := c.c.Client.Apiv2().IP().Get(ctx, &apiv2.IPServiceGetRequest{
+	req := &apiv2.IPServiceGetRequest{
 		Project: c.c.GetProject(),
 		Ip:      id,
-	})
+	}
+
+	if viper.IsSet("file") {
+		var err error
+		req.Ip, req.Project, err = helpers.DecodeProject(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.c.Client.Apiv2().IP().Get(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,8 @@ import (
 	"github.com/metal-stack/api/go/errorutil"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -101,17 +103,27 @@ func newNetworkCmd(c *config.Config) *cobra.Command {
 	}
 	listFlags(listBaseNetworksCmd)
 
-	return genericcli.NewCmds(cmdsConfig, listBaseNetworksCmd)
+	return dryrun.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, listBaseNetworksCmd)))
 }
 
 func (c *networkCmd) Get(id string) (*apiv2.Network, error) {
 	ctx, cancel := c.c.NewRequestContext()
 	defer cancel()
 
-	resp, err := c.c.Client.Apiv2().Network().Get(ctx, &apiv2.NetworkServiceGetRequest{
+	req := &apiv2.NetworkServiceGetRequest{
 		Id:      id,
 		Project: c.c.GetProject(),
-	})
+	}
+
+	if viper.IsSet("file") {
+		var err error
+		req.Id, req.Project, err = helpers.DecodeProject(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.c.Client.Apiv2().Network().Get(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,8 @@ import (
 	"github.com/metal-stack/api/go/errorutil"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -164,7 +166,7 @@ func newProjectCmd(c *config.Config) *cobra.Command {
 
 	memberCmd.AddCommand(removeMemberCmd, updateMemberCmd, listMembersCmd)
 
-	return genericcli.NewCmds(cmdsConfig, joinProjectCmd, inviteCmd, memberCmd)
+	return dryrun.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, joinProjectCmd, inviteCmd, memberCmd)))
 }
 
 func (c *project) Get(id string) (*apiv2.Project, error) {
@@ -8,6 +8,8 @@ import (
 	"github.com/metal-stack/api/go/errorutil"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
@@ -163,7 +165,7 @@ func newTenantCmd(c *config.Config) *cobra.Command {
 
 	inviteCmd.AddCommand(generateInviteCmd, deleteInviteCmd, listInvitesCmd, joinTenantCmd)
 
-	return genericcli.NewCmds(cmdsConfig, joinTenantCmd, inviteCmd, memberCmd)
+	return dryrun.Enable(c, cmdsConfig, filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, joinTenantCmd, inviteCmd, memberCmd)))
 }
 
 func (c *tenant) Get(id string) (*apiv2.Tenant, error) {
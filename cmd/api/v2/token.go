@@ -2,19 +2,23 @@ package v2
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
 	"github.com/metal-stack/cli/cmd/sorters"
 	"github.com/metal-stack/cli/pkg/helpers"
+	helpersaudit "github.com/metal-stack/cli/pkg/helpers/audit"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type token struct {
@@ -36,20 +40,7 @@ func newTokenCmd(c *config.Config) *cobra.Command {
 		DescribePrinter: func() printers.Printer { return c.DescribePrinter },
 		ListPrinter:     func() printers.Printer { return c.ListPrinter },
 		CreateRequestFromCLI: func() (*apiv2.TokenServiceCreateRequest, error) {
-			var methodPermissions []*apiv2.MethodPermission
-			for _, m := range viper.GetStringSlice("permissions") {
-				subject, colonSeparatedMethods, ok := strings.Cut(m, "=")
-				if !ok {
-					colonSeparatedMethods = subject
-				}
-
-				methodPermissions = append(methodPermissions, &apiv2.MethodPermission{
-					Subject: subject,
-					Methods: strings.Split(colonSeparatedMethods, ":"),
-				})
-			}
-
-			perms, err := helpers.ToPermissionsByVisibility(methodPermissions)
+			perms, err := helpers.ToPermissionsByVisibility(helpers.ToMethodPermissions(viper.GetStringSlice("permissions")))
 			if err != nil {
 				return nil, err
 			}
@@ -96,7 +87,190 @@ func newTokenCmd(c *config.Config) *cobra.Command {
 		},
 		ValidArgsFn: w.c.Completion.Token,
 	}
-	return genericcli.NewCmds(cmdsConfig)
+	canICmd := &cobra.Command{
+		Use:   "can-i <method|command>",
+		Short: "checks if the current token is allowed to call an api method",
+		Long: `checks if the current token is allowed to call an api method and shows the role or permission granting the access.
+
+the api method can be given in full (/metalstack.api.v2.MachineService/Delete), without package (MachineService/Delete) or as cli command (machine delete, admin machine list).
+methods of projects are checked against the project of the current context unless --project is given, methods of tenants require --tenant and methods of machines require --machine.
+if the method is not granted, the command exits with an error.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.canI(args)
+		},
+	}
+
+	canICmd.Flags().StringP("project", "p", "", "the project on which the method is called, defaults to the project of the current context")
+	canICmd.Flags().String("tenant", "", "the tenant on which the method is called")
+	canICmd.Flags().String("machine", "", "the machine on which the method is called")
+	genericcli.Must(canICmd.RegisterFlagCompletionFunc("project", c.Completion.Project))
+	genericcli.Must(canICmd.RegisterFlagCompletionFunc("tenant", c.Completion.Tenant))
+	genericcli.Must(canICmd.RegisterFlagCompletionFunc("machine", c.Completion.Machine))
+
+	suggestCmd := &cobra.Command{
+		Use:   "suggest",
+		Short: "suggests a token with the least permissions required for the observed api calls",
+		Long: `suggests a token with the least permissions required for the api calls found in the audit traces of a user.
+
+audit traces do not contain the token used for an api call, so the calls are looked up for the user of the token given by --from-token or for the user given by --user.
+with --from-token, only calls within the validity of the token which the token is allowed to make are considered, so the suggestion never grants more than the token.
+with --user, the calls of all tokens of the user are considered.
+methods are granted on the project or tenant they were called on. the suggestion is printed as token which can be created with token apply -f, alternatively --print-flags prints the token create command.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.suggest()
+		},
+	}
+
+	suggestCmd.Flags().String("from-token", "", "the id of the token whose api calls are used for the suggestion")
+	suggestCmd.Flags().String("user", "", "the user whose api calls are used for the suggestion")
+	suggestCmd.Flags().String("tenant", "", "the tenant of the audit traces, defaults to the tenant of the current project")
+	suggestCmd.Flags().String("since", "7d", "the duration to look back for api calls, e.g. 7d, 12h")
+	suggestCmd.Flags().String("description", "", "the description of the suggested token")
+	suggestCmd.Flags().Duration("expires", 8*time.Hour, "the duration how long the suggested token is valid")
+	suggestCmd.Flags().Bool("print-flags", false, "prints the token create command instead of the token")
+	suggestCmd.MarkFlagsMutuallyExclusive("from-token", "user")
+	suggestCmd.MarkFlagsOneRequired("from-token", "user")
+	genericcli.Must(suggestCmd.RegisterFlagCompletionFunc("from-token", c.Completion.Token))
+	genericcli.Must(suggestCmd.RegisterFlagCompletionFunc("tenant", c.Completion.Tenant))
+
+	return filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, canICmd, suggestCmd, newTokenRotateCmd(c, w)))
+}
+
+func (c *token) canI(args []string) error {
+	method, err := helpers.ResolveMethod(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	visibility, err := helpers.MethodVisibility(method)
+	if err != nil {
+		return err
+	}
+
+	var subject string
+	switch visibility {
+	case helpers.VisibilityProject:
+		subject = c.c.GetProject()
+	case helpers.VisibilityTenant:
+		subject = viper.GetString("tenant")
+	case helpers.VisibilityMachine:
+		subject = viper.GetString("machine")
+	}
+
+	ctx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	resp, err := c.c.Client.Apiv2().Method().TokenScopedList(ctx, &apiv2.MethodServiceTokenScopedListRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list methods: %w", err)
+	}
+
+	access, err := helpers.CanI(resp, method, subject)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case access.Allowed:
+		_, _ = fmt.Fprintf(c.c.Out, "yes, %s is granted by %s\n", access.Method, access.GrantedBy)
+		return nil
+	case access.Subject != "":
+		return fmt.Errorf("no, %s is not granted on %s %s", access.Method, access.Visibility, access.Subject)
+	default:
+		return fmt.Errorf("no, %s is not granted", access.Method)
+	}
+}
+
+func (c *token) suggest() error {
+	since, err := helpers.ParseDuration(viper.GetString("since"))
+	if err != nil {
+		return err
+	}
+
+	var (
+		now         = time.Now()
+		user        = viper.GetString("user")
+		description = viper.GetString("description")
+		fromToken   *apiv2.Token
+		query       = &apiv2.AuditQuery{
+			From:  timestamppb.New(now.Add(-since)),
+			Phase: new(apiv2.AuditPhase_AUDIT_PHASE_REQUEST),
+		}
+	)
+
+	if id := viper.GetString("from-token"); id != "" {
+		fromToken, err = c.Get(id)
+		if err != nil {
+			return err
+		}
+
+		user = fromToken.User
+		if description == "" {
+			description = fmt.Sprintf("least privilege replacement of token %s", fromToken.Uuid)
+		}
+
+		if issued := fromToken.GetIssuedAt(); issued != nil && issued.AsTime().After(query.From.AsTime()) {
+			query.From = issued
+		}
+		if expires := fromToken.GetExpires(); expires != nil && expires.AsTime().Before(now) {
+			query.To = expires
+		}
+	}
+
+	query.User = &user
+
+	tenant, err := c.c.GetTenant()
+	if err != nil {
+		return fmt.Errorf("tenant is required: %w", err)
+	}
+
+	traces, err := helpersaudit.ListAll(query, now, func(query *apiv2.AuditQuery) ([]*apiv2.AuditTrace, error) {
+		ctx, cancel := c.c.NewRequestContext()
+		defer cancel()
+
+		resp, err := c.c.Client.Apiv2().Audit().List(ctx, &apiv2.AuditServiceListRequest{
+			Login: tenant,
+			Query: query,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit traces: %w", err)
+		}
+
+		return resp.Traces, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if fromToken != nil {
+		traces = helpers.GrantedTraces(fromToken, traces)
+	}
+
+	permissions := helpers.FromMethodPermissions(helpers.SuggestPermissions(traces))
+	if len(permissions) == 0 {
+		return fmt.Errorf("no api calls requiring permissions found for user %q in the last %s", user, viper.GetString("since"))
+	}
+
+	if viper.GetBool("print-flags") {
+		args := []string{config.BinaryName, "token", "create"}
+		if description != "" {
+			args = append(args, "--description", strconv.Quote(description))
+		}
+		args = append(args, "--expires", viper.GetDuration("expires").String())
+		for _, p := range permissions {
+			args = append(args, "--permissions", p)
+		}
+
+		_, _ = fmt.Fprintln(c.c.Out, strings.Join(args, " "))
+		return nil
+	}
+
+	return c.c.DescribePrinter.Print(&apiv2.Token{
+		Description: description,
+		Permissions: helpers.ToMethodPermissions(permissions),
+		Expires:     timestamppb.New(time.Now().Add(viper.GetDuration("expires"))),
+	})
 }
 
 func (c *token) Get(id string) (*apiv2.Token, error) {
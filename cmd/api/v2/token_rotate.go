@@ -0,0 +1,245 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func newTokenRotateCmd(c *config.Config, w *token) *cobra.Command {
+	rotateCmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "replaces the token of a context with a new token having the same permissions",
+		Long: `replaces the token of a context with a new token having the same permissions and roles.
+
+the new token is verified before it is written into the context, afterwards the old token gets revoked.
+if any of these steps fails, the new token is revoked again and the context keeps the old token.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.rotate()
+		},
+	}
+
+	rotateCmd.Flags().String("context", "", "the name of the context whose token is rotated, defaults to the current context")
+	rotateCmd.Flags().Duration("all-expiring-within", 0, "rotates the tokens of all contexts which expire within the given duration")
+	rotateCmd.Flags().Duration("expires", 0, "the duration how long the new token is valid, defaults to the lifetime of the rotated token")
+	rotateCmd.MarkFlagsMutuallyExclusive("context", "all-expiring-within")
+	genericcli.Must(rotateCmd.RegisterFlagCompletionFunc("context", c.ContextListCompletion))
+
+	return rotateCmd
+}
+
+func (c *token) rotate() error {
+	if viper.GetString("api-token") != "" {
+		return fmt.Errorf("only tokens stored in a context can be rotated, --api-token cannot be used")
+	}
+
+	ctxs, err := c.c.GetContexts()
+	if err != nil {
+		return err
+	}
+
+	var toRotate []*config.Context
+
+	if viper.IsSet("all-expiring-within") {
+		var (
+			now    = time.Now()
+			within = viper.GetDuration("all-expiring-within")
+		)
+
+		for _, ctx := range ctxs.Contexts {
+			err := ctx.ResolveToken()
+			if err != nil {
+				return err
+			}
+
+			expiry := ctx.Expiry()
+			if ctx.Token == "" || expiry == nil || !expiry.After(now) || expiry.Sub(now) > within {
+				continue
+			}
+
+			toRotate = append(toRotate, ctx)
+		}
+
+		if len(toRotate) == 0 {
+			_, _ = fmt.Fprintf(c.c.Out, "no context token expires within %s\n", within)
+			return nil
+		}
+	} else {
+		name := viper.GetString("context")
+		if name == "" {
+			name = c.c.Context.Name
+		}
+
+		ctx, ok := ctxs.Get(name)
+		if !ok {
+			return fmt.Errorf("no context with name %q found", name)
+		}
+
+		err := ctx.ResolveToken()
+		if err != nil {
+			return err
+		}
+		if ctx.Token == "" {
+			return fmt.Errorf("context %q has no token, please login first", name)
+		}
+
+		toRotate = append(toRotate, ctx)
+	}
+
+	var errs []error
+	for _, ctx := range toRotate {
+		err := c.rotateContext(ctxs, ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to rotate token of context %q: %w", ctx.Name, err))
+			continue
+		}
+
+		if ctx.TokenExpiry == nil {
+			_, _ = fmt.Fprintf(c.c.Out, "%s rotated token of context \"%s\"\n", color.GreenString("✔"), color.GreenString(ctx.Name))
+			continue
+		}
+
+		_, _ = fmt.Fprintf(c.c.Out, "%s rotated token of context \"%s\", the new token expires at %s\n", color.GreenString("✔"), color.GreenString(ctx.Name), ctx.TokenExpiry.Format(time.RFC3339))
+	}
+
+	return errors.Join(errs...)
+}
+
+// rotateContext replaces the token of the given context with a new token having the same permissions and roles.
+// in case one of the steps fails, the new token is revoked and the context is restored with the old token.
+func (c *token) rotateContext(ctxs *config.Contexts, ctx *config.Context) error {
+	id, err := config.TokenID(ctx.Token)
+	if err != nil {
+		return err
+	}
+
+	apiURL := c.c.GetContextApiURL(ctx)
+
+	oldClient, err := c.c.NewClient(apiURL, ctx.Token)
+	if err != nil {
+		return err
+	}
+
+	reqCtx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	getResp, err := oldClient.Apiv2().Token().Get(reqCtx, &apiv2.TokenServiceGetRequest{
+		Uuid: id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+
+	old := getResp.GetToken()
+
+	perms, err := helpers.ToPermissionsByVisibility(old.Permissions)
+	if err != nil {
+		return err
+	}
+
+	expires := viper.GetDuration("expires")
+	if expires <= 0 {
+		expires = old.GetExpires().AsTime().Sub(old.GetIssuedAt().AsTime())
+	}
+	if expires <= 0 {
+		return fmt.Errorf("unable to determine the lifetime of the token, please specify --expires")
+	}
+
+	createResp, err := oldClient.Apiv2().Token().Create(reqCtx, &apiv2.TokenServiceCreateRequest{
+		Description:  old.Description,
+		Permissions:  perms,
+		ProjectRoles: old.ProjectRoles,
+		TenantRoles:  old.TenantRoles,
+		AdminRole:    old.AdminRole,
+		Expires:      durationpb.New(expires),
+		Labels:       pointer.SafeDeref(old.Meta).Labels,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+
+	rollback := func(cause error) error {
+		_, err := oldClient.Apiv2().Token().Revoke(reqCtx, &apiv2.TokenServiceRevokeRequest{
+			Uuid: createResp.GetToken().GetUuid(),
+		})
+		if err != nil {
+			return errors.Join(cause, fmt.Errorf("failed to revoke new token %s on rollback: %w", createResp.GetToken().GetUuid(), err))
+		}
+
+		return cause
+	}
+
+	newClient, err := c.c.NewClient(apiURL, createResp.Secret)
+	if err != nil {
+		return rollback(err)
+	}
+
+	_, err = newClient.Apiv2().User().Get(reqCtx, &apiv2.UserServiceGetRequest{})
+	if err != nil {
+		return rollback(fmt.Errorf("failed to verify new token: %w", err))
+	}
+
+	oldToken, oldExpiry := ctx.Token, ctx.TokenExpiry
+
+	// WriteContexts stores the token in the credential store before writing the config file, so the store
+	// may hold the new token even if writing failed. it must hold the old token again before the new one is revoked.
+	restoreStore := func() error {
+		store, err := ctx.CredentialStore()
+		if err != nil {
+			return err
+		}
+		if store == nil {
+			return nil
+		}
+
+		return store.Store(ctx.CredentialKey(), oldToken)
+	}
+
+	ctx.SetToken(createResp.Secret)
+	if createResp.GetToken().GetExpires() != nil {
+		ctx.TokenExpiry = new(createResp.GetToken().GetExpires().AsTime())
+	}
+
+	err = c.c.WriteContexts(ctxs)
+	if err != nil {
+		ctx.Token, ctx.TokenExpiry = oldToken, oldExpiry
+
+		restoreErr := restoreStore()
+		if restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("unable to restore the old token, the new token is not revoked: %w", restoreErr))
+		}
+
+		return rollback(err)
+	}
+
+	_, err = newClient.Apiv2().Token().Revoke(reqCtx, &apiv2.TokenServiceRevokeRequest{
+		Uuid: id,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to revoke old token: %w", err)
+
+		ctx.Token, ctx.TokenExpiry = oldToken, oldExpiry
+
+		restoreErr := restoreStore()
+		if restoreErr == nil {
+			restoreErr = c.c.WriteContexts(ctxs)
+		}
+		if restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("unable to restore the old token, the new token is not revoked: %w", restoreErr))
+		}
+
+		return rollback(err)
+	}
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/spf13/cobra"
@@ -37,7 +39,28 @@ func newUserCmd(c *config.Config) *cobra.Command {
 		},
 	}
 
-	return genericcli.NewCmds(cmdsConfig)
+	permissionsCmd := &cobra.Command{
+		Use:   "permissions",
+		Short: "shows the effective permissions of the current token",
+		Long:  "shows all api methods of project, tenant and admin visibility along with the role or permission granting the current token access to them on every project and tenant the token has access to.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.permissions()
+		},
+	}
+
+	return filter.Enable(cmdsConfig, genericcli.NewCmds(cmdsConfig, permissionsCmd))
+}
+
+func (c *user) permissions() error {
+	ctx, cancel := c.c.NewRequestContext()
+	defer cancel()
+
+	resp, err := c.c.Client.Apiv2().Method().TokenScopedList(ctx, &apiv2.MethodServiceTokenScopedListRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list methods: %w", err)
+	}
+
+	return c.c.ListPrinter.Print(helpers.NewPermissionMatrix(resp))
 }
 
 func (c *user) Get(id string) (*apiv2.User, error) {
@@ -31,6 +31,7 @@ type Config struct {
 	Out             io.Writer
 	PromptOut       io.Writer
 	Client          client.Client
+	NewClient       func(apiURL, token string) (client.Client, error)
 	ListPrinter     printers.Printer
 	DescribePrinter printers.Printer
 	Completion      *completion.Completion
@@ -119,6 +120,15 @@ func (c *Config) GetApiURL() string {
 	return viper.GetString("api-url")
 }
 
+// GetContextApiURL returns the api url of the given context, it is overridden by the api-url flag.
+func (c *Config) GetContextApiURL(ctx *Context) string {
+	if viper.GetString("api-url") == "" && ctx.ApiURL != nil {
+		return *ctx.ApiURL
+	}
+
+	return c.GetApiURL()
+}
+
 func (c *Config) GetProvider() string {
 	if viper.GetString("provider") != "" {
 		return viper.GetString("provider")
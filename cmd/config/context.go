@@ -30,6 +30,45 @@ type Context struct {
 	DefaultProject string         `json:"default-project"`
 	Timeout        *time.Duration `json:"timeout,omitempty"`
 	Provider       string         `json:"provider"`
+	// TokenExpiry is the expiry of the token, used for warning before the token expires
+	TokenExpiry *time.Time `json:"token-expiry,omitempty"`
+	// AdminToken is a short-lived token with an admin role issued by admin elevate, it is only used for admin commands
+	AdminToken string `json:"admin-token,omitempty"`
+	// AdminTokenExpiry is the expiry of the admin token, afterwards admin commands use the token of the context again
+	AdminTokenExpiry *time.Time `json:"admin-token-expiry,omitempty"`
+	// ExpiryWarning is the duration before token expiry from which on the cli starts warning or refreshing the token
+	ExpiryWarning *time.Duration `json:"token-expiry-warning,omitempty"`
+	// AutoRefresh re-issues the token automatically when it is about to expire
+	AutoRefresh bool `json:"token-auto-refresh,omitempty"`
+	// Credentials is the credential store holding the token, in case it is not stored in this file
+	Credentials string `json:"credential-store,omitempty"`
+	// CredentialHelper is the executable used for storing the token when using the helper credential store
+	CredentialHelper string `json:"credential-helper,omitempty"`
+	// ReasonPattern is a regular expression which the reason given for privileged access has to match
+	ReasonPattern string `json:"reason-pattern,omitempty"`
+	// CapacityThresholds are the thresholds below which a size is flagged in the partition capacity forecast
+	CapacityThresholds *CapacityThresholds `json:"capacity-thresholds,omitempty"`
+	// ColumnPresets are the custom columns printed by default for a command, e.g. "machine list": "ID:.uuid,RACK:.rack"
+	ColumnPresets map[string]string `json:"column-presets,omitempty"`
+}
+
+// CapacityThresholds configure when the capacity of a size in a partition is considered too low
+type CapacityThresholds struct {
+	// MinFree is the minimum amount of free machines per size, the key "*" applies to all sizes without an explicit entry
+	MinFree map[string]int64 `json:"min-free,omitempty"`
+	// MinRunway is the minimum duration until a size is projected to run out of free machines
+	MinRunway *time.Duration `json:"min-runway,omitempty"`
+}
+
+// MinFreeForSize returns the minimum amount of free machines for the given size.
+func (t *CapacityThresholds) MinFreeForSize(size string) int64 {
+	if t == nil {
+		return 0
+	}
+	if minFree, ok := t.MinFree[size]; ok {
+		return minFree
+	}
+	return t.MinFree["*"]
 }
 
 func (cs *Contexts) Get(name string) (*Context, bool) {
@@ -90,7 +129,41 @@ func (c *Config) WriteContexts(ctxs *Contexts) error {
 		return err
 	}
 
-	raw, err := yaml.Marshal(ctxs)
+	toWrite := &Contexts{
+		CurrentContext:  ctxs.CurrentContext,
+		PreviousContext: ctxs.PreviousContext,
+	}
+
+	for _, ctx := range ctxs.Contexts {
+		ctx := *ctx
+
+		store, err := ctx.CredentialStore()
+		if err != nil {
+			return err
+		}
+
+		// tokens that are held by a credential store must not end up in the config file
+		if store != nil && ctx.Token != "" {
+			err = store.Store(ctx.CredentialKey(), ctx.Token)
+			if err != nil {
+				return fmt.Errorf("unable to store token of context %q: %w", ctx.Name, err)
+			}
+
+			ctx.Token = ""
+		}
+		if store != nil && ctx.AdminToken != "" {
+			err = store.Store(ctx.AdminCredentialKey(), ctx.AdminToken)
+			if err != nil {
+				return fmt.Errorf("unable to store admin token of context %q: %w", ctx.Name, err)
+			}
+
+			ctx.AdminToken = ""
+		}
+
+		toWrite.Contexts = append(toWrite.Contexts, &ctx)
+	}
+
+	raw, err := yaml.Marshal(toWrite)
 	if err != nil {
 		return err
 	}
@@ -127,6 +200,11 @@ func (c *Config) MustDefaultContext() Context {
 		return defaultCtx()
 	}
 
+	err = ctx.ResolveToken()
+	if err != nil && c.PromptOut != nil {
+		_, _ = fmt.Fprintf(c.PromptOut, "unable to resolve token: %s\n", err)
+	}
+
 	return *ctx
 }
 
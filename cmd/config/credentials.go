@@ -0,0 +1,287 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	// CredentialStoreFile stores the token in plaintext inside the config file
+	CredentialStoreFile = "file"
+	// CredentialStoreKeyring stores the token in the keyring of the operating system (secret service over d-bus on linux, keychain on macos)
+	CredentialStoreKeyring = "keyring"
+	// CredentialStoreHelper stores the token through an external credential helper following the docker credential helper protocol
+	CredentialStoreHelper = "helper"
+)
+
+var (
+	// CredentialStores contains all supported credential stores
+	CredentialStores = []string{CredentialStoreFile, CredentialStoreKeyring, CredentialStoreHelper}
+
+	// ErrCredentialsNotFound is returned when a credential store does not contain a token for a context
+	ErrCredentialsNotFound = errors.New("credentials not found")
+)
+
+// CredentialStore stores context tokens outside of the config file.
+type CredentialStore interface {
+	// Get returns the secret stored for the given key
+	Get(key string) (string, error)
+	// Store stores the secret for the given key
+	Store(key, secret string) error
+	// Erase removes the secret for the given key
+	Erase(key string) error
+}
+
+// CredentialStore returns the credential store used by the context, nil is returned for contexts storing their token in the config file.
+func (c *Context) CredentialStore() (CredentialStore, error) {
+	switch c.Credentials {
+	case "", CredentialStoreFile:
+		return nil, nil
+	case CredentialStoreKeyring:
+		return &keyringStore{}, nil
+	case CredentialStoreHelper:
+		if c.CredentialHelper == "" {
+			return nil, fmt.Errorf("context %q uses a credential helper but no credential-helper is configured", c.Name)
+		}
+		return &helperStore{helper: c.CredentialHelper}, nil
+	default:
+		return nil, fmt.Errorf("unsupported credential store %q, supported are: %s", c.Credentials, strings.Join(CredentialStores, ", "))
+	}
+}
+
+// CredentialKey returns the key under which the context token is stored in a credential store.
+func (c *Context) CredentialKey() string {
+	return BinaryName + "/" + c.Name
+}
+
+// AdminCredentialKey returns the key under which the admin token of the context is stored in a credential store.
+func (c *Context) AdminCredentialKey() string {
+	return c.CredentialKey() + "/admin"
+}
+
+// ResolveToken reads the token of the context from its credential store in case it is not stored in the config file.
+func (c *Context) ResolveToken() error {
+	if c.Token != "" {
+		return nil
+	}
+
+	store, err := c.CredentialStore()
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return nil
+	}
+
+	token, err := store.Get(c.CredentialKey())
+	if err != nil {
+		return fmt.Errorf("unable to read token of context %q from %s: %w", c.Name, c.Credentials, err)
+	}
+
+	c.Token = token
+
+	return nil
+}
+
+// ResolveAdminToken reads the admin token of an elevated context from its credential store in case it is not stored in the config file.
+func (c *Context) ResolveAdminToken() error {
+	if c.AdminToken != "" || c.AdminTokenExpiry == nil {
+		return nil
+	}
+
+	store, err := c.CredentialStore()
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return nil
+	}
+
+	token, err := store.Get(c.AdminCredentialKey())
+	if err != nil {
+		return fmt.Errorf("unable to read admin token of context %q from %s: %w", c.Name, c.Credentials, err)
+	}
+
+	c.AdminToken = token
+
+	return nil
+}
+
+// helperStore implements the docker credential helper protocol, see https://github.com/docker/docker-credential-helpers.
+type helperStore struct {
+	helper string
+}
+
+type helperCredentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+func (h *helperStore) Get(key string) (string, error) {
+	out, err := h.run("get", strings.NewReader(key))
+	if err != nil {
+		return "", err
+	}
+
+	var creds helperCredentials
+	err = json.Unmarshal(out, &creds)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse credential helper response: %w", err)
+	}
+
+	return creds.Secret, nil
+}
+
+func (h *helperStore) Store(key, secret string) error {
+	raw, err := json.Marshal(helperCredentials{
+		ServerURL: key,
+		Username:  BinaryName,
+		Secret:    secret,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = h.run("store", bytes.NewReader(raw))
+	return err
+}
+
+func (h *helperStore) Erase(key string) error {
+	_, err := h.run("erase", strings.NewReader(key))
+	return err
+}
+
+func (h *helperStore) run(action string, in io.Reader) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(h.helper, action) //nolint:gosec
+	cmd.Stdin = in
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(strings.ToLower(msg), "credentials not found") {
+			return nil, ErrCredentialsNotFound
+		}
+		return nil, fmt.Errorf("credential helper %q failed to %s: %w %s", h.helper, action, err, msg)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// keyringStore uses the keyring of the operating system. on macos the keychain is used through the security command,
+// which is part of the operating system, on other systems the freedesktop secret service is used over d-bus.
+type keyringStore struct{}
+
+// keyringAttributes identify the secret of the given key in the secret service.
+func keyringAttributes(key string) map[string]string {
+	return map[string]string{
+		"service": BinaryName,
+		"account": key,
+	}
+}
+
+// withSecretService runs the given function with a session of the secret service, which is closed afterwards.
+func withSecretService(fn func(s *secretService) error) error {
+	s, err := newSecretService()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.Close()
+	}()
+
+	return fn(s)
+}
+
+func (k *keyringStore) Get(key string) (string, error) {
+	if runtime.GOOS != "darwin" {
+		var secret string
+
+		err := withSecretService(func(s *secretService) error {
+			var err error
+			secret, err = s.get(keyringAttributes(key))
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		if secret == "" {
+			return "", ErrCredentialsNotFound
+		}
+
+		return secret, nil
+	}
+
+	out, err := exec.Command("security", "find-generic-password", "-s", BinaryName, "-a", key, "-w").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrCredentialsNotFound, err)
+	}
+
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", ErrCredentialsNotFound
+	}
+
+	return secret, nil
+}
+
+func (k *keyringStore) Store(key, secret string) error {
+	if runtime.GOOS != "darwin" {
+		err := withSecretService(func(s *secretService) error {
+			return s.store(key, keyringAttributes(key), secret)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to store token in keyring: %w", err)
+		}
+
+		return nil
+	}
+
+	// the secret must not be passed as an argument where it is visible to other users in the process list,
+	// therefore the command is read from stdin in interactive mode with the secret given as hex encoded password data
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", securityQuote(BinaryName), securityQuote(key), hex.EncodeToString([]byte(secret))))
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("unable to store token in keyring: %w %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (k *keyringStore) Erase(key string) error {
+	if runtime.GOOS != "darwin" {
+		err := withSecretService(func(s *secretService) error {
+			return s.erase(keyringAttributes(key))
+		})
+		if err != nil {
+			return fmt.Errorf("unable to remove token from keyring: %w", err)
+		}
+
+		return nil
+	}
+
+	out, err := exec.Command("security", "delete-generic-password", "-s", BinaryName, "-a", key).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("unable to remove token from keyring: %w %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// securityQuote quotes an argument of a command read by security in interactive mode.
+func securityQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
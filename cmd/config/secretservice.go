@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName         = "org.freedesktop.secrets"
+	secretServicePath         = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretServiceInterface    = "org.freedesktop.Secret.Service"
+	secretSessionInterface    = "org.freedesktop.Secret.Session"
+	secretCollectionInterface = "org.freedesktop.Secret.Collection"
+	secretItemInterface       = "org.freedesktop.Secret.Item"
+	secretPromptInterface     = "org.freedesktop.Secret.Prompt"
+	secretDefaultCollection   = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	// secretNoPrompt is returned by the secret service if no prompt is required
+	secretNoPrompt = dbus.ObjectPath("/")
+)
+
+// secret is the secret struct of the secret service api.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretService is a client of the freedesktop secret service api over d-bus, which is implemented by gnome-keyring and kwallet,
+// see https://specifications.freedesktop.org/secret-service-spec/latest/.
+// secrets are stored in the default collection and are identified by their attributes.
+type secretService struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath
+}
+
+func newSecretService() (*secretService, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to the d-bus session bus, the keyring requires a secret service like gnome-keyring or kwallet: %w", err)
+	}
+
+	var (
+		output  dbus.Variant
+		session dbus.ObjectPath
+	)
+
+	// the plain algorithm does not encrypt the secret, it is only transferred over the session bus of the user
+	err = conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to open a secret service session, the keyring requires a secret service like gnome-keyring or kwallet: %w", err)
+	}
+
+	return &secretService{
+		conn:    conn,
+		session: session,
+	}, nil
+}
+
+func (s *secretService) Close() error {
+	_ = s.conn.Object(secretServiceName, s.session).Call(secretSessionInterface+".Close", 0).Err
+	return s.conn.Close()
+}
+
+// get returns the secret of the first item matching the given attributes.
+func (s *secretService) get(attributes map[string]string) (string, error) {
+	items, err := s.search(attributes)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrCredentialsNotFound
+	}
+
+	var sec secret
+	err = s.conn.Object(secretServiceName, items[0]).Call(secretItemInterface+".GetSecret", 0, s.session).Store(&sec)
+	if err != nil {
+		return "", fmt.Errorf("unable to read secret: %w", err)
+	}
+
+	return string(sec.Value), nil
+}
+
+// store creates an item with the given attributes or replaces the secret of the existing one.
+func (s *secretService) store(label string, attributes map[string]string, value string) error {
+	err := s.unlock()
+	if err != nil {
+		return err
+	}
+
+	var (
+		properties = map[string]dbus.Variant{
+			secretItemInterface + ".Label":      dbus.MakeVariant(label),
+			secretItemInterface + ".Attributes": dbus.MakeVariant(attributes),
+		}
+		sec = secret{
+			Session:     s.session,
+			Parameters:  []byte{},
+			Value:       []byte(value),
+			ContentType: "text/plain; charset=utf8",
+		}
+		item   dbus.ObjectPath
+		prompt dbus.ObjectPath
+	)
+
+	err = s.conn.Object(secretServiceName, secretDefaultCollection).Call(secretCollectionInterface+".CreateItem", 0, properties, sec, true).Store(&item, &prompt)
+	if err != nil {
+		return fmt.Errorf("unable to create secret: %w", err)
+	}
+
+	return s.prompt(prompt)
+}
+
+// erase deletes all items matching the given attributes.
+func (s *secretService) erase(attributes map[string]string) error {
+	items, err := s.search(attributes)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+
+		err = s.conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt)
+		if err != nil {
+			return fmt.Errorf("unable to delete secret: %w", err)
+		}
+
+		err = s.prompt(prompt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *secretService) search(attributes map[string]string) ([]dbus.ObjectPath, error) {
+	err := s.unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []dbus.ObjectPath
+
+	err = s.conn.Object(secretServiceName, secretDefaultCollection).Call(secretCollectionInterface+".SearchItems", 0, attributes).Store(&items)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search secrets: %w", err)
+	}
+
+	return items, nil
+}
+
+// unlock unlocks the default collection, which may prompt the user for the password of the keyring.
+func (s *secretService) unlock() error {
+	var (
+		unlocked []dbus.ObjectPath
+		prompt   dbus.ObjectPath
+	)
+
+	err := s.conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".Unlock", 0, []dbus.ObjectPath{secretDefaultCollection}).Store(&unlocked, &prompt)
+	if err != nil {
+		return fmt.Errorf("unable to unlock keyring: %w", err)
+	}
+
+	return s.prompt(prompt)
+}
+
+// prompt shows the given prompt of the secret service and waits until the user completed it.
+func (s *secretService) prompt(prompt dbus.ObjectPath) error {
+	if prompt == secretNoPrompt || prompt == "" {
+		return nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+
+	err := s.conn.AddMatchSignal(match...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.conn.RemoveMatchSignal(match...)
+	}()
+
+	signals := make(chan *dbus.Signal, 1)
+	s.conn.Signal(signals)
+	defer s.conn.RemoveSignal(signals)
+
+	err = s.conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err
+	if err != nil {
+		return fmt.Errorf("unable to show keyring prompt: %w", err)
+	}
+
+	for signal := range signals {
+		if signal.Path != prompt || signal.Name != secretPromptInterface+".Completed" {
+			continue
+		}
+
+		if len(signal.Body) > 0 {
+			if dismissed, ok := signal.Body[0].(bool); ok && dismissed {
+				return fmt.Errorf("keyring prompt was dismissed")
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("connection to the secret service was closed while waiting for the keyring prompt")
+}
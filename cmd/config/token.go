@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTokenExpiryWarning is the duration before token expiry from which on the cli starts warning about the expiring token
+	DefaultTokenExpiryWarning = time.Hour
+)
+
+// TokenExpiry returns the expiry of the given token by reading the exp claim of the jwt.
+// the signature of the token is not verified, this is up to the api.
+func TokenExpiry(token string) (*time.Time, error) {
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	err := parseTokenClaims(token, &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Exp == nil {
+		return nil, errors.New("token does not contain an expiry")
+	}
+
+	exp, err := claims.Exp.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse token expiry: %w", err)
+	}
+
+	expiry := time.Unix(int64(exp), 0)
+
+	return &expiry, nil
+}
+
+// TokenID returns the id of the given token by reading the jti claim of the jwt, this is the id used for revoking the token.
+func TokenID(token string) (string, error) {
+	var claims struct {
+		Jti string `json:"jti"`
+	}
+	err := parseTokenClaims(token, &claims)
+	if err != nil {
+		return "", err
+	}
+
+	if claims.Jti == "" {
+		return "", errors.New("token does not contain an id")
+	}
+
+	return claims.Jti, nil
+}
+
+func parseTokenClaims(token string, into any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("token is not a jwt")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fmt.Errorf("unable to decode token payload: %w", err)
+	}
+
+	err = json.Unmarshal(payload, into)
+	if err != nil {
+		return fmt.Errorf("unable to parse token claims: %w", err)
+	}
+
+	return nil
+}
+
+// Expiry returns the expiry of the context token.
+// if no expiry was recorded in the context, it tries to derive it from the token.
+func (c *Context) Expiry() *time.Time {
+	if c.TokenExpiry != nil {
+		return c.TokenExpiry
+	}
+
+	expiry, err := TokenExpiry(c.Token)
+	if err != nil {
+		return nil
+	}
+
+	return expiry
+}
+
+// SetToken sets the token of the context and records its expiry if it can be derived from the token.
+func (c *Context) SetToken(token string) {
+	c.Token = token
+	c.TokenExpiry = nil
+
+	if expiry, err := TokenExpiry(token); err == nil {
+		c.TokenExpiry = expiry
+	}
+}
+
+// TokenExpiryWarning returns the duration before token expiry from which on the cli warns about the expiring token.
+func (c *Context) TokenExpiryWarning() time.Duration {
+	if c.ExpiryWarning != nil {
+		return *c.ExpiryWarning
+	}
+
+	return DefaultTokenExpiryWarning
+}
+
+// Elevated returns true if the context holds an admin token issued by admin elevate which has not yet expired.
+func (c *Context) Elevated(now time.Time) bool {
+	return c.AdminTokenExpiry != nil && now.Before(*c.AdminTokenExpiry)
+}
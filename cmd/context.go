@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/metal-stack/cli/cmd/config"
 	"github.com/metal-stack/cli/cmd/sorters"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 	"github.com/spf13/cobra"
@@ -92,6 +97,14 @@ func newContextCmd(c *config.Config) *cobra.Command {
 	contextAddCmd.Flags().Duration("timeout", 0, "sets a default request timeout")
 	contextAddCmd.Flags().Bool("activate", false, "immediately switches to the new context")
 	contextAddCmd.Flags().String("provider", "", "sets the login provider for this context")
+	contextAddCmd.Flags().Bool("token-auto-refresh", false, "re-issues the api-token automatically when it is about to expire")
+	contextAddCmd.Flags().Duration("token-expiry-warning", 0, "sets the duration before token expiry from which on the cli warns or refreshes the token (default 1h)")
+	contextAddCmd.Flags().String("credential-store", "", "sets where the api-token is stored, can be one of file|keyring|helper (default file)")
+	contextAddCmd.Flags().String("credential-helper", "", "sets the credential helper executable used for storing the api-token, implies --credential-store helper")
+	contextAddCmd.Flags().String("reason-pattern", "", "sets a regular expression which the reason given for privileged access has to match, e.g. for enforcing ticket ids")
+	contextAddCmd.Flags().StringSlice("capacity-min-free", nil, "sets the minimum amount of free machines per size flagged by the partition capacity forecast, e.g. c1-xlarge-x86=5, the size * applies to all other sizes")
+	contextAddCmd.Flags().Duration("capacity-min-runway", 0, "sets the minimum duration until a size runs out of free machines flagged by the partition capacity forecast")
+	contextAddCmd.Flags().StringArray("column-preset", nil, "sets the custom columns printed by default for a command in the form <command>=<columns>, e.g. \"machine list=ID:.uuid,RACK:.rack\", an empty value removes the preset")
 
 	genericcli.Must(contextAddCmd.MarkFlagRequired("api-token"))
 
@@ -109,9 +122,33 @@ func newContextCmd(c *config.Config) *cobra.Command {
 	contextUpdateCmd.Flags().Duration("timeout", 0, "sets a default request timeout")
 	contextUpdateCmd.Flags().Bool("activate", false, "immediately switches to the new context")
 	contextUpdateCmd.Flags().String("provider", "", "sets the login provider for this context")
+	contextUpdateCmd.Flags().Bool("token-auto-refresh", false, "re-issues the api-token automatically when it is about to expire")
+	contextUpdateCmd.Flags().Duration("token-expiry-warning", 0, "sets the duration before token expiry from which on the cli warns or refreshes the token (default 1h)")
+	contextUpdateCmd.Flags().String("credential-store", "", "sets where the api-token is stored, can be one of file|keyring|helper (default file)")
+	contextUpdateCmd.Flags().String("credential-helper", "", "sets the credential helper executable used for storing the api-token, implies --credential-store helper")
+	contextUpdateCmd.Flags().String("reason-pattern", "", "sets a regular expression which the reason given for privileged access has to match, e.g. for enforcing ticket ids")
+	contextUpdateCmd.Flags().StringSlice("capacity-min-free", nil, "sets the minimum amount of free machines per size flagged by the partition capacity forecast, e.g. c1-xlarge-x86=5, the size * applies to all other sizes")
+	contextUpdateCmd.Flags().Duration("capacity-min-runway", 0, "sets the minimum duration until a size runs out of free machines flagged by the partition capacity forecast")
+	contextUpdateCmd.Flags().StringArray("column-preset", nil, "sets the custom columns printed by default for a command in the form <command>=<columns>, e.g. \"machine list=ID:.uuid,RACK:.rack\", an empty value removes the preset")
 
 	genericcli.Must(contextUpdateCmd.RegisterFlagCompletionFunc("default-project", c.Completion.Project))
 
+	contextMigrateCredentialsCmd := &cobra.Command{
+		Use:   "migrate-credentials [<context-name>...]",
+		Short: "moves plaintext tokens from the config file into a credential store",
+		Long:  "moves the tokens of the given contexts or all contexts storing their token in plaintext into the given credential store, the config file only keeps a reference afterwards.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return w.migrateCredentials(args)
+		},
+		ValidArgsFunction: c.ContextListCompletion,
+	}
+	contextMigrateCredentialsCmd.Flags().String("credential-store", config.CredentialStoreKeyring, "the credential store to move the tokens into, can be one of keyring|helper")
+	contextMigrateCredentialsCmd.Flags().String("credential-helper", "", "the credential helper executable used for storing the tokens, implies --credential-store helper")
+
+	for _, cmd := range []*cobra.Command{contextAddCmd, contextUpdateCmd, contextMigrateCredentialsCmd} {
+		genericcli.Must(cmd.RegisterFlagCompletionFunc("credential-store", cobra.FixedCompletions(config.CredentialStores, cobra.ShellCompDirectiveNoFileComp)))
+	}
+
 	contextCmd.AddCommand(
 		contextListCmd,
 		contextSwitchCmd,
@@ -120,6 +157,7 @@ func newContextCmd(c *config.Config) *cobra.Command {
 		contextRemoveCmd,
 		contextShortCmd,
 		contextSetProjectCmd,
+		contextMigrateCredentialsCmd,
 	)
 
 	return contextCmd
@@ -169,10 +207,35 @@ func (c *ctx) add(args []string) error {
 	ctx := &config.Context{
 		Name:           name,
 		ApiURL:         pointer.PointerOrNil(viper.GetString("api-url")),
-		Token:          viper.GetString("api-token"),
 		DefaultProject: viper.GetString("default-project"),
 		Timeout:        pointer.PointerOrNil(viper.GetDuration("timeout")),
 		Provider:       viper.GetString("provider"),
+		ExpiryWarning:  pointer.PointerOrNil(viper.GetDuration("token-expiry-warning")),
+		AutoRefresh:    viper.GetBool("token-auto-refresh"),
+		ReasonPattern:  viper.GetString("reason-pattern"),
+	}
+	ctx.SetToken(viper.GetString("api-token"))
+
+	ctx.CapacityThresholds, err = capacityThresholdsFromCLI(nil)
+	if err != nil {
+		return err
+	}
+
+	ctx.ColumnPresets, err = columnPresetsFromCLI(nil)
+	if err != nil {
+		return err
+	}
+
+	store, err := credentialStoreFromCLI()
+	if err != nil {
+		return err
+	}
+	eraseOld := func() error { return nil }
+	if store != "" {
+		eraseOld, err = moveToCredentialStore(ctx, store)
+		if err != nil {
+			return err
+		}
 	}
 
 	ctxs.Contexts = append(ctxs.Contexts, ctx)
@@ -187,6 +250,11 @@ func (c *ctx) add(args []string) error {
 		return err
 	}
 
+	err = eraseOld()
+	if err != nil {
+		return err
+	}
+
 	_, _ = fmt.Fprintf(c.c.Out, "%s added context \"%s\"\n", color.GreenString("✔"), color.GreenString(ctx.Name))
 
 	return nil
@@ -211,8 +279,19 @@ func (c *ctx) update(args []string) error {
 	if viper.IsSet("api-url") {
 		ctx.ApiURL = pointer.PointerOrNil(viper.GetString("api-url"))
 	}
+	store, err := credentialStoreFromCLI()
+	if err != nil {
+		return err
+	}
+	eraseOld := func() error { return nil }
+	if store != "" {
+		eraseOld, err = moveToCredentialStore(ctx, store)
+		if err != nil {
+			return err
+		}
+	}
 	if viper.IsSet("api-token") {
-		ctx.Token = viper.GetString("api-token")
+		ctx.SetToken(viper.GetString("api-token"))
 	}
 	if viper.IsSet("default-project") {
 		ctx.DefaultProject = viper.GetString("default-project")
@@ -223,6 +302,23 @@ func (c *ctx) update(args []string) error {
 	if viper.IsSet("provider") {
 		ctx.Provider = viper.GetString("provider")
 	}
+	if viper.IsSet("token-auto-refresh") {
+		ctx.AutoRefresh = viper.GetBool("token-auto-refresh")
+	}
+	if viper.IsSet("token-expiry-warning") {
+		ctx.ExpiryWarning = pointer.PointerOrNil(viper.GetDuration("token-expiry-warning"))
+	}
+	if viper.IsSet("reason-pattern") {
+		ctx.ReasonPattern = viper.GetString("reason-pattern")
+	}
+	ctx.CapacityThresholds, err = capacityThresholdsFromCLI(ctx.CapacityThresholds)
+	if err != nil {
+		return err
+	}
+	ctx.ColumnPresets, err = columnPresetsFromCLI(ctx.ColumnPresets)
+	if err != nil {
+		return err
+	}
 	if viper.GetBool("activate") {
 		ctxs.PreviousContext = ctxs.CurrentContext
 		ctxs.CurrentContext = ctx.Name
@@ -233,6 +329,11 @@ func (c *ctx) update(args []string) error {
 		return err
 	}
 
+	err = eraseOld()
+	if err != nil {
+		return err
+	}
+
 	_, _ = fmt.Fprintf(c.c.Out, "%s updated context \"%s\"\n", color.GreenString("✔"), color.GreenString(ctx.Name))
 
 	return nil
@@ -254,6 +355,23 @@ func (c *ctx) remove(args []string) error {
 		return fmt.Errorf("no context with name %q found", name)
 	}
 
+	store, err := ctx.CredentialStore()
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		err = store.Erase(ctx.CredentialKey())
+		if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+			return fmt.Errorf("unable to remove token of context %q from credential store: %w", ctx.Name, err)
+		}
+	}
+	if store != nil && ctx.AdminTokenExpiry != nil {
+		err = store.Erase(ctx.AdminCredentialKey())
+		if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+			return fmt.Errorf("unable to remove admin token of context %q from credential store: %w", ctx.Name, err)
+		}
+	}
+
 	ctxs.Delete(ctx.Name)
 
 	err = c.c.WriteContexts(ctxs)
@@ -337,3 +455,219 @@ func (c *ctx) setProject(args []string) error {
 
 	return nil
 }
+
+func (c *ctx) migrateCredentials(args []string) error {
+	target, err := credentialStoreFromCLI()
+	if err != nil {
+		return err
+	}
+
+	ctxs, err := c.c.GetContexts()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range args {
+		if _, ok := ctxs.Get(name); !ok {
+			return fmt.Errorf("no context with name %q found", name)
+		}
+	}
+
+	var (
+		migrated []*config.Context
+		erase    []func() error
+	)
+	for _, ctx := range ctxs.Contexts {
+		if len(args) > 0 && !slices.Contains(args, ctx.Name) {
+			continue
+		}
+		if len(args) == 0 && (ctx.Token == "" || ctx.Credentials != "" && ctx.Credentials != config.CredentialStoreFile) {
+			continue
+		}
+
+		eraseOld, err := moveToCredentialStore(ctx, target)
+		if err != nil {
+			return err
+		}
+
+		migrated = append(migrated, ctx)
+		erase = append(erase, eraseOld)
+	}
+
+	if len(migrated) == 0 {
+		_, _ = fmt.Fprintf(c.c.Out, "%s no contexts with plaintext tokens found\n", color.GreenString("✔"))
+		return nil
+	}
+
+	err = c.c.WriteContexts(ctxs)
+	if err != nil {
+		return err
+	}
+
+	for _, eraseOld := range erase {
+		err = eraseOld()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ctx := range migrated {
+		_, _ = fmt.Fprintf(c.c.Out, "%s moved token of context \"%s\" to %s\n", color.GreenString("✔"), color.GreenString(ctx.Name), ctx.Credentials)
+	}
+
+	return nil
+}
+
+// capacityThresholdsFromCLI applies the capacity threshold flags to the given thresholds.
+func capacityThresholdsFromCLI(thresholds *config.CapacityThresholds) (*config.CapacityThresholds, error) {
+	if !viper.IsSet("capacity-min-free") && !viper.IsSet("capacity-min-runway") {
+		return thresholds, nil
+	}
+
+	if thresholds == nil {
+		thresholds = &config.CapacityThresholds{}
+	}
+
+	if viper.IsSet("capacity-min-free") {
+		thresholds.MinFree = nil
+
+		for _, entry := range viper.GetStringSlice("capacity-min-free") {
+			size, value, ok := strings.Cut(entry, "=")
+			if !ok {
+				return nil, fmt.Errorf("capacity threshold %q must be given in the form <size>=<amount>", entry)
+			}
+
+			minFree, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("capacity threshold %q must be given in the form <size>=<amount>: %w", entry, err)
+			}
+
+			if thresholds.MinFree == nil {
+				thresholds.MinFree = map[string]int64{}
+			}
+			thresholds.MinFree[size] = minFree
+		}
+	}
+
+	if viper.IsSet("capacity-min-runway") {
+		thresholds.MinRunway = pointer.PointerOrNil(viper.GetDuration("capacity-min-runway"))
+	}
+
+	if thresholds.MinFree == nil && thresholds.MinRunway == nil {
+		return nil, nil
+	}
+
+	return thresholds, nil
+}
+
+// columnPresetsFromCLI applies the column preset flags to the given presets.
+func columnPresetsFromCLI(presets map[string]string) (map[string]string, error) {
+	for _, entry := range viper.GetStringSlice("column-preset") {
+		command, columns, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(command) == "" {
+			return nil, fmt.Errorf("column preset %q must be given in the form <command>=<columns>", entry)
+		}
+		command = strings.Join(strings.Fields(command), " ")
+
+		if columns == "" {
+			delete(presets, command)
+			continue
+		}
+
+		_, err := helpers.ParseCustomColumns(columns)
+		if err != nil {
+			return nil, fmt.Errorf("column preset for %q is invalid: %w", command, err)
+		}
+
+		if presets == nil {
+			presets = map[string]string{}
+		}
+		presets[command] = columns
+	}
+
+	if len(presets) == 0 {
+		return nil, nil
+	}
+
+	return presets, nil
+}
+
+// credentialStoreFromCLI returns the credential store given by flags, it is empty if no store was given.
+func credentialStoreFromCLI() (string, error) {
+	target := viper.GetString("credential-store")
+	if viper.GetString("credential-helper") != "" && !viper.IsSet("credential-store") {
+		target = config.CredentialStoreHelper
+	}
+
+	if target == "" {
+		return "", nil
+	}
+	if !slices.Contains(config.CredentialStores, target) {
+		return "", fmt.Errorf("unsupported credential store %q, supported are: %s", target, strings.Join(config.CredentialStores, ", "))
+	}
+	if target == config.CredentialStoreHelper && viper.GetString("credential-helper") == "" {
+		return "", fmt.Errorf("--credential-helper must be specified when using the helper credential store")
+	}
+
+	return target, nil
+}
+
+// moveToCredentialStore moves the tokens of the context into the given credential store.
+// the tokens are read from the previous store, the new store receives them when the contexts are written.
+// the returned function removes the tokens from the previous store, it must only be called after the contexts were written successfully.
+func moveToCredentialStore(ctx *config.Context, target string) (func() error, error) {
+	noop := func() error { return nil }
+
+	old, err := ctx.CredentialStore()
+	if err != nil {
+		return nil, err
+	}
+
+	helper := ""
+	if target == config.CredentialStoreHelper {
+		helper = viper.GetString("credential-helper")
+	}
+
+	if old == nil || (ctx.Credentials == target && ctx.CredentialHelper == helper) {
+		ctx.Credentials = target
+		ctx.CredentialHelper = helper
+		return noop, nil
+	}
+
+	err = ctx.ResolveToken()
+	if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+		return nil, err
+	}
+
+	elevated := ctx.AdminTokenExpiry != nil
+	if elevated {
+		err = ctx.ResolveAdminToken()
+		if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+			return nil, err
+		}
+	}
+
+	var (
+		key      = ctx.CredentialKey()
+		adminKey = ctx.AdminCredentialKey()
+	)
+
+	ctx.Credentials = target
+	ctx.CredentialHelper = helper
+
+	return func() error {
+		err := old.Erase(key)
+		if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+			return fmt.Errorf("unable to remove token of context %q from previous credential store: %w", ctx.Name, err)
+		}
+
+		if elevated {
+			err = old.Erase(adminKey)
+			if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+				return fmt.Errorf("unable to remove admin token of context %q from previous credential store: %w", ctx.Name, err)
+			}
+		}
+
+		return nil
+	}, nil
+}
@@ -0,0 +1,314 @@
+package dryrun
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"buf.build/go/protoyaml"
+	"connectrpc.com/connect"
+	"github.com/fatih/color"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExitCodeChangesDetected is the exit code of the cli when a dry-run detected changes.
+const ExitCodeChangesDetected = 2
+
+// ErrChangesDetected is returned by commands running with --dry-run when the desired state differs from the current state.
+var ErrChangesDetected = errors.New("dry-run detected changes")
+
+type dryRun[C, U any, R proto.Message] struct {
+	c          *config.Config
+	cmdsConfig *genericcli.CmdsConfig[C, U, R]
+}
+
+// Enable adds the --dry-run flag to the update, apply and edit commands of a generic cli command.
+// when running dry, the current entity is fetched and a field-level diff of the update is printed instead of sending it.
+// if there are changes, ErrChangesDetected is returned such that the cli exits with ExitCodeChangesDetected.
+func Enable[C, U any, R proto.Message](c *config.Config, cmdsConfig *genericcli.CmdsConfig[C, U, R], cmd *cobra.Command) *cobra.Command {
+	for _, name := range []genericcli.DefaultCmd{genericcli.UpdateCmd, genericcli.ApplyCmd, genericcli.EditCmd} {
+		subCmd, _, err := cmd.Find([]string{string(name)})
+		if err != nil || subCmd == cmd {
+			continue
+		}
+
+		subCmd.Flags().Bool("dry-run", false, fmt.Sprintf("prints the changes as a field-level diff instead of applying them, exits with code %d if there are changes", ExitCodeChangesDetected))
+
+		run := subCmd.RunE
+		subCmd.RunE = func(cmd *cobra.Command, args []string) error {
+			if !viper.GetBool("dry-run") {
+				return run(cmd, args)
+			}
+
+			var (
+				d = &dryRun[C, U, R]{
+					c:          c,
+					cmdsConfig: cmdsConfig,
+				}
+				changed bool
+				err     error
+			)
+
+			switch {
+			case name == genericcli.EditCmd:
+				changed, err = d.edit(args)
+			case viper.IsSet("file"):
+				changed, err = d.fromFile(viper.GetString("file"), name == genericcli.ApplyCmd)
+			default:
+				changed, err = d.fromCLI(args)
+			}
+			if err != nil {
+				return err
+			}
+
+			if changed {
+				return ErrChangesDetected
+			}
+
+			return nil
+		}
+	}
+
+	return cmd
+}
+
+func (d *dryRun[C, U, R]) fromCLI(args []string) (bool, error) {
+	if d.cmdsConfig.UpdateRequestFromCLI == nil {
+		return false, fmt.Errorf("updating a %s from flags is not supported, please use --file", d.cmdsConfig.Singular)
+	}
+
+	ids, err := genericcli.GetExactlyNArgs(len(d.cmdsConfig.Args), args)
+	if err != nil {
+		return false, err
+	}
+
+	rq, err := d.cmdsConfig.UpdateRequestFromCLI(args)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := d.currentUpdate(ids)
+	if err != nil {
+		return false, err
+	}
+
+	update, err := asProto(rq)
+	if err != nil {
+		return false, err
+	}
+
+	// the update request from the cli only contains the fields which are going to be changed
+	return d.printDiff(strings.Join(ids, " "), current, helpers.ApplyUpdate(current, update)), nil
+}
+
+func (d *dryRun[C, U, R]) fromFile(from string, apply bool) (bool, error) {
+	docs, err := d.readFile(from)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		crud    = d.cmdsConfig.MultiArgGenericCLI.Interface()
+		changed bool
+	)
+
+	for _, doc := range docs {
+		ids, createRq, updateRq, err := crud.Convert(doc)
+		if err != nil {
+			return false, err
+		}
+
+		id := strings.Join(ids, " ")
+
+		current, err := d.currentUpdate(ids)
+		if err != nil {
+			if !apply || connect.CodeOf(err) != connect.CodeNotFound {
+				return false, err
+			}
+
+			d.printCreate(id, createRq)
+			changed = true
+			continue
+		}
+
+		desired, err := asProto(updateRq)
+		if err != nil {
+			return false, err
+		}
+
+		if d.printDiff(id, current, desired) {
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+func (d *dryRun[C, U, R]) edit(args []string) (bool, error) {
+	ids, err := genericcli.GetExactlyNArgs(len(d.cmdsConfig.Args), args)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := d.currentUpdate(ids)
+	if err != nil {
+		return false, err
+	}
+
+	raw, err := protoyaml.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+
+	tmpfile, err := afero.TempFile(d.c.Fs, "", config.BinaryName+"-*.yaml")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = d.c.Fs.Remove(tmpfile.Name())
+	}()
+
+	err = d.c.Fs.WriteFile(tmpfile.Name(), raw, 0600)
+	if err != nil {
+		return false, err
+	}
+
+	editor, ok := os.LookupEnv("EDITOR")
+	if !ok {
+		editor = "vi"
+	}
+
+	editCommand := exec.Command(editor, tmpfile.Name()) //nolint:gosec
+	editCommand.Stdout = os.Stdout
+	editCommand.Stdin = os.Stdin
+	editCommand.Stderr = os.Stderr
+
+	err = editCommand.Run()
+	if err != nil {
+		return false, err
+	}
+
+	edited, err := d.c.Fs.ReadFile(tmpfile.Name())
+	if err != nil {
+		return false, err
+	}
+
+	desired := current.ProtoReflect().Type().New().Interface()
+
+	err = protoyaml.Unmarshal(edited, desired)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse edited %s: %w", d.cmdsConfig.Singular, err)
+	}
+
+	return d.printDiff(strings.Join(ids, " "), current, desired), nil
+}
+
+// currentUpdate fetches the entity and returns the update request representing its current state.
+func (d *dryRun[C, U, R]) currentUpdate(ids []string) (proto.Message, error) {
+	crud := d.cmdsConfig.MultiArgGenericCLI.Interface()
+
+	current, err := crud.Get(ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, rq, err := crud.Convert(current)
+	if err != nil {
+		return nil, err
+	}
+
+	return asProto(rq)
+}
+
+func (d *dryRun[C, U, R]) readFile(from string) ([]R, error) {
+	var (
+		r   io.Reader
+		err error
+	)
+
+	if from == "-" {
+		r = d.c.In
+	} else {
+		var data []byte
+		data, err = d.c.Fs.ReadFile(from)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %q: %w", from, err)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	raws, err := helpers.SplitYAMLDocuments(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []R
+
+	for _, raw := range raws {
+		var zero R
+		doc := zero.ProtoReflect().Type().New().Interface().(R)
+
+		err = protoyaml.Unmarshal(raw, doc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal %s: %w", d.cmdsConfig.Singular, err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+func (d *dryRun[C, U, R]) printDiff(id string, current, desired proto.Message) bool {
+	diff := helpers.ProtoDiff(current, desired)
+	if diff == "" {
+		_, _ = fmt.Fprintf(d.c.Out, "%s %s \"%s\" is up to date\n", color.GreenString("="), d.cmdsConfig.Singular, id)
+		return false
+	}
+
+	_, _ = fmt.Fprintf(d.c.Out, "%s %s \"%s\" would be changed:\n", color.YellowString("~"), d.cmdsConfig.Singular, id)
+	printColoredDiff(d.c.Out, diff)
+
+	return true
+}
+
+func (d *dryRun[C, U, R]) printCreate(id string, rq C) {
+	_, _ = fmt.Fprintf(d.c.Out, "%s %s \"%s\" would be created\n", color.GreenString("+"), d.cmdsConfig.Singular, id)
+
+	// not every entity has a create request, e.g. switches register themselves
+	if desired, err := asProto(rq); err == nil {
+		printColoredDiff(d.c.Out, helpers.ProtoDiff(desired.ProtoReflect().Type().New().Interface(), desired))
+	}
+}
+
+func printColoredDiff(out io.Writer, diff string) {
+	for line := range strings.SplitSeq(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			line = color.RedString("%s", line)
+		case strings.HasPrefix(line, "+"):
+			line = color.GreenString("%s", line)
+		}
+
+		_, _ = fmt.Fprintln(out, line)
+	}
+}
+
+func asProto(v any) (proto.Message, error) {
+	msg, ok := v.(proto.Message)
+	if !ok || msg == nil {
+		return nil, fmt.Errorf("dry-run is not supported for requests of type %T", v)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"time"
+
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+)
+
+// Enable adds the --filter flag to the list command of a generic cli command.
+// the filter is a CEL expression which is evaluated against every listed entity before sorting and printing.
+func Enable[C, U any, R proto.Message](cmdsConfig *genericcli.CmdsConfig[C, U, R], cmd *cobra.Command) *cobra.Command {
+	listCmd, _, err := cmd.Find([]string{string(genericcli.ListCmd)})
+	if err != nil || listCmd == cmd {
+		return cmd
+	}
+
+	listCmd.Flags().String("filter", "", `only lists the entities matching the given CEL expression, the fields of an entity are available as variables and now contains the current time, e.g. "meta.created_at > now - duration('24h')"`)
+
+	run := listCmd.RunE
+	listCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if viper.GetString("filter") == "" {
+			return run(cmd, args)
+		}
+
+		sortKeys, err := genericcli.ParseSortFlags()
+		if err != nil {
+			return err
+		}
+
+		entities, err := List(cmdsConfig, sortKeys...)
+		if err != nil {
+			return err
+		}
+
+		return cmdsConfig.ListPrinter().Print(entities)
+	}
+
+	return cmd
+}
+
+// List lists the entities of a generic cli command, the entities are filtered by the --filter flag if given and sorted afterwards.
+func List[C, U any, R proto.Message](cmdsConfig *genericcli.CmdsConfig[C, U, R], sortKeys ...multisort.Key) ([]R, error) {
+	expression := viper.GetString("filter")
+	if expression == "" {
+		return cmdsConfig.MultiArgGenericCLI.List(sortKeys...)
+	}
+
+	entities, err := cmdsConfig.MultiArgGenericCLI.Interface().List()
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err = helpers.FilterEntities(entities, expression, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if sorter := cmdsConfig.MultiArgGenericCLI.Sorter(); sorter != nil {
+		err = sorter.SortBy(entities, sortKeys...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entities, nil
+}
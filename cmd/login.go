@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -22,6 +27,21 @@ type login struct {
 	c *config.Config
 }
 
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+type deviceTokenResponse struct {
+	Token            string `json:"token,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
 func newLoginCmd(c *config.Config) *cobra.Command {
 	w := &login{
 		c: c,
@@ -30,23 +50,38 @@ func newLoginCmd(c *config.Config) *cobra.Command {
 	loginCmd := &cobra.Command{
 		Use:   "login",
 		Short: "login",
+		Long: `login to the metal-stack api and store the retrieved token into a context.
+
+By default a browser is opened and the token is received through a callback on a local http server.
+On hosts without a browser (jump hosts, ssh sessions, ci runners) use one of:
+
+  --no-browser   prints the login url, the token can be pasted (either the token or the entire callback url) after login
+  --device-flow  prints a user code and verification url and polls the api until the login was completed`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return w.login()
+			return w.login(cmd.Context())
 		},
 	}
 
 	loginCmd.Flags().String("provider", "openid-connect", "the provider used to login with")
 	loginCmd.Flags().String("context", "", "the context into which the token gets injected, if not specified it uses the current context or creates a context named default in case there is no current context set")
 	loginCmd.Flags().String("admin-role", "", "operators can use this flag to issue an admin token with the token retrieved from login and store this into context")
+	loginCmd.Flags().Bool("no-browser", false, "does not open a browser but prints the login url, the retrieved token or callback url can then be pasted to stdin")
+	loginCmd.Flags().Bool("device-flow", false, "uses the device authorization flow, prints a user code and polls the api until the login was completed")
+	loginCmd.Flags().Duration("login-timeout", 5*time.Minute, "the maximum duration to wait for the login to complete")
+	loginCmd.Flags().String("credential-store", "", "sets where the retrieved token is stored, can be one of file|keyring|helper (default is the credential store of the context)")
+	loginCmd.Flags().String("credential-helper", "", "sets the credential helper executable used for storing the retrieved token, implies --credential-store helper")
 
-	genericcli.Must(loginCmd.Flags().MarkHidden("admin-role"))
+	loginCmd.MarkFlagsMutuallyExclusive("no-browser", "device-flow")
+
+	genericcli.Must(loginCmd.Flags().MarkDeprecated("admin-role", "use admin elevate for issuing a short-lived admin token instead"))
 	genericcli.Must(loginCmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions([]string{"openid-connect"}, cobra.ShellCompDirectiveNoFileComp)))
 	genericcli.Must(loginCmd.RegisterFlagCompletionFunc("admin-role", c.Completion.TokenAdminRole))
+	genericcli.Must(loginCmd.RegisterFlagCompletionFunc("credential-store", cobra.FixedCompletions(config.CredentialStores, cobra.ShellCompDirectiveNoFileComp)))
 
 	return loginCmd
 }
 
-func (l *login) login() error {
+func (l *login) login(parent context.Context) error {
 	provider := l.c.GetProvider()
 	if provider == "" {
 		return errors.New("provider must be specified")
@@ -75,47 +110,35 @@ func (l *login) login() error {
 
 	ctx.Provider = provider
 
-	// switch into new context
-	ctxs.PreviousContext = ctxs.CurrentContext
-	ctxs.CurrentContext = ctx.Name
-
-	tokenChan := make(chan string)
-
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		tokenChan <- r.URL.Query().Get("token")
-
-		http.Redirect(w, r, "https://metal-stack.io", http.StatusSeeOther)
-	})
-
-	listener, err := net.Listen("tcp", "localhost:0")
+	store, err := credentialStoreFromCLI()
 	if err != nil {
 		return err
 	}
-
-	server := http.Server{Addr: listener.Addr().String(), ReadTimeout: 2 * time.Second}
-
-	go func() {
-		fmt.Printf("Starting server at http://%s...\n", listener.Addr().String())
-		err = server.Serve(listener) //nolint
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			panic(fmt.Errorf("http server closed unexpectedly: %w", err))
+	eraseOld := func() error { return nil }
+	if store != "" {
+		eraseOld, err = moveToCredentialStore(ctx, store)
+		if err != nil {
+			return err
 		}
-	}()
-
-	url := fmt.Sprintf("%s/auth/%s?redirect-url=http://%s/callback", l.c.GetApiURL(), provider, listener.Addr().String()) // TODO(vknabel): nicify please
-
-	err = exec.Command("xdg-open", url).Run() //nolint
-	if err != nil {
-		return fmt.Errorf("error opening browser: %w", err)
 	}
 
-	token := <-tokenChan
+	// switch into new context
+	ctxs.PreviousContext = ctxs.CurrentContext
+	ctxs.CurrentContext = ctx.Name
 
-	err = server.Shutdown(context.Background())
+	loginCtx, cancel := context.WithTimeout(parent, viper.GetDuration("login-timeout"))
+	defer cancel()
+
+	var token string
+	switch {
+	case viper.GetBool("device-flow"):
+		token, err = l.deviceFlow(loginCtx, provider)
+	default:
+		token, err = l.callbackFlow(loginCtx, provider, !viper.GetBool("no-browser"))
+	}
 	if err != nil {
-		return fmt.Errorf("unable to close http server: %w", err)
+		return err
 	}
-	_ = listener.Close()
 
 	if token == "" {
 		return errors.New("no token was retrieved")
@@ -139,7 +162,7 @@ func (l *login) login() error {
 		token = tokenResp.Secret
 	}
 
-	ctx.Token = token
+	ctx.SetToken(token)
 
 	if ctx.DefaultProject == "" {
 		mc, err := newApiClient(l.c.GetApiURL(), token)
@@ -162,7 +185,202 @@ func (l *login) login() error {
 		return err
 	}
 
+	err = eraseOld()
+	if err != nil {
+		return err
+	}
+
 	_, _ = fmt.Fprintf(l.c.Out, "%s login successful! Updated and activated context \"%s\"\n", color.GreenString("✔"), color.GreenString(ctx.Name))
 
 	return nil
 }
+
+// callbackFlow starts a local http server which receives the token from the api through a redirect.
+// when no browser is opened, the login url is printed and the token can also be pasted to stdin.
+func (l *login) callbackFlow(ctx context.Context, provider string, openBrowser bool) (string, error) {
+	var (
+		tokenChan = make(chan string, 1)
+		errChan   = make(chan error, 1)
+		mux       = http.NewServeMux()
+	)
+
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case tokenChan <- r.URL.Query().Get("token"):
+		default:
+		}
+
+		http.Redirect(w, r, "https://metal-stack.io", http.StatusSeeOther)
+	})
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux, ReadTimeout: 2 * time.Second}
+	defer func() {
+		_ = server.Shutdown(context.Background())
+	}()
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errChan <- fmt.Errorf("http server closed unexpectedly: %w", err)
+		}
+	}()
+
+	loginURL, err := url.Parse(l.c.GetApiURL())
+	if err != nil {
+		return "", fmt.Errorf("unable to parse api url: %w", err)
+	}
+	loginURL = loginURL.JoinPath("auth", provider)
+	loginURL.RawQuery = url.Values{"redirect-url": []string{fmt.Sprintf("http://%s/callback", listener.Addr().String())}}.Encode()
+
+	if openBrowser {
+		_, _ = fmt.Fprintf(l.c.PromptOut, "Starting server at http://%s...\n", listener.Addr().String())
+
+		err = exec.CommandContext(ctx, "xdg-open", loginURL.String()).Run() //nolint
+		if err != nil {
+			return "", fmt.Errorf("error opening browser, consider using --no-browser or --device-flow: %w", err)
+		}
+	} else {
+		_, _ = fmt.Fprintf(l.c.PromptOut, "Open the following url in a browser to login:\n\n  %s\n\nAfter login, paste the token or the url you were redirected to: ", loginURL.String())
+
+		if l.c.In != nil {
+			go readPastedToken(l.c.In, tokenChan, errChan)
+		}
+	}
+
+	select {
+	case token := <-tokenChan:
+		return token, nil
+	case err := <-errChan:
+		return "", err
+	case <-ctx.Done():
+		return "", fmt.Errorf("login was not completed: %w", ctx.Err())
+	}
+}
+
+// readPastedToken reads a single line from the given reader, which can either be the token or the callback url containing the token.
+func readPastedToken(in io.Reader, tokenChan chan<- string, errChan chan<- error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		errChan <- fmt.Errorf("unable to read token from stdin: %w", err)
+		return
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		errChan <- errors.New("no token was retrieved")
+		return
+	}
+
+	if u, err := url.Parse(line); err == nil && u.Scheme != "" && u.Host != "" {
+		line = u.Query().Get("token")
+	}
+
+	select {
+	case tokenChan <- line:
+	default:
+	}
+}
+
+// deviceFlow implements a device authorization grant similar to RFC 8628.
+// the user code and verification url are printed and the api is polled until the user completed the login.
+func (l *login) deviceFlow(ctx context.Context, provider string) (string, error) {
+	baseURL, err := url.Parse(l.c.GetApiURL())
+	if err != nil {
+		return "", fmt.Errorf("unable to parse api url: %w", err)
+	}
+
+	// no keep-alives, polling is infrequent and idle connections should not outlive the login
+	httpClient := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	var auth deviceAuthorization
+	err = postForm(ctx, httpClient, baseURL.JoinPath("auth", provider, "device").String(), url.Values{}, &auth)
+	if err != nil {
+		return "", fmt.Errorf("unable to start device authorization: %w", err)
+	}
+
+	if auth.DeviceCode == "" {
+		return "", errors.New("device authorization response did not contain a device code")
+	}
+
+	verificationURI := auth.VerificationURI
+	if auth.VerificationURIComplete != "" {
+		verificationURI = auth.VerificationURIComplete
+	}
+
+	_, _ = fmt.Fprintf(l.c.PromptOut, "Open the following url in a browser and enter the code %s to login:\n\n  %s\n\n", color.GreenString(auth.UserCode), verificationURI)
+
+	if auth.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(auth.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	interval := 5 * time.Second
+	if auth.Interval > 0 {
+		interval = time.Duration(auth.Interval) * time.Second
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("login was not completed: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+
+		var resp deviceTokenResponse
+		err = postForm(ctx, httpClient, baseURL.JoinPath("auth", provider, "device", "token").String(), url.Values{"device_code": []string{auth.DeviceCode}}, &resp)
+		if err != nil {
+			return "", fmt.Errorf("unable to retrieve token: %w", err)
+		}
+
+		switch resp.Error {
+		case "":
+			return resp.Token, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		case "expired_token":
+			return "", errors.New("device code has expired, please login again")
+		case "access_denied":
+			return "", errors.New("login was denied")
+		default:
+			return "", fmt.Errorf("unable to retrieve token: %s %s", resp.Error, resp.ErrorDescription)
+		}
+	}
+}
+
+func postForm(ctx context.Context, httpClient *http.Client, target string, values url.Values, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// device token errors are returned with status code 400 as well, so the body is decoded anyway
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(into)
+	if err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+
+	return nil
+}
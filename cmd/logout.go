@@ -1,15 +1,14 @@
 package cmd
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"net"
-	"net/http"
 	"os/exec"
 	"time"
 
+	"connectrpc.com/connect"
 	"github.com/fatih/color"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/spf13/cobra"
@@ -28,15 +27,21 @@ func newLogoutCmd(c *config.Config) *cobra.Command {
 	logoutCmd := &cobra.Command{
 		Use:   "logout",
 		Short: "logout",
+		Long:  "revokes the token of the context at the api and removes it from the context. afterwards the session at the identity provider is ended in the browser.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return w.logout()
 		},
 	}
 
 	logoutCmd.Flags().String("provider", "openid-connect", "the provider used to logout with")
-	logoutCmd.Flags().String("context-name", "", "the context into which the token gets injected, if not specified it uses the current context or creates a context named default in case there is no current context set")
+	logoutCmd.Flags().String("context-name", "", "the context to logout from, if not specified the current context is used")
+	logoutCmd.Flags().Bool("all-contexts", false, "revokes the tokens of all contexts, e.g. before handing back a device, the browser is not opened in this case")
+	logoutCmd.Flags().Bool("no-browser", false, "do not open the browser for ending the session at the identity provider")
 
 	genericcli.Must(logoutCmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions([]string{"openid-connect"}, cobra.ShellCompDirectiveNoFileComp)))
+	genericcli.Must(logoutCmd.RegisterFlagCompletionFunc("context-name", c.ContextListCompletion))
+
+	logoutCmd.MarkFlagsMutuallyExclusive("context-name", "all-contexts")
 
 	return logoutCmd
 }
@@ -52,53 +57,130 @@ func (l *logout) logout() error {
 		return err
 	}
 
-	ctxName := ctxs.CurrentContext
-	if viper.IsSet("context-name") {
-		ctxName = viper.GetString("context-name")
-	}
+	var toLogout []*config.Context
 
-	ctx, ok := ctxs.Get(ctxName)
-	if !ok {
-		defaultCtx := l.c.MustDefaultContext()
-		defaultCtx.Name = "default"
+	if viper.GetBool("all-contexts") {
+		toLogout = ctxs.Contexts
+	} else {
+		ctxName := ctxs.CurrentContext
+		if viper.IsSet("context-name") {
+			ctxName = viper.GetString("context-name")
+		}
 
-		ctxs.PreviousContext = ctxs.CurrentContext
-		ctxs.CurrentContext = ctx.Name
+		ctx, ok := ctxs.Get(ctxName)
+		if !ok {
+			return fmt.Errorf("no context with name %q found", ctxName)
+		}
 
-		ctxs.Contexts = append(ctxs.Contexts, &defaultCtx)
+		toLogout = append(toLogout, ctx)
+	}
 
-		ctx = &defaultCtx
+	var errs []error
+	for _, ctx := range toLogout {
+		revoked, err := l.revoke(ctx)
+		if err != nil {
+			// the token is kept in the context such that the logout can be retried
+			errs = append(errs, err)
+			continue
+		}
+		if !revoked {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(l.c.Out, "%s revoked token of context \"%s\"\n", color.GreenString("✔"), color.GreenString(ctx.Name))
 	}
 
-	listener, err := net.Listen("tcp", "localhost:0")
+	err = l.c.WriteContexts(ctxs)
 	if err != nil {
 		return err
 	}
 
-	server := http.Server{Addr: listener.Addr().String(), ReadTimeout: 2 * time.Second}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
 
-	go func() {
-		fmt.Printf("Starting server at http://%s...\n", listener.Addr().String())
-		err = server.Serve(listener) //nolint
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			panic(fmt.Errorf("http server closed unexpectedly: %w", err))
+	if !viper.GetBool("all-contexts") && !viper.GetBool("no-browser") {
+		url := fmt.Sprintf("%s/auth/logout/%s", l.c.GetContextApiURL(toLogout[0]), provider)
+
+		err = exec.Command("xdg-open", url).Run() //nolint
+		if err != nil {
+			_, _ = fmt.Fprintf(l.c.PromptOut, "unable to open browser, please open %s for ending the session at the identity provider\n", url)
 		}
-	}()
+	}
 
-	url := fmt.Sprintf("%s/auth/logout/%s", l.c.GetApiURL(), provider)
+	_, _ = fmt.Fprintf(l.c.Out, "%s logout successful!\n", color.GreenString("✔"))
 
-	err = exec.Command("xdg-open", url).Run() //nolint
-	if err != nil {
-		return fmt.Errorf("error opening browser: %w", err)
+	return nil
+}
+
+// revoke revokes the token and the admin token of the given context at the api and removes them from the context and its credential store.
+// it returns false if the context did not contain a token.
+func (l *logout) revoke(ctx *config.Context) (bool, error) {
+	err := ctx.ResolveToken()
+	if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+		return false, err
+	}
+
+	var tokens []string
+	if ctx.Token != "" {
+		tokens = append(tokens, ctx.Token)
 	}
 
-	err = server.Shutdown(context.Background())
+	// expired admin tokens do not need to be revoked anymore
+	if ctx.Elevated(time.Now()) {
+		err = ctx.ResolveAdminToken()
+		if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+			return false, err
+		}
+		if ctx.AdminToken != "" {
+			tokens = append(tokens, ctx.AdminToken)
+		}
+	}
+
+	for _, token := range tokens {
+		id, err := config.TokenID(token)
+		if err != nil {
+			return false, fmt.Errorf("unable to determine id of token of context %q: %w", ctx.Name, err)
+		}
+
+		// every token is revoked with a client authenticated by the token itself,
+		// regardless of the token used for running the command
+		mc, err := l.c.NewClient(l.c.GetContextApiURL(ctx), token)
+		if err != nil {
+			return false, err
+		}
+
+		reqCtx, cancel := l.c.NewRequestContext()
+		_, err = mc.Apiv2().Token().Revoke(reqCtx, &apiv2.TokenServiceRevokeRequest{Uuid: id})
+		cancel()
+		// as the token authenticated its own revocation, unauthenticated means it is already expired or revoked and can safely be removed
+		if err != nil && connect.CodeOf(err) != connect.CodeUnauthenticated {
+			return false, fmt.Errorf("unable to revoke token of context %q: %w", ctx.Name, err)
+		}
+	}
+
+	store, err := ctx.CredentialStore()
 	if err != nil {
-		return fmt.Errorf("unable to close http server: %w", err)
+		return false, err
+	}
+	if store != nil {
+		keys := []string{ctx.CredentialKey()}
+		if ctx.AdminTokenExpiry != nil {
+			keys = append(keys, ctx.AdminCredentialKey())
+		}
+
+		for _, key := range keys {
+			err = store.Erase(key)
+			if err != nil && !errors.Is(err, config.ErrCredentialsNotFound) {
+				return false, fmt.Errorf("unable to remove token of context %q from credential store: %w", ctx.Name, err)
+			}
+		}
 	}
-	_ = listener.Close()
 
-	_, _ = fmt.Fprintf(l.c.Out, "%s logout successful! \n", color.GreenString("✔"))
+	ctx.Token = ""
+	ctx.TokenExpiry = nil
+	ctx.AdminToken = ""
+	ctx.AdminTokenExpiry = nil
 
-	return nil
+	return len(tokens) > 0, nil
 }
@@ -1,19 +1,61 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
 
 	"github.com/fatih/color"
 	"github.com/metal-stack/cli/cmd/tableprinters"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/spf13/viper"
 )
 
-func newPrinterFromCLI(out io.Writer) (printers.Printer, error) {
-	var printer printers.Printer
+func newPrinterFromCLI(out io.Writer, columnPresets map[string]string, command string) (printers.Printer, error) {
+	var (
+		printer printers.Printer
+		format  = viper.GetString("output-format")
+	)
 
-	switch format := viper.GetString("output-format"); format {
+	if preset, ok := columnPresets[command]; ok && (format == "custom-columns" || !viper.IsSet("output-format")) {
+		format = "custom-columns=" + preset
+	}
+
+	if spec, ok := strings.CutPrefix(format, "custom-columns"); ok {
+		spec, ok = strings.CutPrefix(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("no column preset for %q configured in the current context, custom columns need to be given like custom-columns=ID:.uuid,NAME:.name", command)
+		}
+
+		columns, err := helpers.ParseCustomColumns(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		return printers.NewTablePrinter(&printers.TablePrinterConfig{
+			ToHeaderAndRows: selectColumns(customColumns(columns), viper.GetStringSlice("columns")),
+			NoHeaders:       viper.GetBool("no-headers"),
+		}).WithOut(out), nil
+	}
+
+	if template, ok := strings.CutPrefix(format, "jsonpath="); ok {
+		jsonPath, err := helpers.ParseJSONPath(template)
+		if err != nil {
+			return nil, err
+		}
+
+		return &jsonPathPrinter{
+			jsonPath: jsonPath,
+			out:      out,
+		}, nil
+	}
+
+	switch format {
 	case "yaml":
 		printer = printers.NewProtoYAMLPrinter().WithFallback(false).WithOut(out)
 	case "json":
@@ -21,7 +63,7 @@ func newPrinterFromCLI(out io.Writer) (printers.Printer, error) {
 	case "table", "wide", "markdown":
 		tp := tableprinters.New()
 		cfg := &printers.TablePrinterConfig{
-			ToHeaderAndRows: tp.ToHeaderAndRows,
+			ToHeaderAndRows: selectColumns(tp.ToHeaderAndRows, viper.GetStringSlice("columns")),
 			Wide:            format == "wide",
 			Markdown:        format == "markdown",
 			NoHeaders:       viper.GetBool("no-headers"),
@@ -30,6 +72,23 @@ func newPrinterFromCLI(out io.Writer) (printers.Printer, error) {
 		tp.SetPrinter(tablePrinter)
 		tp.SetLastEventErrorThreshold(viper.GetDuration("last-event-error-threshold"))
 		printer = tablePrinter
+	case "csv", "tsv":
+		tp := tableprinters.New()
+		// the table printer is only used for configuring the rendering, which does not apply to csv
+		tp.SetPrinter(printers.NewTablePrinter(&printers.TablePrinterConfig{ToHeaderAndRows: tp.ToHeaderAndRows}))
+		tp.SetLastEventErrorThreshold(viper.GetDuration("last-event-error-threshold"))
+
+		delimiter := ','
+		if format == "tsv" {
+			delimiter = '\t'
+		}
+
+		printer = &csvPrinter{
+			toHeaderAndRows: selectColumns(tp.ToHeaderAndRows, viper.GetStringSlice("columns")),
+			noHeaders:       viper.GetBool("no-headers"),
+			delimiter:       delimiter,
+			out:             out,
+		}
 	case "template":
 		printer = printers.NewTemplatePrinter(viper.GetString("template")).WithOut(out)
 	default:
@@ -48,9 +107,204 @@ func newPrinterFromCLI(out io.Writer) (printers.Printer, error) {
 	return printer, nil
 }
 
-func defaultToYAMLPrinter(out io.Writer) (printers.Printer, error) {
+func defaultToYAMLPrinter(out io.Writer, columnPresets map[string]string, command string) (printers.Printer, error) {
 	if viper.IsSet("output-format") {
-		return newPrinterFromCLI(out)
+		return newPrinterFromCLI(out, columnPresets, command)
 	}
 	return printers.NewProtoYAMLPrinter().WithFallback(true).WithOut(out), nil
 }
+
+type toHeaderAndRowsFn func(data any, wide bool) ([]string, [][]string, error)
+
+// selectColumns restricts the output of the table printers to the given columns in the given order.
+// columns are matched case-insensitively by their header, spaces can be written as dashes.
+func selectColumns(toHeaderAndRows toHeaderAndRowsFn, columns []string) toHeaderAndRowsFn {
+	if len(columns) == 0 {
+		return toHeaderAndRows
+	}
+
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(plainCell(s)))
+	}
+
+	return func(data any, wide bool) ([]string, [][]string, error) {
+		header, rows, err := toHeaderAndRows(data, wide)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var indices []int
+		for _, column := range columns {
+			index := -1
+			for i, h := range header {
+				if normalize(h) == normalize(column) {
+					index = i
+					break
+				}
+			}
+
+			if index < 0 {
+				var available []string
+				for _, h := range header {
+					if name := plainCell(h); name != "" {
+						available = append(available, name)
+					}
+				}
+				return nil, nil, fmt.Errorf("unknown column %q, available columns are: %s", column, strings.Join(available, ", "))
+			}
+
+			indices = append(indices, index)
+		}
+
+		selectCells := func(cells []string) []string {
+			selected := make([]string, 0, len(indices))
+			for _, i := range indices {
+				cell := ""
+				if i < len(cells) {
+					cell = cells[i]
+				}
+				selected = append(selected, cell)
+			}
+			return selected
+		}
+
+		var selected [][]string
+		for _, row := range rows {
+			selected = append(selected, selectCells(row))
+		}
+
+		return selectCells(header), selected, nil
+	}
+}
+
+// customColumns returns the given columns from the field paths of the printed entities, one row per entity.
+func customColumns(columns []helpers.CustomColumn) toHeaderAndRowsFn {
+	return func(data any, _ bool) ([]string, [][]string, error) {
+		var (
+			header []string
+			rows   [][]string
+		)
+
+		for _, column := range columns {
+			header = append(header, column.Header)
+		}
+
+		for _, item := range printedItems(data) {
+			var row []string
+
+			for _, column := range columns {
+				value, err := helpers.FieldPathValue(item, column.Path)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				row = append(row, value)
+			}
+
+			rows = append(rows, row)
+		}
+
+		return header, rows, nil
+	}
+}
+
+// printedItems returns the entities of a list, any other value is returned as a single entity.
+func printedItems(data any) []any {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return []any{data}
+	}
+
+	var items []any
+	for i := range v.Len() {
+		items = append(items, v.Index(i).Interface())
+	}
+
+	return items
+}
+
+// jsonPathPrinter prints a line rendered from the jsonpath template for every printed entity.
+type jsonPathPrinter struct {
+	jsonPath *helpers.JSONPath
+	out      io.Writer
+}
+
+func (p *jsonPathPrinter) Print(data any) error {
+	for _, item := range printedItems(data) {
+		line, err := p.jsonPath.Execute(item)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(p.out, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// csvPrinter prints the wide columns of the table printers as delimiter separated values, which can be imported into spreadsheets.
+// colors and emojis are removed from the cells, cells are quoted where required.
+type csvPrinter struct {
+	toHeaderAndRows toHeaderAndRowsFn
+	noHeaders       bool
+	delimiter       rune
+	out             io.Writer
+}
+
+func (p *csvPrinter) Print(data any) error {
+	header, rows, err := p.toHeaderAndRows(data, true)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(p.out)
+	w.Comma = p.delimiter
+
+	if !p.noHeaders {
+		err = w.Write(plainCells(header))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		err = w.Write(plainCells(row))
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// plainCell removes colors and emojis from a table cell.
+func plainCell(s string) string {
+	s = ansiEscape.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.So, r), unicode.Is(unicode.Variation_Selector, r):
+			return -1
+		case r > unicode.MaxASCII && unicode.IsSpace(r):
+			return ' '
+		default:
+			return r
+		}
+	}, s)
+
+	return strings.TrimSpace(s)
+}
+
+func plainCells(cells []string) []string {
+	plain := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		plain = append(plain, plainCell(cell))
+	}
+	return plain
+}
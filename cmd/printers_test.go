@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_plainCell(t *testing.T) {
+	tests := []struct {
+		name string
+		cell string
+		want string
+	}{
+		{
+			name: "plain text is unchanged",
+			cell: "machine-1",
+			want: "machine-1",
+		},
+		{
+			name: "colors are removed",
+			cell: "\x1b[32m⏻\x1b[0m\u2007120W",
+			want: "120W",
+		},
+		{
+			name: "emojis and non-breaking spaces are removed",
+			cell: "🛡\u2007●\u2007⚠️",
+			want: "",
+		},
+		{
+			name: "non-breaking spaces inside text are replaced",
+			cell: "ON\u2007Power\u2007Supply\u2007Critical",
+			want: "ON Power Supply Critical",
+		},
+		{
+			name: "line breaks are kept",
+			cell: "1.2.3.4\n5.6.7.8",
+			want: "1.2.3.4\n5.6.7.8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, plainCell(tt.cell)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_csvPrinter(t *testing.T) {
+	toHeaderAndRows := func(data any, wide bool) ([]string, [][]string, error) {
+		return []string{"ID", "", "Name", "IPs"}, [][]string{
+			{"1", "🛡", "a, b", "1.2.3.4\n5.6.7.8"},
+			{"2", "", `with "quotes"`, ""},
+		}, nil
+	}
+
+	tests := []struct {
+		name      string
+		delimiter rune
+		noHeaders bool
+		columns   []string
+		want      string
+		wantErr   error
+	}{
+		{
+			name:      "csv",
+			delimiter: ',',
+			want: `ID,,Name,IPs
+1,,"a, b","1.2.3.4
+5.6.7.8"
+2,,"with ""quotes""",
+`,
+		},
+		{
+			name:      "tsv with selected columns and without headers",
+			delimiter: '\t',
+			noHeaders: true,
+			columns:   []string{"ips", "id"},
+			want:      "\"1.2.3.4\n5.6.7.8\"\t1\n\t2\n",
+		},
+		{
+			name:      "unknown column",
+			delimiter: ',',
+			columns:   []string{"id", "size"},
+			wantErr:   fmt.Errorf(`unknown column "size", available columns are: ID, Name, IPs`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			p := &csvPrinter{
+				toHeaderAndRows: selectColumns(toHeaderAndRows, tt.columns),
+				noHeaders:       tt.noHeaders,
+				delimiter:       tt.delimiter,
+				out:             &out,
+			}
+
+			err := p.Print(nil)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
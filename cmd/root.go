@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	client "github.com/metal-stack/api/go/client"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 
@@ -12,6 +17,7 @@ import (
 
 	"github.com/metal-stack/cli/cmd/completion"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/dryrun"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
@@ -33,6 +39,10 @@ func Execute() {
 
 	err := cmd.Execute()
 	if err != nil {
+		if errors.Is(err, dryrun.ErrChangesDetected) {
+			os.Exit(dryrun.ExitCodeChangesDetected)
+		}
+
 		if viper.GetBool("debug") {
 			panic(err)
 		}
@@ -42,6 +52,10 @@ func Execute() {
 }
 
 func NewRootCmd(c *config.Config) *cobra.Command {
+	if c.NewClient == nil {
+		c.NewClient = newApiClient
+	}
+
 	rootCmd := &cobra.Command{
 		Use:          config.BinaryName,
 		Aliases:      []string{"m"},
@@ -54,16 +68,18 @@ func NewRootCmd(c *config.Config) *cobra.Command {
 			genericcli.Must(viper.BindPFlags(cmd.Flags()))
 			genericcli.Must(viper.BindPFlags(cmd.PersistentFlags()))
 
-			return initConfigWithViperCtx(c)
+			return initConfigWithViperCtx(c, strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "))
 		},
 	}
 	rootCmd.PersistentFlags().StringP("config", "c", "", "alternative config file path, (default is ~/.metal-stack/config.yaml)")
-	rootCmd.PersistentFlags().StringP("output-format", "o", "table", "output format (table|wide|markdown|json|yaml|template), wide is a table with more columns.")
+	rootCmd.PersistentFlags().StringP("output-format", "o", "table", "output format (table|wide|markdown|json|yaml|csv|tsv|template|custom-columns=...|jsonpath=...), wide is a table with more columns, csv and tsv contain the columns of wide, custom-columns prints the given field paths like custom-columns=ID:.uuid,SIZE:.size.id, jsonpath prints a line per entity like jsonpath={.uuid}{\"\\t\"}{.size.id}.")
 
-	genericcli.Must(rootCmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions([]string{"table", "wide", "markdown", "json", "yaml", "template"}, cobra.ShellCompDirectiveNoFileComp)))
+	genericcli.Must(rootCmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions([]string{"table", "wide", "markdown", "json", "yaml", "csv", "tsv", "template", "custom-columns=", "jsonpath="}, cobra.ShellCompDirectiveNoFileComp)))
 
 	rootCmd.PersistentFlags().StringP("template", "", "", `output template for template output-format, go template format. For property names inspect the output of -o json or -o yaml for reference.`)
 	rootCmd.PersistentFlags().Bool("force-color", false, "force colored output even without tty")
+	rootCmd.PersistentFlags().Bool("no-headers", false, "omit the header line of table, csv and tsv output")
+	rootCmd.PersistentFlags().StringSlice("columns", nil, "only print the given columns of table, csv and tsv output in the given order, e.g. id,name")
 	rootCmd.PersistentFlags().Bool("debug", false, "debug output")
 	rootCmd.PersistentFlags().Duration("timeout", 0, "request timeout used for api requests")
 
@@ -105,14 +121,14 @@ func NewRootCmd(c *config.Config) *cobra.Command {
 	return rootCmd
 }
 
-func initConfigWithViperCtx(c *config.Config) error {
+func initConfigWithViperCtx(c *config.Config, command string) error {
 	c.Context = c.MustDefaultContext()
 
-	listPrinter, err := newPrinterFromCLI(c.Out)
+	listPrinter, err := newPrinterFromCLI(c.Out, c.Context.ColumnPresets, command)
 	if err != nil {
 		return err
 	}
-	describePrinter, err := defaultToYAMLPrinter(c.Out)
+	describePrinter, err := defaultToYAMLPrinter(c.Out, c.Context.ColumnPresets, command)
 	if err != nil {
 		return err
 	}
@@ -120,11 +136,18 @@ func initConfigWithViperCtx(c *config.Config) error {
 	c.ListPrinter = listPrinter
 	c.DescribePrinter = describePrinter
 
+	checkTokenExpiry(c, command)
+
 	if c.Client != nil {
 		return nil
 	}
 
-	mc, err := newApiClient(c.GetApiURL(), c.GetToken())
+	token := c.GetToken()
+	if usesAdminToken(command) {
+		token = adminToken(c, token)
+	}
+
+	mc, err := newApiClient(c.GetApiURL(), token)
 	if err != nil {
 		return err
 	}
@@ -135,6 +158,45 @@ func initConfigWithViperCtx(c *config.Config) error {
 	return nil
 }
 
+// usesAdminToken returns true if the command runs with the admin token of an elevated context.
+// the elevation itself is managed with the token of the context.
+func usesAdminToken(command string) bool {
+	if !strings.HasPrefix(command, "admin ") {
+		return false
+	}
+
+	switch command {
+	case "admin elevate", "admin drop":
+		return false
+	default:
+		return true
+	}
+}
+
+// adminToken returns the admin token of the current context if it was elevated with admin elevate,
+// otherwise or when the elevation has expired the given token is returned.
+func adminToken(c *config.Config, token string) string {
+	if viper.GetString("api-token") != "" || c.Context.AdminTokenExpiry == nil {
+		return token
+	}
+
+	if !c.Context.Elevated(time.Now()) {
+		_, _ = fmt.Fprintf(c.PromptOut, "%s admin elevation of context \"%s\" has expired, falling back to the token of the context\n", color.YellowString("⚠"), c.Context.Name)
+		return token
+	}
+
+	err := c.Context.ResolveAdminToken()
+	if err != nil {
+		_, _ = fmt.Fprintf(c.PromptOut, "%s %s, falling back to the token of the context\n", color.YellowString("⚠"), err)
+		return token
+	}
+	if c.Context.AdminToken == "" {
+		return token
+	}
+
+	return c.Context.AdminToken
+}
+
 func newApiClient(apiURL, token string) (client.Client, error) {
 	logLevel := slog.LevelInfo
 	if viper.GetBool("debug") {
@@ -0,0 +1,60 @@
+package tableprinters
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+)
+
+// ApplyResult is the outcome of applying or pruning a single resource from a manifest.
+type ApplyResult struct {
+	Kind     string
+	ID       string
+	Source   string
+	Action   genericcli.BulkAction
+	Error    error
+	Duration time.Duration
+}
+
+func (t *TablePrinter) ApplyResultTable(data []*ApplyResult, wide bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"Kind", "ID", "Action", "Duration", "Error"}
+		rows   [][]string
+	)
+
+	if wide {
+		header = []string{"Kind", "ID", "Source", "Action", "Duration", "Error"}
+	}
+
+	for _, r := range data {
+		var (
+			action = string(r.Action)
+			errMsg string
+		)
+
+		switch r.Action {
+		case genericcli.BulkCreated, genericcli.BulkUpdated:
+			action = color.GreenString(action)
+		case genericcli.BulkDeleted:
+			action = color.YellowString(action)
+		default:
+			action = color.RedString(action)
+		}
+
+		if r.Error != nil {
+			errMsg = r.Error.Error()
+		}
+
+		duration := r.Duration.Round(time.Millisecond).String()
+
+		if wide {
+			rows = append(rows, []string{r.Kind, r.ID, r.Source, action, duration, errMsg})
+			continue
+		}
+
+		rows = append(rows, []string{r.Kind, r.ID, action, duration, errMsg})
+	}
+
+	return header, rows, nil
+}
@@ -1,7 +1,9 @@
 package tableprinters
 
 import (
+	"github.com/fatih/color"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 	"google.golang.org/grpc/codes"
@@ -43,3 +45,55 @@ func (t *TablePrinter) AuditTable(data []*apiv2.AuditTrace, wide bool) ([]string
 
 	return header, rows, nil
 }
+
+func (t *TablePrinter) AccessHistoryTable(data []*helpers.AccessHistoryEntry, wide bool) ([]string, [][]string, error) {
+	var (
+		rows [][]string
+	)
+
+	header := []string{"Time", "RequestId", "Method", "Target", "Reason", "Recorded"}
+	if wide {
+		header = []string{"Time", "RequestId", "User", "Method", "Target", "Reason", "Recorded", "Transcript"}
+	}
+
+	for _, entry := range data {
+		var (
+			time                         = entry.Time().Format("2006-01-02 15:04:05")
+			id, user, method, target     string
+			reason, recorded, transcript string
+		)
+
+		switch {
+		case entry.Record != nil && entry.Trace != nil:
+			recorded = "local+audit"
+		case entry.Record != nil:
+			recorded = color.YellowString("local")
+		default:
+			recorded = "audit"
+		}
+
+		if entry.Trace != nil {
+			id = entry.Trace.Uuid
+			user = entry.Trace.User
+			method = entry.Trace.Method
+			target = pointer.SafeDeref(entry.Trace.Project)
+		}
+
+		if entry.Record != nil {
+			method = entry.Record.Method
+			target = entry.Record.Target
+			reason = entry.Record.Reason
+			transcript = entry.Record.Transcript
+		}
+
+		if wide {
+			rows = append(rows, []string{time, id, user, method, target, reason, recorded, transcript})
+		} else {
+			rows = append(rows, []string{time, id, method, target, genericcli.TruncateEnd(reason, 40), recorded})
+		}
+	}
+
+	t.t.DisableAutoWrap(false)
+
+	return header, rows, nil
+}
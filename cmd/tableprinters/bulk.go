@@ -0,0 +1,38 @@
+package tableprinters
+
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// BulkOperationResult is the outcome of an operation performed on a single entity as part of a bulk operation.
+type BulkOperationResult struct {
+	ID        string
+	Operation string
+	Error     error
+	Duration  time.Duration
+}
+
+func (t *TablePrinter) BulkOperationResultTable(data []*BulkOperationResult, _ bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"ID", "Operation", "Result", "Duration", "Error"}
+		rows   [][]string
+	)
+
+	for _, r := range data {
+		var (
+			result = color.GreenString("✔")
+			errMsg string
+		)
+
+		if r.Error != nil {
+			result = color.RedString("✗")
+			errMsg = r.Error.Error()
+		}
+
+		rows = append(rows, []string{r.ID, r.Operation, result, r.Duration.Round(time.Millisecond).String(), errMsg})
+	}
+
+	return header, rows, nil
+}
@@ -9,6 +9,7 @@ import (
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
 	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 )
@@ -43,10 +44,17 @@ func (t *TablePrinter) SetLastEventErrorThreshold(threshold time.Duration) {
 func (t *TablePrinter) ToHeaderAndRows(data any, wide bool) ([]string, [][]string, error) {
 	switch d := data.(type) {
 
+	case []*ApplyResult:
+		return t.ApplyResultTable(d, wide)
+	case []*BulkOperationResult:
+		return t.BulkOperationResultTable(d, wide)
+
 	case *apiv2.AuditTrace:
 		return t.AuditTable(pointer.WrapInSlice(d), wide)
 	case []*apiv2.AuditTrace:
 		return t.AuditTable(d, wide)
+	case []*helpers.AccessHistoryEntry:
+		return t.AccessHistoryTable(d, wide)
 
 	case *config.Contexts:
 		return t.ContextTable(d, wide)
@@ -66,6 +74,12 @@ func (t *TablePrinter) ToHeaderAndRows(data any, wide bool) ([]string, [][]strin
 	case []*apiv2.Machine:
 		return t.MachineTable(d, wide)
 
+	case *apiv2.MachineRecentProvisioningEvents:
+		return t.MachineProvisioningEventTable(d, wide)
+
+	case []*helpers.MachineIssue:
+		return t.MachineIssueTable(d, wide)
+
 	case *apiv2.MachineBMCDetails:
 		return t.MachineBMCTable(pointer.WrapInSlice(d), wide)
 	case []*apiv2.MachineBMCDetails:
@@ -113,6 +127,8 @@ func (t *TablePrinter) ToHeaderAndRows(data any, wide bool) ([]string, [][]strin
 		return t.PartitionCapacityTable(pointer.WrapInSlice(d), wide)
 	case []*adminv2.PartitionCapacity:
 		return t.PartitionCapacityTable(d, wide)
+	case []*helpers.CapacityForecast:
+		return t.PartitionCapacityForecastTable(d, wide)
 
 	case *apiv2.Token:
 		return t.TokenTable(pointer.WrapInSlice(d), wide)
@@ -146,6 +162,12 @@ func (t *TablePrinter) ToHeaderAndRows(data any, wide bool) ([]string, [][]strin
 		return t.SwitchTable(d, wide)
 	case []SwitchDetail:
 		return t.SwitchDetailTable(d)
+	case []*helpers.SwitchFinding:
+		return t.SwitchFindingTable(d, wide)
+	case []*helpers.SwitchPort:
+		return t.SwitchPortTable(d, wide)
+	case []*helpers.SwitchPortChange:
+		return t.SwitchPortChangeTable(d, wide)
 	case *adminv2.SwitchServiceConnectedMachinesResponse:
 		return t.SwitchWithConnectedMachinesTable(d.SwitchesWithMachines, wide)
 
@@ -153,6 +175,8 @@ func (t *TablePrinter) ToHeaderAndRows(data any, wide bool) ([]string, [][]strin
 		return t.UserTable(pointer.WrapInSlice(d), wide)
 	case []*apiv2.User:
 		return t.UserTable(d, wide)
+	case *helpers.PermissionMatrix:
+		return t.UserPermissionsTable(d, wide)
 
 	case *apiv2.VPNNode:
 		return t.VPNTable(pointer.WrapInSlice(d), wide)
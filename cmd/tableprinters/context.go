@@ -1,6 +1,8 @@
 package tableprinters
 
 import (
+	"time"
+
 	"github.com/fatih/color"
 	"github.com/metal-stack/cli/cmd/config"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
@@ -14,7 +16,7 @@ func (t *TablePrinter) ContextTable(data *config.Contexts, wide bool) ([]string,
 	)
 
 	if wide {
-		header = append(header, "API URL")
+		header = append(header, "API URL", "Token Expires", "Credentials")
 	}
 
 	for _, c := range data.Contexts {
@@ -30,7 +32,17 @@ func (t *TablePrinter) ContextTable(data *config.Contexts, wide bool) ([]string,
 				url = viper.GetString("api-url")
 			}
 
-			row = append(row, url)
+			expires := ""
+			if expiry := c.Expiry(); expiry != nil {
+				expires = expiry.Format(time.DateTime)
+			}
+
+			credentials := c.Credentials
+			if credentials == "" {
+				credentials = config.CredentialStoreFile
+			}
+
+			row = append(row, url, expires, credentials)
 		}
 
 		rows = append(rows, row)
@@ -0,0 +1,53 @@
+package tableprinters
+
+import (
+	"github.com/fatih/color"
+	"github.com/metal-stack/cli/pkg/helpers"
+)
+
+func (t *TablePrinter) MachineIssueTable(data []*helpers.MachineIssue, wide bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"Severity", "Issue", "ID", "Partition", "Rack", "Details"}
+		rows   [][]string
+		last   helpers.IssueType
+	)
+
+	if wide {
+		header = []string{"Severity", "Issue", "ID", "Partition", "Rack", "Details", "Description"}
+	}
+
+	for _, issue := range data {
+		var (
+			severity    = string(issue.Severity)
+			issueType   = string(issue.Type)
+			description = issue.Description
+			partition   string
+		)
+
+		// issues are grouped by type, so severity and type are only shown for the first issue of a group
+		if issue.Type == last {
+			severity, issueType, description = "", "", ""
+		}
+		last = issue.Type
+
+		switch issue.Severity {
+		case helpers.IssueSeverityCritical:
+			severity = color.RedString(severity)
+		case helpers.IssueSeverityMajor:
+			severity = color.YellowString(severity)
+		}
+
+		if issue.Machine.Partition != nil {
+			partition = issue.Machine.Partition.Id
+		}
+
+		row := []string{severity, issueType, issue.Machine.Uuid, partition, issue.Machine.Rack, issue.Details}
+		if wide {
+			row = append(row, description)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
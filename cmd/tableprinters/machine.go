@@ -276,3 +276,62 @@ func (t *TablePrinter) getMachineStatusEmojis(m *apiv2.Machine) string {
 
 	return strings.Join(emojis, nbr)
 }
+
+func (t *TablePrinter) MachineProvisioningEventTable(data *apiv2.MachineRecentProvisioningEvents, _ bool) ([]string, [][]string, error) {
+	var (
+		rows   [][]string
+		header = []string{"", "Time", "Event", "Duration", "Message"}
+		events = helpers.ProvisioningEventsSince(data, time.Time{})
+	)
+
+	for i, e := range events {
+		eventString, err := enum.GetStringValue(e.Event)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var (
+			last      = i == len(events)-1
+			duration  time.Duration
+			emojis    []string
+			highlight bool
+		)
+
+		// the duration of a phase lasts until the next event, the latest phase is still ongoing
+		if last {
+			duration = time.Since(e.Time.AsTime())
+		} else {
+			duration = events[i+1].Time.AsTime().Sub(e.Time.AsTime())
+		}
+
+		if helpers.IsRecentProvisioningErrorEvent(data, e, t.lastEventErrorThreshold) {
+			emojis = append(emojis, helpers.Exclamation)
+			highlight = true
+		}
+
+		if last {
+			switch data.State {
+			case apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_CRASHLOOP:
+				emojis = append(emojis, helpers.Loop)
+				highlight = true
+			case apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_FAILED_RECLAIM:
+				emojis = append(emojis, helpers.Ambulance)
+				highlight = true
+			default:
+				// noop
+			}
+		}
+
+		row := []string{strings.Join(emojis, nbr), e.Time.AsTime().Format(time.RFC3339), *eventString, humanizeDuration(duration), e.Message}
+
+		if highlight {
+			for j := 1; j < len(row); j++ {
+				row[j] = color.RedString("%s", row[j])
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 )
 
@@ -120,3 +123,52 @@ func (t *TablePrinter) PartitionCapacityTable(data []*adminv2.PartitionCapacity,
 
 	return header, rows, nil
 }
+
+func (t *TablePrinter) PartitionCapacityForecastTable(data []*helpers.CapacityForecast, wide bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"Partition", "Size", "Free", "Allocated", "Released", "Net/Day", "Runs Out", "Status"}
+		rows   [][]string
+		now    = time.Now()
+	)
+
+	if wide {
+		header = []string{"Partition", "Size", "Free", "Allocated", "Released", "Net/Day", "Runs Out", "Exhausted At", "Status"}
+	}
+
+	for _, f := range data {
+		var (
+			runsOut     = "never"
+			exhaustedAt = ""
+			status      = color.GreenString("ok")
+		)
+
+		if runway := f.Runway(now); runway != nil {
+			runsOut = "in " + humanizeDuration(runway.Truncate(time.Hour))
+			if *runway < time.Hour {
+				runsOut = "now"
+			}
+			exhaustedAt = f.ExhaustedAt.Format("2006-01-02 15:04:05")
+		}
+
+		if len(f.Warnings) > 0 {
+			status = color.RedString(strings.Join(f.Warnings, ", "))
+		}
+
+		row := []string{
+			f.Partition,
+			f.Size,
+			fmt.Sprintf("%d", f.Free),
+			fmt.Sprintf("%d", f.Allocations),
+			fmt.Sprintf("%d", f.Releases),
+			fmt.Sprintf("%.2f", f.NetPerDay),
+			runsOut,
+		}
+		if wide {
+			row = append(row, exhaustedAt)
+		}
+
+		rows = append(rows, append(row, status))
+	}
+
+	return header, rows, nil
+}
@@ -3,15 +3,14 @@ package tableprinters
 import (
 	"fmt"
 	"math"
-	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/metal-stack/api/go/enum"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
 
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 )
@@ -334,35 +333,103 @@ func filterColumns(filter *apiv2.BGPFilter, i int) []string {
 }
 
 func switchInterfaceNameLessFunc(conns []*apiv2.SwitchNicWithMachine) func(i, j int) bool {
-	numberRegex := regexp.MustCompile("([0-9]+)")
-
 	return func(i, j int) bool {
 		var (
 			a = pointer.SafeDeref(pointer.SafeDeref(conns[i]).Nic).Name
 			b = pointer.SafeDeref(pointer.SafeDeref(conns[j]).Nic).Name
+		)
+
+		return helpers.CompareSwitchPortNames(a, b) < 0
+	}
+}
+
+func (t *TablePrinter) SwitchFindingTable(data []*helpers.SwitchFinding, _ bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"Partition", "Rack", "Finding", "Switches", "Machine", "Details"}
+		rows   [][]string
+	)
+
+	for _, f := range data {
+		rows = append(rows, []string{f.Partition, f.Rack, color.RedString(string(f.Type)), strings.Join(f.Switches, ","), f.MachineID, f.Details})
+	}
+
+	return header, rows, nil
+}
+
+func (t *TablePrinter) SwitchPortChangeTable(data []*helpers.SwitchPortChange, _ bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"Port", "Machine", "Change", "Before", "After"}
+		rows   [][]string
+	)
 
-			aMatch = numberRegex.FindAllStringSubmatch(a, -1)
-			bMatch = numberRegex.FindAllStringSubmatch(b, -1)
+	for _, c := range data {
+		change := c.Change
+		if c.LostConnectivity {
+			change = color.RedString(change)
+		}
+
+		rows = append(rows, []string{c.Port, c.MachineID, change, c.Before, c.After})
+	}
+
+	return header, rows, nil
+}
+
+func (t *TablePrinter) SwitchPortTable(data []*helpers.SwitchPort, wide bool) ([]string, [][]string, error) {
+	var (
+		header = []string{"Port", "Desired", "Actual", "BGP State", "Machine"}
+		rows   [][]string
+	)
+
+	if wide {
+		header = []string{"Port", "Identifier", "VRF", "Desired", "Actual", "BGP State", "Machine"}
+	}
+
+	for _, p := range data {
+		var (
+			nic      = p.Nic
+			desired  string
+			actual   string
+			bgpState string
 		)
 
-		for i := range aMatch {
-			if i >= len(bMatch) {
-				return true
+		if nic.State != nil {
+			state, err := enum.GetStringValue(nic.State.Actual)
+			if err != nil {
+				return nil, nil, err
 			}
+			actual = *state
 
-			interfaceNumberA, aErr := strconv.Atoi(aMatch[i][0])
-			interfaceNumberB, bErr := strconv.Atoi(bMatch[i][0])
+			if nic.State.Desired != nil {
+				state, err := enum.GetStringValue(*nic.State.Desired)
+				if err != nil {
+					return nil, nil, err
+				}
+				desired = *state
 
-			if aErr == nil && bErr == nil {
-				if interfaceNumberA < interfaceNumberB {
-					return true
+				if *nic.State.Desired != nic.State.Actual {
+					actual = color.RedString(actual)
 				}
-				if interfaceNumberA != interfaceNumberB {
-					return false
+			}
+		}
+
+		if nic.BgpPortState != nil {
+			if nic.BgpPortState.BgpState == apiv2.BGPState_BGP_STATE_ESTABLISHED {
+				bgpState = fmt.Sprintf("Established (%s)", humanizeDuration(time.Since(nic.BgpPortState.BgpTimerUpEstablished.AsTime())))
+			} else {
+				state, err := enum.GetStringValue(nic.BgpPortState.BgpState)
+				if err != nil {
+					return nil, nil, err
 				}
+				bgpState = *state
 			}
 		}
 
-		return a < b
+		if wide {
+			rows = append(rows, []string{nic.Name, nic.Identifier, pointer.SafeDeref(nic.Vrf), desired, actual, bgpState, p.MachineID})
+		} else {
+			rows = append(rows, []string{nic.Name, desired, actual, bgpState, p.MachineID})
+		}
 	}
+
+	return header, rows, nil
 }
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
 )
 
 type Named interface {
@@ -36,6 +37,59 @@ func (t *TablePrinter) UserTable(data []*apiv2.User, wide bool) ([]string, [][]s
 	return header, rows, nil
 }
 
+func (t *TablePrinter) UserPermissionsTable(data *helpers.PermissionMatrix, wide bool) ([]string, [][]string, error) {
+	var (
+		rows   [][]string
+		header = []string{"Visibility", "Method"}
+	)
+
+	header = append(header, data.Projects...)
+	header = append(header, data.Tenants...)
+	if data.Admin {
+		header = append(header, "Admin")
+	}
+
+	for _, m := range data.Methods {
+		method := m.Method
+		if !wide {
+			// the package of the method is already expressed by the visibility
+			method = method[strings.LastIndex(method, ".")+1:]
+		}
+
+		row := []string{m.Visibility, method}
+
+		cell := func(visibility, subject string) string {
+			if m.Visibility != visibility {
+				return ""
+			}
+
+			grant, ok := m.Grants[subject]
+			switch {
+			case !ok:
+				return "-"
+			case wide:
+				return grant
+			default:
+				return "✔"
+			}
+		}
+
+		for _, project := range data.Projects {
+			row = append(row, cell(helpers.VisibilityProject, project))
+		}
+		for _, tenant := range data.Tenants {
+			row = append(row, cell(helpers.VisibilityTenant, tenant))
+		}
+		if data.Admin {
+			row = append(row, cell(helpers.VisibilityAdmin, helpers.VisibilityAdmin))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
+
 func namesString[T Named](arr []T) string {
 	names := make([]string, 0, len(arr))
 
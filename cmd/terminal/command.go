@@ -0,0 +1,90 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type commandSession struct {
+	cmd *exec.Cmd
+}
+
+// NewCommandSession returns a session which runs an external binary like ipmitool. it is no in-process session,
+// the binary must be installed locally, it is only used for endpoints which can not be reached natively yet.
+func NewCommandSession(ctx context.Context, env []string, name string, args ...string) Session {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:gosec
+	if len(env) > 0 {
+		cmd.Env = append(cmd.Environ(), env...)
+	}
+	// the input is not read by the process directly, so the copying of the input must not block after the process exited
+	cmd.WaitDelay = time.Second
+
+	return &commandSession{
+		cmd: cmd,
+	}
+}
+
+func (s *commandSession) Start(in io.Reader, out io.Writer, _ Size) error {
+	s.cmd.Stdin = in
+	s.cmd.Stdout = out
+	s.cmd.Stderr = out
+
+	err := s.cmd.Start()
+	if err != nil {
+		return fmt.Errorf("unable to start %s: %w", s.cmd.Path, err)
+	}
+
+	return nil
+}
+
+// Resize is not supported, the command reads the terminal size on its own.
+func (s *commandSession) Resize(Size) error {
+	return nil
+}
+
+func (s *commandSession) Wait() error {
+	err := s.cmd.Wait()
+	if errors.Is(err, exec.ErrWaitDelay) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *commandSession) Close() error {
+	if s.cmd.Process == nil || s.cmd.ProcessState != nil {
+		return nil
+	}
+
+	return s.cmd.Process.Kill()
+}
+
+// NewConsoleSession returns a session for the console command of a switch. telnet connections are handled natively,
+// other commands are run locally and require the binary of the command to be installed.
+func NewConsoleSession(ctx context.Context, command string) (Session, error) {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("console command is empty")
+	}
+
+	if parts[0] == "telnet" && len(parts) > 1 && len(parts) <= 3 && !strings.HasPrefix(parts[1], "-") {
+		port := "23"
+		if len(parts) == 3 {
+			port = parts[2]
+		}
+
+		return NewTelnetSession(parts[1], port), nil
+	}
+
+	path, err := exec.LookPath(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("console command %q requires %s to be installed, only telnet consoles are supported natively: %w", command, parts[0], err)
+	}
+
+	return NewCommandSession(ctx, nil, path, parts[1:]...), nil
+}
@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Recorder writes the output of a session in the asciicast v2 format, such that it can be replayed with asciinema.
+// see https://docs.asciinema.org/manual/asciicast/v2/
+type Recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+type asciicastHeader struct {
+	Version   int   `json:"version"`
+	Width     int   `json:"width"`
+	Height    int   `json:"height"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+func NewRecorder(w io.Writer, size Size) (*Recorder, error) {
+	if size.IsZero() {
+		size = Size{Width: 80, Height: 24}
+	}
+
+	r := &Recorder{
+		w:     w,
+		start: time.Now(),
+	}
+
+	header, err := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     size.Width,
+		Height:    size.Height,
+		Timestamp: r.start.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", header)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write recording: %w", err)
+	}
+
+	return r, nil
+}
+
+// Write records the given output as an event relative to the start of the recording.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event, err := json.Marshal([]any{time.Since(r.start).Seconds(), "o", string(p)})
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = fmt.Fprintf(r.w, "%s\n", event)
+	if err != nil {
+		return 0, fmt.Errorf("unable to write recording: %w", err)
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize emits an event whenever the size of the terminal changes until the context is done.
+func notifyResize(ctx context.Context) <-chan struct{} {
+	var (
+		events  = make(chan struct{})
+		signals = make(chan os.Signal, 1)
+	)
+
+	signal.Notify(signals, syscall.SIGWINCH)
+
+	go func() {
+		defer close(events)
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				select {
+				case events <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
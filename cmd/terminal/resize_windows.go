@@ -0,0 +1,19 @@
+//go:build windows
+
+package terminal
+
+import (
+	"context"
+)
+
+// notifyResize does not emit events on windows as there is no signal for terminal size changes.
+func notifyResize(ctx context.Context) <-chan struct{} {
+	events := make(chan struct{})
+
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+
+	return events
+}
@@ -0,0 +1,292 @@
+package terminal
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+type sshSession struct {
+	client  *ssh.Client
+	env     map[string]string
+	session *ssh.Session
+}
+
+// NewSSHSession returns a session which opens an interactive shell on the given ssh client.
+// the client is closed when the session is closed.
+func NewSSHSession(client *ssh.Client, env map[string]string) Session {
+	return &sshSession{
+		client: client,
+		env:    env,
+	}
+}
+
+// SSHConfig contains the parameters for connecting to an ssh server with DialSSH.
+type SSHConfig struct {
+	User string
+	Host string
+	// Port defaults to the port configured for the host in ~/.ssh/config, otherwise 22 is used
+	Port int
+	// PrivateKey is used for authentication, if not given the identity files configured for the host in ~/.ssh/config are used
+	PrivateKey []byte
+	// Password is asked for when the server accepts none of the keys
+	Password func() (string, error)
+}
+
+// DialSSH connects to the ssh server on the given host and port. the host key of the server is verified against
+// the user's known hosts files, connecting to hosts with an unknown or changed host key fails.
+//
+// for authentication the private key, the keys of a running ssh agent and finally the password are tried.
+func DialSSH(cfg *SSHConfig) (*ssh.Client, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	hostConfig, err := readSSHConfig(filepath.Join(home, ".ssh", "config"), cfg.Host, home)
+	if err != nil {
+		return nil, err
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = hostConfig.port
+	}
+	if port == 0 {
+		port = 22
+	}
+
+	var auth []ssh.AuthMethod
+
+	switch {
+	case len(cfg.PrivateKey) > 0:
+		signer, err := ssh.ParsePrivateKey(cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	default:
+		var signers []ssh.Signer
+		for _, file := range hostConfig.identityFiles {
+			raw, err := os.ReadFile(file) //nolint:gosec
+			if err != nil {
+				return nil, fmt.Errorf("unable to read identity file configured in ssh config: %w", err)
+			}
+
+			// keys protected by a passphrase are left to the ssh agent
+			signer, err := ssh.ParsePrivateKey(raw)
+			if err != nil {
+				continue
+			}
+			signers = append(signers, signer)
+		}
+		if len(signers) > 0 {
+			auth = append(auth, ssh.PublicKeys(signers...))
+		}
+	}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to ssh agent: %w", err)
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	if cfg.Password != nil {
+		auth = append(auth, ssh.PasswordCallback(cfg.Password))
+	}
+
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("no private key given, no ssh agent running and no password prompt available")
+	}
+
+	knownHosts := hostConfig.knownHostsFiles
+	if len(knownHosts) == 0 {
+		knownHosts = []string{filepath.Join(home, ".ssh", "known_hosts")}
+	}
+
+	hostKeyCallback, algorithms, err := knownHostsCallback(knownHosts, cfg.Host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.Dial("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(port)), &ssh.ClientConfig{
+		User:              cfg.User,
+		Auth:              auth,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: algorithms,
+		Timeout:           10 * time.Second,
+	})
+}
+
+// PasswordPrompt returns a function for DialSSH which asks for the password, without echo if the input is a terminal.
+func PasswordPrompt(in io.Reader, out io.Writer, prompt string) func() (string, error) {
+	return func() (string, error) {
+		_, _ = fmt.Fprint(out, prompt)
+
+		if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) { //nolint:gosec
+			password, err := term.ReadPassword(int(f.Fd())) //nolint:gosec
+			_, _ = fmt.Fprintln(out)
+			return string(password), err
+		}
+
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+}
+
+// knownHostsCallback returns a host key callback verifying the host key against the given known hosts files,
+// missing files are treated as empty. the returned host key algorithms prefer the types of the known keys,
+// such that a server offering another key type is not mistaken for a changed host key.
+func knownHostsCallback(files []string, host string, port int) (ssh.HostKeyCallback, []string, error) {
+	var existing []string
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			existing = append(existing, file)
+		}
+	}
+
+	callback, err := knownhosts.New(existing...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read known hosts: %w", err)
+	}
+
+	var (
+		address    = knownhosts.Normalize(net.JoinHostPort(host, strconv.Itoa(port)))
+		knownHosts = strings.Join(files, ", ")
+		algorithms []string
+	)
+
+	// a probe key never matches, so the known keys of the host are returned in the key error
+	_, probe, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	probeKey, err := ssh.NewPublicKey(probe.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keyErr *knownhosts.KeyError
+	if err := callback(net.JoinHostPort(host, strconv.Itoa(port)), &net.TCPAddr{}, probeKey); errors.As(err, &keyErr) {
+		for _, known := range keyErr.Want {
+			switch keyType := known.Key.Type(); keyType {
+			case ssh.KeyAlgoRSA:
+				algorithms = append(algorithms, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA)
+			default:
+				algorithms = append(algorithms, keyType)
+			}
+		}
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+
+		if len(keyErr.Want) == 0 {
+			return fmt.Errorf("host key %s of %s is unknown, verify its fingerprint and add it to the known hosts (%s), e.g. with: ssh-keyscan -p %d %s >> %s",
+				ssh.FingerprintSHA256(key), address, knownHosts, port, host, files[0])
+		}
+
+		return fmt.Errorf("host key of %s has changed to %s, the known key is in %s:%d. this could be a man-in-the-middle attack, if the host was replaced remove the known key with: ssh-keygen -R %q -f %s",
+			address, ssh.FingerprintSHA256(key), keyErr.Want[0].Filename, keyErr.Want[0].Line, address, keyErr.Want[0].Filename)
+	}, algorithms, nil
+}
+
+func (s *sshSession) Start(in io.Reader, out io.Writer, size Size) error {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return err
+	}
+	s.session = session
+
+	var errs []error
+	for key, value := range s.env {
+		err := session.Setenv(key, value)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	session.Stdin = in
+	session.Stdout = out
+	session.Stderr = out
+
+	if !size.IsZero() {
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 115200,
+			ssh.TTY_OP_OSPEED: 115200,
+		}
+
+		err = session.RequestPty("xterm-256color", size.Height, size.Width, modes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return session.Shell()
+}
+
+func (s *sshSession) Resize(size Size) error {
+	if s.session == nil {
+		return nil
+	}
+
+	return s.session.WindowChange(size.Height, size.Width)
+}
+
+func (s *sshSession) Wait() error {
+	if s.session == nil {
+		return fmt.Errorf("session was not started")
+	}
+
+	err := s.session.Wait()
+
+	var exitMissing *ssh.ExitMissingError
+	if errors.As(err, &exitMissing) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *sshSession) Close() error {
+	if s.session != nil {
+		_ = s.session.Close()
+	}
+
+	return s.client.Close()
+}
@@ -0,0 +1,121 @@
+package terminal
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sshHostConfig contains the settings of an ssh config file which are relevant for DialSSH.
+type sshHostConfig struct {
+	port            int
+	identityFiles   []string
+	knownHostsFiles []string
+}
+
+// readSSHConfig reads the settings for the given host from an openssh client config file, a missing file is no error.
+func readSSHConfig(file, host, home string) (*sshHostConfig, error) {
+	f, err := os.Open(file) //nolint:gosec
+	if errors.Is(err, fs.ErrNotExist) {
+		return &sshHostConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return parseSSHConfig(f, host, home)
+}
+
+// parseSSHConfig supports the Host blocks of an openssh client config, other blocks like Match are skipped.
+// like in openssh, the first value of a setting wins, identity files are accumulated.
+func parseSSHConfig(r io.Reader, host, home string) (*sshHostConfig, error) {
+	var (
+		config  = &sshHostConfig{}
+		matches = true
+		scanner = bufio.NewScanner(r)
+	)
+
+	expand := func(p string) string {
+		if strings.HasPrefix(p, "~/") {
+			return filepath.Join(home, p[2:])
+		}
+		return p
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		if k, v, ok := strings.Cut(line, "="); ok && !strings.Contains(k, " ") {
+			keyword, value = k, v
+		}
+
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		values := strings.Fields(value)
+
+		switch keyword {
+		case "host":
+			matches = hostMatches(host, values)
+		case "match":
+			matches = false
+		}
+
+		if !matches || len(values) == 0 {
+			continue
+		}
+
+		switch keyword {
+		case "port":
+			if config.port == 0 {
+				port, err := strconv.Atoi(values[0])
+				if err != nil {
+					return nil, err
+				}
+				config.port = port
+			}
+		case "identityfile":
+			config.identityFiles = append(config.identityFiles, expand(values[0]))
+		case "userknownhostsfile":
+			if config.knownHostsFiles == nil {
+				for _, v := range values {
+					config.knownHostsFiles = append(config.knownHostsFiles, expand(v))
+				}
+			}
+		}
+	}
+
+	return config, scanner.Err()
+}
+
+// hostMatches evaluates the patterns of a Host line, a matching negated pattern excludes the host.
+func hostMatches(host string, patterns []string) bool {
+	matched := false
+
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+
+		ok, err := path.Match(strings.TrimPrefix(pattern, "!"), host)
+		if err != nil || !ok {
+			continue
+		}
+		if negated {
+			return false
+		}
+
+		matched = true
+	}
+
+	return matched
+}
@@ -0,0 +1,245 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// telnet commands and options, see rfc 854, 857, 858 and 1073
+const (
+	telnetIAC  byte = 255
+	telnetDONT byte = 254
+	telnetDO   byte = 253
+	telnetWONT byte = 252
+	telnetWILL byte = 251
+	telnetSB   byte = 250
+	telnetSE   byte = 240
+
+	telnetOptEcho byte = 1
+	telnetOptSGA  byte = 3
+	telnetOptNAWS byte = 31
+)
+
+type telnetSession struct {
+	address string
+
+	mu   sync.Mutex
+	conn net.Conn
+	naws bool
+	size Size
+
+	done chan error
+}
+
+// NewTelnetSession returns a session to a telnet server like a console server, options are negotiated such that
+// the server echoes and the window size is reported.
+func NewTelnetSession(host, port string) Session {
+	return &telnetSession{
+		address: net.JoinHostPort(host, port),
+		done:    make(chan error, 1),
+	}
+}
+
+func (s *telnetSession) Start(in io.Reader, out io.Writer, size Size) error {
+	conn, err := net.DialTimeout("tcp", s.address, 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	s.size = size
+
+	go func() {
+		_, _ = io.Copy(&telnetWriter{w: conn}, in)
+
+		// signal the end of the input to the server
+		if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+			_ = cw.CloseWrite()
+		}
+	}()
+
+	go func() {
+		s.done <- s.read(bufio.NewReader(conn), out)
+	}()
+
+	return nil
+}
+
+// read passes the data from the server to out and answers the option negotiations.
+func (s *telnetSession) read(r *bufio.Reader, out io.Writer) error {
+	var data []byte
+
+	flush := func() error {
+		if len(data) == 0 {
+			return nil
+		}
+		_, err := out.Write(data)
+		data = data[:0]
+		return err
+	}
+
+	for {
+		if r.Buffered() == 0 {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			_ = flush()
+			return err
+		}
+
+		if b != telnetIAC {
+			data = append(data, b)
+			continue
+		}
+
+		cmd, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch cmd {
+		case telnetIAC:
+			data = append(data, telnetIAC)
+		case telnetDO, telnetDONT, telnetWILL, telnetWONT:
+			var opt byte
+			opt, err = r.ReadByte()
+			if err != nil {
+				return err
+			}
+			err = s.negotiate(cmd, opt)
+		case telnetSB:
+			// sub negotiations are not supported by the client, so they are skipped
+			for {
+				b, err = r.ReadByte()
+				if err != nil {
+					return err
+				}
+				if b == telnetIAC {
+					b, err = r.ReadByte()
+					if err != nil || b == telnetSE {
+						break
+					}
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *telnetSession) negotiate(cmd, opt byte) error {
+	switch cmd {
+	case telnetDO:
+		s.mu.Lock()
+		size := s.size
+		if opt == telnetOptNAWS {
+			s.naws = !size.IsZero()
+		}
+		s.mu.Unlock()
+
+		if opt == telnetOptNAWS && !size.IsZero() {
+			err := s.send(telnetIAC, telnetWILL, opt)
+			if err != nil {
+				return err
+			}
+			return s.Resize(size)
+		}
+		return s.send(telnetIAC, telnetWONT, opt)
+	case telnetWILL:
+		if opt == telnetOptEcho || opt == telnetOptSGA {
+			return s.send(telnetIAC, telnetDO, opt)
+		}
+		return s.send(telnetIAC, telnetDONT, opt)
+	}
+
+	return nil
+}
+
+func (s *telnetSession) send(b ...byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.conn.Write(b)
+	return err
+}
+
+func (s *telnetSession) Resize(size Size) error {
+	s.mu.Lock()
+	naws := s.naws
+	s.size = size
+	s.mu.Unlock()
+
+	if !naws {
+		return nil
+	}
+
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload[0:2], uint16(size.Width))  //nolint:gosec
+	binary.BigEndian.PutUint16(payload[2:4], uint16(size.Height)) //nolint:gosec
+
+	msg := []byte{telnetIAC, telnetSB, telnetOptNAWS}
+	for _, b := range payload {
+		msg = append(msg, b)
+		if b == telnetIAC {
+			msg = append(msg, telnetIAC)
+		}
+	}
+	msg = append(msg, telnetIAC, telnetSE)
+
+	return s.send(msg...)
+}
+
+func (s *telnetSession) Wait() error {
+	if s.conn == nil {
+		return fmt.Errorf("session was not started")
+	}
+
+	err := <-s.done
+	if err == io.EOF {
+		return nil
+	}
+
+	return err
+}
+
+func (s *telnetSession) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	return s.conn.Close()
+}
+
+// telnetWriter escapes the IAC byte in the data sent to the server.
+type telnetWriter struct {
+	w io.Writer
+}
+
+func (t *telnetWriter) Write(p []byte) (int, error) {
+	var escaped []byte
+
+	for _, b := range p {
+		escaped = append(escaped, b)
+		if b == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+	}
+
+	_, err := t.w.Write(escaped)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
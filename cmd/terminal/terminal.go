@@ -0,0 +1,264 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Session is an interactive session to a remote endpoint like a switch or the serial console of a machine.
+type Session interface {
+	// Start starts the session reading from in and writing to out. if size is zero, the session is started without a pty.
+	Start(in io.Reader, out io.Writer, size Size) error
+	// Resize informs the remote endpoint about a changed terminal size.
+	Resize(size Size) error
+	// Wait blocks until the session is terminated.
+	Wait() error
+	// Close terminates the session.
+	Close() error
+}
+
+// Size is the size of a terminal in columns and rows.
+type Size struct {
+	Width  int
+	Height int
+}
+
+func (s Size) IsZero() bool {
+	return s.Width <= 0 || s.Height <= 0
+}
+
+// Terminal attaches sessions to the in- and output of the cli.
+type Terminal struct {
+	in        io.Reader
+	out       io.Writer
+	recording io.Writer
+	escape    bool
+}
+
+type Opt func(t *Terminal)
+
+// WithRecording records the output of the session in asciicast v2 format to the given writer.
+func WithRecording(w io.Writer) Opt {
+	return func(t *Terminal) {
+		t.recording = w
+	}
+}
+
+// WithEscapeSequence allows the user to detach from the session by typing ~. at the beginning of a line.
+func WithEscapeSequence() Opt {
+	return func(t *Terminal) {
+		t.escape = true
+	}
+}
+
+func New(in io.Reader, out io.Writer, opts ...Opt) *Terminal {
+	t := &Terminal{
+		in:  in,
+		out: out,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Attach starts the session and blocks until it is terminated or the user detached with the escape sequence.
+// if the input is a terminal, it is put into raw mode for the duration of the session and size changes are forwarded.
+func (t *Terminal) Attach(ctx context.Context, s Session) (err error) {
+	var (
+		in   = t.in
+		out  = t.out
+		size Size
+	)
+
+	if fd, ok := terminalFd(t.in); ok {
+		size = terminalSize(fd, t.out)
+
+		state, rawErr := term.MakeRaw(fd)
+		if rawErr != nil {
+			return fmt.Errorf("unable to put terminal into raw mode: %w", rawErr)
+		}
+		defer func() {
+			if restoreErr := term.Restore(fd, state); restoreErr != nil && err == nil {
+				err = fmt.Errorf("unable to restore terminal: %w", restoreErr)
+			}
+		}()
+
+		resizeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		go func() {
+			for range notifyResize(resizeCtx) {
+				_ = s.Resize(terminalSize(fd, t.out))
+			}
+		}()
+	}
+
+	if t.recording != nil {
+		recorder, err := NewRecorder(t.recording, size)
+		if err != nil {
+			return err
+		}
+		out = io.MultiWriter(out, recorder)
+	}
+
+	detached := make(chan struct{})
+	if t.escape {
+		var once sync.Once
+		in = newEscapeReader(in, func() {
+			once.Do(func() {
+				close(detached)
+				_ = s.Close()
+			})
+		})
+	}
+
+	if in == nil {
+		in = eofReader{}
+	}
+
+	err = s.Start(in, out, size)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = s.Close()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Wait()
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		_ = s.Close()
+		return ctx.Err()
+	}
+
+	select {
+	case <-detached:
+		return nil
+	default:
+	}
+
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+func terminalFd(r io.Reader) (int, bool) {
+	f, ok := r.(*os.File)
+	if !ok {
+		return 0, false
+	}
+
+	fd := int(f.Fd()) //nolint:gosec
+
+	return fd, term.IsTerminal(fd)
+}
+
+func terminalSize(fd int, out io.Writer) Size {
+	if f, ok := out.(*os.File); ok && term.IsTerminal(int(f.Fd())) { //nolint:gosec
+		fd = int(f.Fd()) //nolint:gosec
+	}
+
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return Size{Width: 80, Height: 24}
+	}
+
+	return Size{Width: width, Height: height}
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// escapeReader detects the escape sequence ~. at the beginning of a line, like it is known from openssh.
+// a tilde which is not followed by a dot is passed through, a double tilde sends a single one.
+type escapeReader struct {
+	r         io.Reader
+	onEscape  func()
+	lineStart bool
+	tilde     bool
+	pending   []byte
+	err       error
+}
+
+func newEscapeReader(r io.Reader, onEscape func()) *escapeReader {
+	if r == nil {
+		r = eofReader{}
+	}
+
+	return &escapeReader{
+		r:         r,
+		onEscape:  onEscape,
+		lineStart: true,
+	}
+}
+
+func (e *escapeReader) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 && e.err == nil {
+		buf := make([]byte, max(len(p), 1))
+
+		n, err := e.r.Read(buf)
+		for _, b := range buf[:n] {
+			if e.process(b) {
+				e.onEscape()
+				err = io.EOF
+				break
+			}
+		}
+
+		if err != nil {
+			e.err = err
+		}
+	}
+
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+
+	if len(e.pending) == 0 && e.err != nil {
+		return n, e.err
+	}
+
+	return n, nil
+}
+
+// process adds the given byte to the pending bytes and returns true if the escape sequence was typed.
+func (e *escapeReader) process(b byte) bool {
+	switch {
+	case e.tilde && b == '.':
+		return true
+	case e.tilde:
+		e.tilde = false
+		if b == '~' {
+			e.pending = append(e.pending, b)
+			return false
+		}
+		e.pending = append(e.pending, '~')
+	case e.lineStart && b == '~':
+		e.tilde = true
+		e.lineStart = false
+		return false
+	}
+
+	e.pending = append(e.pending, b)
+	e.lineStart = b == '\r' || b == '\n'
+
+	return false
+}
@@ -0,0 +1,246 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/synctest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func Test_escapeReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantEscaped bool
+	}{
+		{
+			name:  "no escape sequence",
+			input: "ls -la\nexit\n",
+			want:  "ls -la\nexit\n",
+		},
+		{
+			name:        "escape at the beginning",
+			input:       "~.ls",
+			want:        "",
+			wantEscaped: true,
+		},
+		{
+			name:        "escape after a line",
+			input:       "ls\r~.exit",
+			want:        "ls\r",
+			wantEscaped: true,
+		},
+		{
+			name:  "tilde in the middle of a line",
+			input: "cd ~.\n",
+			want:  "cd ~.\n",
+		},
+		{
+			name:  "tilde not followed by a dot",
+			input: "~/bin\n",
+			want:  "~/bin\n",
+		},
+		{
+			name:  "double tilde sends a single one",
+			input: "~~.\n",
+			want:  "~.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var escaped bool
+
+			got, err := io.ReadAll(newEscapeReader(strings.NewReader(tt.input), func() {
+				escaped = true
+			}))
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+			if escaped != tt.wantEscaped {
+				t.Errorf("escaped = %t, want %t", escaped, tt.wantEscaped)
+			}
+		})
+	}
+}
+
+func Test_Recorder(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var buf bytes.Buffer
+
+		r, err := NewRecorder(&buf, Size{})
+		require.NoError(t, err)
+
+		_, err = r.Write([]byte("hello\r\n"))
+		require.NoError(t, err)
+
+		want := `{"version":2,"width":80,"height":24,"timestamp":946684800}
+[0,"o","hello\r\n"]
+`
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+	})
+}
+
+func Test_telnetSession(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = lis.Close()
+	}()
+
+	negotiated := make(chan []byte, 1)
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		// the server wants to echo and asks for the window size and the terminal type
+		_, _ = conn.Write([]byte{telnetIAC, telnetWILL, telnetOptEcho, telnetIAC, telnetDO, telnetOptNAWS, telnetIAC, telnetDO, 24})
+
+		answer := make([]byte, 9)
+		_, _ = io.ReadFull(conn, answer)
+		negotiated <- answer
+
+		_, _ = conn.Write([]byte{'o', 'k', telnetIAC, telnetIAC})
+	}()
+
+	var (
+		out   bytes.Buffer
+		in, w = io.Pipe()
+		port  = strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+	)
+	defer func() {
+		_ = w.Close()
+	}()
+
+	err = New(in, &out).Attach(context.Background(), NewTelnetSession("127.0.0.1", port))
+	require.NoError(t, err)
+
+	// without a terminal no window size is reported
+	want := []byte{telnetIAC, telnetDO, telnetOptEcho, telnetIAC, telnetWONT, telnetOptNAWS, telnetIAC, telnetWONT, 24}
+	if diff := cmp.Diff(want, <-negotiated); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	if diff := cmp.Diff([]byte{'o', 'k', telnetIAC}, out.Bytes()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func Test_NewConsoleSession(t *testing.T) {
+	session, err := NewConsoleSession(context.Background(), "telnet console-server 7008")
+	require.NoError(t, err)
+	require.IsType(t, &telnetSession{}, session)
+
+	_, err = NewConsoleSession(context.Background(), "not-installed-console-binary --port 7008")
+	require.ErrorContains(t, err, `console command "not-installed-console-binary --port 7008" requires not-installed-console-binary to be installed, only telnet consoles are supported natively`)
+}
+
+func Test_parseSSHConfig(t *testing.T) {
+	config := `# switches of the lab
+Host 10.0.0.* !10.0.0.99
+  Port 2222
+  IdentityFile ~/.ssh/switch_ed25519
+  UserKnownHostsFile ~/.ssh/known_hosts_switches /etc/ssh/known_hosts_switches
+
+Match host 10.0.0.1
+  Port 3333
+
+Host *
+  Port=22
+  IdentityFile ~/.ssh/id_ed25519
+`
+
+	tests := []struct {
+		name string
+		host string
+		want *sshHostConfig
+	}{
+		{
+			name: "first value wins and identity files are accumulated",
+			host: "10.0.0.1",
+			want: &sshHostConfig{
+				port:            2222,
+				identityFiles:   []string{"/home/user/.ssh/switch_ed25519", "/home/user/.ssh/id_ed25519"},
+				knownHostsFiles: []string{"/home/user/.ssh/known_hosts_switches", "/etc/ssh/known_hosts_switches"},
+			},
+		},
+		{
+			name: "negated pattern excludes the host",
+			host: "10.0.0.99",
+			want: &sshHostConfig{
+				port:          22,
+				identityFiles: []string{"/home/user/.ssh/id_ed25519"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSSHConfig(strings.NewReader(config), tt.host, "/home/user")
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(sshHostConfig{})); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_knownHostsCallback(t *testing.T) {
+	newKey := func() ssh.PublicKey {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		key, err := ssh.NewPublicKey(pub)
+		require.NoError(t, err)
+		return key
+	}
+
+	var (
+		knownKey   = newKey()
+		changedKey = newKey()
+		file       = path.Join(t.TempDir(), "known_hosts")
+		missing    = path.Join(t.TempDir(), "known_hosts")
+		remote     = &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+	)
+
+	require.NoError(t, os.WriteFile(file, []byte(knownhosts.Line([]string{"10.0.0.1"}, knownKey)+"\n"), 0600))
+
+	callback, algorithms, err := knownHostsCallback([]string{file, missing}, "10.0.0.1", 22)
+	require.NoError(t, err)
+	require.Equal(t, []string{ssh.KeyAlgoED25519}, algorithms)
+
+	require.NoError(t, callback("10.0.0.1:22", remote, knownKey))
+
+	err = callback("10.0.0.1:22", remote, changedKey)
+	require.EqualError(t, err, fmt.Sprintf("host key of 10.0.0.1 has changed to %s, the known key is in %s:1. this could be a man-in-the-middle attack, if the host was replaced remove the known key with: ssh-keygen -R \"10.0.0.1\" -f %s", ssh.FingerprintSHA256(changedKey), file, file))
+
+	callback, algorithms, err = knownHostsCallback([]string{file}, "10.0.0.2", 2222)
+	require.NoError(t, err)
+	require.Empty(t, algorithms)
+
+	err = callback("[10.0.0.2]:2222", remote, knownKey)
+	require.EqualError(t, err, fmt.Sprintf("host key %s of [10.0.0.2]:2222 is unknown, verify its fingerprint and add it to the known hosts (%s), e.g. with: ssh-keyscan -p 2222 10.0.0.2 >> %s", ssh.FingerprintSHA256(knownKey), file, file))
+}
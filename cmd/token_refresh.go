@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// checkTokenExpiry warns when the token of the current context is expired or about to expire.
+// if auto refresh is enabled for the context, a token which is about to expire gets re-issued and persisted.
+func checkTokenExpiry(c *config.Config, command string) {
+	if viper.GetString("api-token") != "" || c.Context.Token == "" {
+		// tokens passed by flag are not stored in a context
+		return
+	}
+	if !checksTokenExpiry(command) {
+		return
+	}
+
+	expiry := c.Context.Expiry()
+	if expiry == nil {
+		return
+	}
+
+	remaining := time.Until(*expiry)
+
+	switch {
+	case remaining <= 0:
+		_, _ = fmt.Fprintf(c.PromptOut, "%s token of context \"%s\" has expired %s ago, please login again\n", color.RedString("✗"), c.Context.Name, helpers.HumanizeDuration(-remaining))
+		return
+	case remaining > c.Context.TokenExpiryWarning():
+		return
+	case !c.Context.AutoRefresh:
+		_, _ = fmt.Fprintf(c.PromptOut, "%s token of context \"%s\" expires in %s, please login again or enable auto refresh for this context\n", color.YellowString("⚠"), c.Context.Name, helpers.HumanizeDuration(remaining))
+		return
+	}
+
+	err := refreshToken(c)
+	if err != nil {
+		_, _ = fmt.Fprintf(c.PromptOut, "%s token of context \"%s\" expires in %s and could not be refreshed: %s\n", color.YellowString("⚠"), c.Context.Name, helpers.HumanizeDuration(remaining), err)
+	}
+}
+
+// checksTokenExpiry returns false for commands which manage the tokens and contexts themselves,
+// these must neither be interrupted by warnings nor rewrite the config file on their own.
+func checksTokenExpiry(command string) bool {
+	name, _, _ := strings.Cut(command, " ")
+
+	switch name {
+	case "login", "logout", "context", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return false
+	default:
+		return true
+	}
+}
+
+// refreshToken re-issues the token of the current context and writes it into the config.
+func refreshToken(c *config.Config) error {
+	mc := c.Client
+	if mc == nil {
+		var err error
+		mc, err = newApiClient(c.GetApiURL(), c.Context.Token)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := c.NewRequestContext()
+	defer cancel()
+
+	resp, err := mc.Apiv2().Token().Refresh(ctx, &apiv2.TokenServiceRefreshRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to refresh token: %w", err)
+	}
+
+	ctxs, err := c.GetContexts()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := ctxs.Get(c.Context.Name)
+	if !ok {
+		return fmt.Errorf("context %q not found", c.Context.Name)
+	}
+
+	stored.SetToken(resp.Secret)
+	if resp.Token != nil && resp.Token.Expires != nil {
+		stored.TokenExpiry = new(resp.Token.Expires.AsTime())
+	}
+
+	err = c.WriteContexts(ctxs)
+	if err != nil {
+		return err
+	}
+
+	c.Context = *stored
+
+	return nil
+}
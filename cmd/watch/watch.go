@@ -0,0 +1,230 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/metal-stack/cli/cmd/config"
+	"github.com/metal-stack/cli/cmd/filter"
+	"github.com/metal-stack/cli/cmd/tableprinters"
+	"github.com/metal-stack/cli/pkg/helpers"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const clearScreen = "\033[H\033[2J"
+
+type jsonEvent struct {
+	Type   helpers.WatchEventType `json:"type"`
+	ID     string                 `json:"id"`
+	Time   time.Time              `json:"time"`
+	Object json.RawMessage        `json:"object"`
+}
+
+// Enable adds the --watch and --interval flags to the list command of a generic cli command.
+// when watching, the list is refreshed periodically, on a terminal the table is re-rendered in place with changes highlighted,
+// otherwise change events are written as json lines.
+func Enable[C, U any, R proto.Message](c *config.Config, cmdsConfig *genericcli.CmdsConfig[C, U, R], cmd *cobra.Command) *cobra.Command {
+	listCmd, _, err := cmd.Find([]string{string(genericcli.ListCmd)})
+	if err != nil || listCmd == cmd {
+		return cmd
+	}
+
+	listCmd.Flags().BoolP("watch", "w", false, "watches the list, on a terminal changes are highlighted, otherwise change events are printed as json lines")
+	listCmd.Flags().Duration("interval", 2*time.Second, "the refresh interval used when watching")
+
+	run := listCmd.RunE
+	listCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if !viper.GetBool("watch") {
+			return run(cmd, args)
+		}
+
+		sortKeys, err := genericcli.ParseSortFlags()
+		if err != nil {
+			return err
+		}
+
+		return watch(cmd.Context(), c, func() ([]R, error) {
+			return filter.List(cmdsConfig, sortKeys...)
+		})
+	}
+
+	return cmd
+}
+
+func watch[R proto.Message](ctx context.Context, c *config.Config, list func() ([]R, error)) error {
+	interval := viper.GetDuration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	var (
+		format   = viper.GetString("output-format")
+		jsonMode = !isTerminal(c.Out) || format == "json" || format == "yaml"
+		previous []R
+		first    = true
+		ticker   = time.NewTicker(interval)
+	)
+	defer ticker.Stop()
+
+	for {
+		current, err := list()
+		if err != nil {
+			return err
+		}
+
+		events := helpers.WatchDiff(previous, current)
+
+		if jsonMode {
+			err = printJSONEvents(c.Out, events)
+		} else {
+			err = printTable(c.Out, current, events, !first, interval, format)
+		}
+		if err != nil {
+			return err
+		}
+
+		previous = current
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func printJSONEvents[R proto.Message](out io.Writer, events []helpers.WatchEvent[R]) error {
+	now := time.Now()
+
+	for _, e := range events {
+		raw, err := protojson.Marshal(e.Object)
+		if err != nil {
+			return err
+		}
+
+		line, err := json.Marshal(jsonEvent{
+			Type:   e.Type,
+			ID:     e.ID,
+			Time:   now,
+			Object: raw,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintln(out, string(line))
+	}
+
+	return nil
+}
+
+func printTable[R proto.Message](out io.Writer, current []R, events []helpers.WatchEvent[R], highlightChanges bool, interval time.Duration, format string) error {
+	var (
+		wide     = format == "wide"
+		tp       = tableprinters.New()
+		eventsBy = map[string]helpers.WatchEventType{}
+		header   []string
+		rows     [][]string
+	)
+
+	printer := printers.NewTablePrinter(&printers.TablePrinterConfig{
+		ToHeaderAndRows: func(data any, wide bool) ([]string, [][]string, error) {
+			return header, rows, nil
+		},
+		Wide:      wide,
+		Markdown:  format == "markdown",
+		NoHeaders: viper.GetBool("no-headers"),
+	}).WithOut(out)
+
+	tp.SetPrinter(printer)
+	tp.SetLastEventErrorThreshold(viper.GetDuration("last-event-error-threshold"))
+
+	for _, e := range events {
+		eventsBy[e.ID] = e.Type
+	}
+
+	rowsOf := func(item R, highlight func(format string, a ...any) string) error {
+		h, rs, err := tp.ToHeaderAndRows([]R{item}, wide)
+		if err != nil {
+			return err
+		}
+
+		header = h
+		for _, row := range rs {
+			if highlight != nil {
+				for i := range row {
+					row[i] = highlight("%s", row[i])
+				}
+			}
+			rows = append(rows, row)
+		}
+
+		return nil
+	}
+
+	for _, item := range current {
+		var highlight func(format string, a ...any) string
+
+		// the initial result is not highlighted, everything would be new
+		if highlightChanges {
+			switch eventsBy[helpers.ProtoID(item)] {
+			case helpers.WatchEventAdded:
+				highlight = color.GreenString
+			case helpers.WatchEventChanged:
+				highlight = color.YellowString
+			}
+		}
+
+		err := rowsOf(item, highlight)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range events {
+		if e.Type != helpers.WatchEventRemoved {
+			continue
+		}
+
+		err := rowsOf(e.Object, color.New(color.FgRed, color.CrossedOut).Sprintf)
+		if err != nil {
+			return err
+		}
+	}
+
+	if header == nil {
+		var zero []R
+		h, _, err := tp.ToHeaderAndRows(zero, wide)
+		if err != nil {
+			return err
+		}
+		header = h
+	}
+
+	_, _ = fmt.Fprint(out, clearScreen)
+	_, _ = fmt.Fprintf(out, "Every %s: %s    %s\n\n", interval, strings.Join(os.Args, " "), time.Now().Format(time.DateTime))
+
+	return printer.Print(nil)
+}
+
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return term.IsTerminal(int(f.Fd())) //nolint:gosec
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+)
+
+// AccessRecord is a privileged access performed with the cli. it is stored locally in order to correlate
+// the access with the audit traces of the api afterwards.
+type AccessRecord struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Target string    `json:"target"`
+	Reason string    `json:"reason"`
+	// Context is the name of the cli context the access was performed with
+	Context string `json:"context"`
+	// User is the login of the user who performed the access
+	User       string `json:"user"`
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// AccessHistoryEntry is a privileged access, either recorded locally, found in the audit traces of the api or both.
+type AccessHistoryEntry struct {
+	Record *AccessRecord     `json:"record,omitempty"`
+	Trace  *apiv2.AuditTrace `json:"trace,omitempty"`
+}
+
+func (e *AccessHistoryEntry) Time() time.Time {
+	if e.Trace != nil && e.Trace.Timestamp != nil {
+		return e.Trace.Timestamp.AsTime()
+	}
+	if e.Record != nil {
+		return e.Record.Time
+	}
+	return time.Time{}
+}
+
+// AccessReason returns the reason for a privileged access, prefixed with the ticket if given.
+// the reason must not be empty and has to match the pattern if one is configured.
+func AccessReason(reason, ticket, pattern string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	ticket = strings.TrimSpace(ticket)
+
+	if reason == "" {
+		return "", fmt.Errorf("a non-empty --reason is required for privileged access")
+	}
+
+	if ticket != "" {
+		reason = fmt.Sprintf("[%s] %s", ticket, reason)
+	}
+
+	if pattern == "" {
+		return reason, nil
+	}
+
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("reason pattern of the current context is invalid: %w", err)
+	}
+
+	if !r.MatchString(reason) {
+		return "", fmt.Errorf("reason %q does not match the pattern %q required by the current context", reason, pattern)
+	}
+
+	return reason, nil
+}
+
+// CorrelateAccessHistory matches the locally recorded accesses with the audit traces of the same method
+// which are closest in time and within the given tolerance. the entries are ordered by time.
+func CorrelateAccessHistory(records []*AccessRecord, traces []*apiv2.AuditTrace, tolerance time.Duration) []*AccessHistoryEntry {
+	var (
+		entries []*AccessHistoryEntry
+		used    = map[int]bool{}
+	)
+
+	for _, record := range records {
+		var (
+			match = -1
+			best  time.Duration
+		)
+
+		for i, trace := range traces {
+			if used[i] || trace.Method != record.Method || trace.Timestamp == nil {
+				continue
+			}
+
+			diff := trace.Timestamp.AsTime().Sub(record.Time).Abs()
+			if diff > tolerance {
+				continue
+			}
+
+			if match < 0 || diff < best {
+				match = i
+				best = diff
+			}
+		}
+
+		entry := &AccessHistoryEntry{Record: record}
+		if match >= 0 {
+			used[match] = true
+			entry.Trace = traces[match]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	for i, trace := range traces {
+		if used[i] {
+			continue
+		}
+
+		entries = append(entries, &AccessHistoryEntry{Trace: trace})
+	}
+
+	slices.SortStableFunc(entries, func(a, b *AccessHistoryEntry) int {
+		return cmp.Compare(a.Time().UnixNano(), b.Time().UnixNano())
+	})
+
+	return entries
+}
@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_AccessReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  string
+		ticket  string
+		pattern string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "reason only",
+			reason: " debugging boot issue ",
+			want:   "debugging boot issue",
+		},
+		{
+			name:   "reason with ticket",
+			reason: "debugging boot issue",
+			ticket: "OPS-123",
+			want:   "[OPS-123] debugging boot issue",
+		},
+		{
+			name:    "empty reason",
+			reason:  "  ",
+			ticket:  "OPS-123",
+			wantErr: "a non-empty --reason is required for privileged access",
+		},
+		{
+			name:    "pattern matches",
+			reason:  "debugging boot issue",
+			ticket:  "OPS-123",
+			pattern: `^\[OPS-\d+\] `,
+			want:    "[OPS-123] debugging boot issue",
+		},
+		{
+			name:    "pattern does not match",
+			reason:  "debugging boot issue",
+			pattern: `^\[OPS-\d+\] `,
+			wantErr: `reason "debugging boot issue" does not match the pattern "^\\[OPS-\\d+\\] " required by the current context`,
+		},
+		{
+			name:    "invalid pattern",
+			reason:  "debugging boot issue",
+			pattern: `[`,
+			wantErr: "reason pattern of the current context is invalid: error parsing regexp: missing closing ]: `[`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AccessReason(tt.reason, tt.ticket, tt.pattern)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_CorrelateAccessHistory(t *testing.T) {
+	var (
+		now = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		consoleRecord = &AccessRecord{Time: now, Method: "/console", Target: "m1", Reason: "debugging"}
+		vpnRecord     = &AccessRecord{Time: now.Add(time.Hour), Method: "/vpn", Target: "p1", Reason: "maintenance"}
+		localRecord   = &AccessRecord{Time: now.Add(-time.Hour), Method: "/console", Target: "m2", Reason: "offline"}
+
+		consoleTrace = &apiv2.AuditTrace{Uuid: "t1", Method: "/console", Timestamp: timestamppb.New(now.Add(time.Second))}
+		otherTrace   = &apiv2.AuditTrace{Uuid: "t2", Method: "/console", Timestamp: timestamppb.New(now.Add(10 * time.Second))}
+		vpnTrace     = &apiv2.AuditTrace{Uuid: "t3", Method: "/vpn", Timestamp: timestamppb.New(now.Add(time.Hour - time.Second))}
+	)
+
+	got := CorrelateAccessHistory(
+		[]*AccessRecord{vpnRecord, consoleRecord, localRecord},
+		[]*apiv2.AuditTrace{otherTrace, vpnTrace, consoleTrace},
+		time.Minute,
+	)
+
+	want := []*AccessHistoryEntry{
+		{Record: localRecord},
+		{Record: consoleRecord, Trace: consoleTrace},
+		{Trace: otherTrace},
+		{Record: vpnRecord, Trace: vpnTrace},
+	}
+
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
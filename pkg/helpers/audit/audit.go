@@ -7,9 +7,75 @@ import (
 	"time"
 
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// ListLimit is the amount of audit traces requested at once by ListAll.
+const ListLimit int32 = 500
+
+// ListAll lists all audit traces of the query between its from and to timestamp, to defaults to now.
+// as the api does not support paging, a time range in which the limit is reached is split into halves
+// until every range returns less traces than the limit.
+func ListAll(query *apiv2.AuditQuery, now time.Time, list func(*apiv2.AuditQuery) ([]*apiv2.AuditTrace, error)) ([]*apiv2.AuditTrace, error) {
+	to := now
+	if query.To != nil {
+		to = query.To.AsTime()
+	}
+
+	var (
+		traces []*apiv2.AuditTrace
+		seen   = map[string]bool{}
+	)
+
+	var listRange func(from, to time.Time) error
+	listRange = func(from, to time.Time) error {
+		q := proto.Clone(query).(*apiv2.AuditQuery)
+		q.From = timestamppb.New(from)
+		q.To = timestamppb.New(to)
+		q.Limit = new(ListLimit)
+
+		result, err := list(q)
+		if err != nil {
+			return err
+		}
+
+		if len(result) >= int(ListLimit) {
+			if to.Sub(from) <= time.Second {
+				return fmt.Errorf("more than %d audit traces between %s and %s, unable to list all of them", ListLimit, from.Format(time.RFC3339), to.Format(time.RFC3339))
+			}
+
+			middle := from.Add(to.Sub(from) / 2)
+			if err := listRange(from, middle); err != nil {
+				return err
+			}
+			return listRange(middle, to)
+		}
+
+		for _, trace := range result {
+			// traces on the boundary of two ranges may be returned twice
+			if trace.Uuid != "" {
+				key := trace.Uuid + "/" + trace.Phase.String()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+
+			traces = append(traces, trace)
+		}
+
+		return nil
+	}
+
+	err := listRange(query.GetFrom().AsTime(), to)
+	if err != nil {
+		return nil, err
+	}
+
+	return traces, nil
+}
+
 func RelativeDateTime(s string) (*timestamppb.Timestamp, error) {
 	if s == "" {
 		return nil, nil
@@ -0,0 +1,101 @@
+package helpersaudit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_ListAll(t *testing.T) {
+	var (
+		now   = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		from  = now.Add(-time.Hour)
+		users = new("me")
+	)
+
+	// the traces at the boundary of two ranges are returned by both of them
+	traceAt := func(ts time.Time) *apiv2.AuditTrace {
+		return &apiv2.AuditTrace{
+			Uuid:      fmt.Sprintf("trace-%d", ts.Unix()),
+			Timestamp: timestamppb.New(ts),
+			Phase:     apiv2.AuditPhase_AUDIT_PHASE_REQUEST,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		traces    int
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "below the limit",
+			traces:    10,
+			wantCalls: 1,
+		},
+		{
+			name:      "split ranges reaching the limit",
+			traces:    int(ListLimit) + 10,
+			wantCalls: 7,
+		},
+		{
+			name:    "limit reached within a second",
+			traces:  0,
+			wantErr: fmt.Sprintf("more than %d audit traces between", ListLimit),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+
+			got, err := ListAll(&apiv2.AuditQuery{From: timestamppb.New(from), User: users}, now, func(q *apiv2.AuditQuery) ([]*apiv2.AuditTrace, error) {
+				calls++
+
+				if q.GetUser() != "me" || q.GetLimit() != ListLimit {
+					return nil, fmt.Errorf("unexpected query: %v", q)
+				}
+
+				var result []*apiv2.AuditTrace
+				if tt.wantErr != "" {
+					// every range is full
+					for range ListLimit {
+						result = append(result, traceAt(q.From.AsTime()))
+					}
+					return result, nil
+				}
+
+				for i := range tt.traces {
+					ts := from.Add(time.Duration(i) * time.Second)
+					if ts.Before(q.From.AsTime()) || ts.After(q.To.AsTime()) {
+						continue
+					}
+					result = append(result, traceAt(ts))
+				}
+
+				return result, nil
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.traces, len(got)); diff != "" {
+				t.Errorf("traces diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("calls diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
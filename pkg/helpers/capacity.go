@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/api/go/metalstack/api/v2/apiv2connect"
+	"github.com/metal-stack/cli/cmd/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// CapacityEventMethods are the api methods which allocate or release machines.
+var CapacityEventMethods = []string{
+	apiv2connect.MachineServiceCreateProcedure,
+	apiv2connect.MachineServiceDeleteProcedure,
+	adminv2connect.MachineServiceDeleteProcedure,
+}
+
+// CapacityEvent is the allocation (delta 1) or release (delta -1) of a machine.
+type CapacityEvent struct {
+	Partition string
+	Size      string
+	Delta     int64
+}
+
+// CapacityForecast is the projected capacity of a size in a partition.
+type CapacityForecast struct {
+	Partition   string `json:"partition"`
+	Size        string `json:"size"`
+	Free        int64  `json:"free"`
+	Allocations int64  `json:"allocations"`
+	Releases    int64  `json:"releases"`
+	// NetPerDay is the average amount of machines allocated per day minus the ones released
+	NetPerDay float64 `json:"net_per_day"`
+	// ExhaustedAt is the projected point in time when no free machines are left, it is nil if the size does not run out
+	ExhaustedAt *time.Time `json:"exhausted_at,omitempty"`
+	// Warnings contains the thresholds this size is below
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Runway returns the duration until the size is projected to run out of free machines.
+func (f *CapacityForecast) Runway(now time.Time) *time.Duration {
+	if f.ExhaustedAt == nil {
+		return nil
+	}
+	return new(max(f.ExhaustedAt.Sub(now), 0))
+}
+
+// CapacityEventsFromTraces derives the machine allocations and releases from the response audit traces
+// of the machine create and delete methods. traces of failed calls or with a body that cannot be interpreted are skipped.
+func CapacityEventsFromTraces(traces []*apiv2.AuditTrace) []CapacityEvent {
+	var events []CapacityEvent
+
+	for _, trace := range traces {
+		if trace.Body == nil {
+			continue
+		}
+		if trace.ResultCode != nil && codes.Code(uint32(*trace.ResultCode)) != codes.OK { //nolint:gosec
+			continue
+		}
+
+		var (
+			resp interface {
+				proto.Message
+				GetMachine() *apiv2.Machine
+			}
+			delta int64
+		)
+
+		switch trace.Method {
+		case apiv2connect.MachineServiceCreateProcedure:
+			resp, delta = &apiv2.MachineServiceCreateResponse{}, 1
+		case apiv2connect.MachineServiceDeleteProcedure:
+			resp, delta = &apiv2.MachineServiceDeleteResponse{}, -1
+		case adminv2connect.MachineServiceDeleteProcedure:
+			resp, delta = &adminv2.MachineServiceDeleteResponse{}, -1
+		default:
+			continue
+		}
+
+		err := protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal([]byte(*trace.Body), resp)
+		if err != nil {
+			continue
+		}
+
+		machine := resp.GetMachine()
+		if machine.GetPartition().GetId() == "" || machine.GetSize().GetId() == "" {
+			continue
+		}
+
+		events = append(events, CapacityEvent{
+			Partition: machine.Partition.Id,
+			Size:      machine.Size.Id,
+			Delta:     delta,
+		})
+	}
+
+	return events
+}
+
+// ForecastCapacity projects when the sizes of the given partition capacities run out of free machines, assuming that
+// machines continue to be allocated and released at the same rate as in the given events, which occurred within the window.
+func ForecastCapacity(capacities []*adminv2.PartitionCapacity, events []CapacityEvent, window time.Duration, now time.Time, thresholds *config.CapacityThresholds) []*CapacityForecast {
+	type key struct {
+		partition, size string
+	}
+
+	var (
+		forecasts []*CapacityForecast
+		byKey     = map[key]*CapacityForecast{}
+	)
+
+	for _, pc := range capacities {
+		for _, c := range pc.MachineSizeCapacities {
+			f := &CapacityForecast{
+				Partition: pc.Partition,
+				Size:      c.Size,
+				Free:      c.Free,
+			}
+
+			forecasts = append(forecasts, f)
+			byKey[key{partition: pc.Partition, size: c.Size}] = f
+		}
+	}
+
+	for _, e := range events {
+		f, ok := byKey[key{partition: e.Partition, size: e.Size}]
+		if !ok {
+			continue
+		}
+
+		if e.Delta > 0 {
+			f.Allocations += e.Delta
+		} else {
+			f.Releases -= e.Delta
+		}
+	}
+
+	for _, f := range forecasts {
+		net := f.Allocations - f.Releases
+
+		if window > 0 {
+			f.NetPerDay = float64(net) * 24 / window.Hours()
+		}
+
+		switch {
+		case f.Free <= 0:
+			f.ExhaustedAt = new(now)
+		case net > 0 && window > 0:
+			f.ExhaustedAt = new(now.Add(time.Duration(float64(window) * float64(f.Free) / float64(net))))
+		}
+
+		if minFree := thresholds.MinFreeForSize(f.Size); f.Free < minFree {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("less than %d free", minFree))
+		}
+
+		if thresholds != nil && thresholds.MinRunway != nil {
+			if runway := f.Runway(now); runway != nil && *runway < *thresholds.MinRunway {
+				f.Warnings = append(f.Warnings, fmt.Sprintf("runs out within %s", HumanizeDuration(*thresholds.MinRunway)))
+			}
+		}
+	}
+
+	slices.SortStableFunc(forecasts, func(a, b *CapacityForecast) int {
+		return cmp.Or(cmp.Compare(a.Partition, b.Partition), cmp.Compare(a.Size, b.Size))
+	})
+
+	return forecasts
+}
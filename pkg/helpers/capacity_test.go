@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/api/go/metalstack/api/v2/apiv2connect"
+	"github.com/metal-stack/cli/cmd/config"
+)
+
+func Test_CapacityEventsFromTraces(t *testing.T) {
+	traces := []*apiv2.AuditTrace{
+		{
+			Method: apiv2connect.MachineServiceCreateProcedure,
+			Body:   new(`{"machine":{"uuid":"m1","size":{"id":"c1-large"},"partition":{"id":"fra"}}}`),
+		},
+		{
+			Method:     apiv2connect.MachineServiceCreateProcedure,
+			Body:       new(`{"machine":{"uuid":"m2","size":{"id":"c1-large"},"partition":{"id":"fra"}}}`),
+			ResultCode: new(int32(9)),
+		},
+		{
+			Method: adminv2connect.MachineServiceDeleteProcedure,
+			Body:   new(`{"machine":{"uuid":"m3","size":{"id":"c1-large"},"partition":{"id":"fra"},"unknownField":true}}`),
+		},
+		{
+			Method: apiv2connect.MachineServiceDeleteProcedure,
+			Body:   new(`not json`),
+		},
+		{
+			Method: apiv2connect.MachineServiceDeleteProcedure,
+		},
+	}
+
+	want := []CapacityEvent{
+		{Partition: "fra", Size: "c1-large", Delta: 1},
+		{Partition: "fra", Size: "c1-large", Delta: -1},
+	}
+
+	if diff := cmp.Diff(want, CapacityEventsFromTraces(traces)); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func Test_ForecastCapacity(t *testing.T) {
+	var (
+		now    = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		window = 10 * 24 * time.Hour
+
+		capacities = []*adminv2.PartitionCapacity{
+			{
+				Partition: "fra",
+				MachineSizeCapacities: []*adminv2.MachineSizeCapacity{
+					{Size: "c1-medium", Free: 20},
+					{Size: "c1-large", Free: 10},
+				},
+			},
+			{
+				Partition: "ams",
+				MachineSizeCapacities: []*adminv2.MachineSizeCapacity{
+					{Size: "c1-large", Free: 0},
+				},
+			},
+		}
+
+		events []CapacityEvent
+	)
+
+	for range 6 {
+		events = append(events, CapacityEvent{Partition: "fra", Size: "c1-large", Delta: 1})
+	}
+	events = append(events,
+		CapacityEvent{Partition: "fra", Size: "c1-large", Delta: -1},
+		CapacityEvent{Partition: "fra", Size: "c1-medium", Delta: -1},
+		CapacityEvent{Partition: "unknown", Size: "c1-large", Delta: 1},
+	)
+
+	tests := []struct {
+		name       string
+		thresholds *config.CapacityThresholds
+		want       []*CapacityForecast
+	}{
+		{
+			name: "without thresholds",
+			want: []*CapacityForecast{
+				{Partition: "ams", Size: "c1-large", Free: 0, ExhaustedAt: new(now)},
+				{Partition: "fra", Size: "c1-large", Free: 10, Allocations: 6, Releases: 1, NetPerDay: 0.5, ExhaustedAt: new(now.Add(20 * 24 * time.Hour))},
+				{Partition: "fra", Size: "c1-medium", Free: 20, Releases: 1, NetPerDay: -0.1},
+			},
+		},
+		{
+			name: "with thresholds",
+			thresholds: &config.CapacityThresholds{
+				MinFree: map[string]int64{
+					"*":         5,
+					"c1-medium": 25,
+				},
+				MinRunway: new(30 * 24 * time.Hour),
+			},
+			want: []*CapacityForecast{
+				{Partition: "ams", Size: "c1-large", Free: 0, ExhaustedAt: new(now), Warnings: []string{"less than 5 free", "runs out within 30d"}},
+				{Partition: "fra", Size: "c1-large", Free: 10, Allocations: 6, Releases: 1, NetPerDay: 0.5, ExhaustedAt: new(now.Add(20 * 24 * time.Hour)), Warnings: []string{"runs out within 30d"}},
+				{Partition: "fra", Size: "c1-medium", Free: 20, Releases: 1, NetPerDay: -0.1, Warnings: []string{"less than 25 free"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForecastCapacity(capacities, events, window, now, tt.thresholds)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CustomColumn is a column of the custom-columns output format, the value of a column is taken from a field path of the printed entity.
+type CustomColumn struct {
+	Header string
+	Path   string
+}
+
+type fieldPathSegment struct {
+	name  string
+	index *string
+}
+
+// ParseCustomColumns parses a custom columns specification in the form <header>:<field path>,...
+// e.g. ID:.uuid,RACK:.rack,SIZE:.size.id
+func ParseCustomColumns(spec string) ([]CustomColumn, error) {
+	var columns []CustomColumn
+
+	for column := range strings.SplitSeq(spec, ",") {
+		header, path, ok := strings.Cut(strings.TrimSpace(column), ":")
+		if !ok || header == "" || path == "" {
+			return nil, fmt.Errorf("custom column %q must be given in the form <header>:<field path>", column)
+		}
+
+		_, err := parseFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, CustomColumn{Header: header, Path: path})
+	}
+
+	return columns, nil
+}
+
+// parseFieldPath parses a field path like .allocation.networks[0].ips, map entries can be addressed like .meta.labels.labels[key].
+// the index [*] selects all elements of a list, which is the same as omitting the index.
+func parseFieldPath(path string) ([]fieldPathSegment, error) {
+	var segments []fieldPathSegment
+
+	if path == "." {
+		// the entity itself
+		return nil, nil
+	}
+
+	for _, segment := range splitFieldPath(strings.TrimPrefix(path, ".")) {
+		name, rest, hasIndex := strings.Cut(segment, "[")
+		if name == "" {
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+
+		s := fieldPathSegment{name: name}
+
+		if hasIndex {
+			index, ok := strings.CutSuffix(rest, "]")
+			if !ok || index == "" {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+			s.index = &index
+		}
+
+		segments = append(segments, s)
+	}
+
+	return segments, nil
+}
+
+// splitFieldPath splits a field path at its dots, dots within an index are retained.
+func splitFieldPath(path string) []string {
+	var (
+		segments []string
+		start    int
+		inIndex  bool
+	)
+
+	for i, r := range path {
+		switch r {
+		case '[':
+			inIndex = true
+		case ']':
+			inIndex = false
+		case '.':
+			if !inIndex {
+				segments = append(segments, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(segments, path[start:])
+}
+
+// FieldPathValue returns the value of the given field path within v. protobuf messages are evaluated by their field names or json names,
+// other values by their json representation. the values of a list without an index are joined by comma, unset fields result in an empty string.
+func FieldPathValue(v any, path string) (string, error) {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	var values []string
+
+	if m, ok := v.(proto.Message); ok {
+		values, err = messageFieldPathValues(m.ProtoReflect(), segments)
+	} else {
+		var generic any
+		generic, err = toGeneric(v)
+		if err != nil {
+			return "", err
+		}
+		values, err = genericFieldPathValues(generic, segments)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to evaluate field path %q: %w", path, err)
+	}
+
+	return strings.Join(values, ","), nil
+}
+
+func messageFieldPathValues(m protoreflect.Message, segments []fieldPathSegment) ([]string, error) {
+	if !m.IsValid() {
+		return nil, nil
+	}
+	if len(segments) == 0 {
+		value, err := formatMessage(m)
+		if err != nil {
+			return nil, err
+		}
+		return []string{value}, nil
+	}
+
+	var (
+		segment = segments[0]
+		rest    = segments[1:]
+		fields  = m.Descriptor().Fields()
+		fd      = fields.ByName(protoreflect.Name(segment.name))
+	)
+
+	if fd == nil {
+		fd = fields.ByJSONName(segment.name)
+	}
+	if fd == nil {
+		return nil, fmt.Errorf("field %q does not exist in %s", segment.name, m.Descriptor().FullName())
+	}
+
+	value := m.Get(fd)
+
+	switch {
+	case fd.IsList():
+		list := value.List()
+
+		if segment.index != nil && *segment.index != "*" {
+			i, err := strconv.Atoi(*segment.index)
+			if err != nil {
+				return nil, fmt.Errorf("index of list %q must be a number", segment.name)
+			}
+			if i < 0 || i >= list.Len() {
+				return nil, nil
+			}
+			return fieldValues(fd, list.Get(i), rest)
+		}
+
+		var values []string
+		for i := range list.Len() {
+			v, err := fieldValues(fd, list.Get(i), rest)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v...)
+		}
+		return values, nil
+
+	case fd.IsMap():
+		entries := value.Map()
+
+		key := segment.index
+		if key == nil && len(rest) > 0 {
+			key = &rest[0].name
+			rest = rest[1:]
+		}
+
+		if key == nil {
+			var values []string
+			entries.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				values = append(values, k.String()+"="+formatScalar(fd.MapValue(), v))
+				return true
+			})
+			slices.Sort(values)
+			return []string{strings.Join(values, ",")}, nil
+		}
+
+		var values []string
+		var err error
+		entries.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			if k.String() != *key {
+				return true
+			}
+			values, err = fieldValues(fd.MapValue(), v, rest)
+			return false
+		})
+		return values, err
+
+	default:
+		if segment.index != nil {
+			return nil, fmt.Errorf("field %q is neither a list nor a map", segment.name)
+		}
+		if fd.HasPresence() && !m.Has(fd) {
+			return nil, nil
+		}
+		return fieldValues(fd, value, rest)
+	}
+}
+
+func fieldValues(fd protoreflect.FieldDescriptor, v protoreflect.Value, rest []fieldPathSegment) ([]string, error) {
+	if fd.Message() != nil {
+		return messageFieldPathValues(v.Message(), rest)
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("field %q is not a message", fd.Name())
+	}
+	return []string{formatScalar(fd, v)}, nil
+}
+
+func formatScalar(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		value, err := formatMessage(v.Message())
+		if err != nil {
+			return ""
+		}
+		return value
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func formatMessage(m protoreflect.Message) (string, error) {
+	switch msg := m.Interface().(type) {
+	case *timestamppb.Timestamp:
+		return msg.AsTime().Format(time.RFC3339), nil
+	case *durationpb.Duration:
+		return msg.AsDuration().String(), nil
+	}
+
+	raw, err := protojson.Marshal(m.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	// protojson randomly adds whitespace to its output, which would make the output unstable
+	var compacted bytes.Buffer
+	err = json.Compact(&compacted, raw)
+	if err != nil {
+		return "", err
+	}
+
+	return compacted.String(), nil
+}
+
+func toGeneric(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var generic any
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return generic, nil
+}
+
+func genericFieldPathValues(v any, segments []fieldPathSegment) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if len(segments) == 0 {
+		switch value := v.(type) {
+		case map[string]any, []any:
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			return []string{string(raw)}, nil
+		default:
+			return []string{fmt.Sprint(value)}, nil
+		}
+	}
+
+	object, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("field %q does not exist", segments[0].name)
+	}
+
+	value, ok := object[segments[0].name]
+	if !ok {
+		return nil, nil
+	}
+
+	if index := segments[0].index; index != nil && *index != "*" {
+		switch indexed := value.(type) {
+		case []any:
+			i, err := strconv.Atoi(*index)
+			if err != nil {
+				return nil, fmt.Errorf("index of list %q must be a number", segments[0].name)
+			}
+			if i < 0 || i >= len(indexed) {
+				return nil, nil
+			}
+			return genericFieldPathValues(indexed[i], segments[1:])
+		case map[string]any:
+			return genericFieldPathValues(indexed[*index], segments[1:])
+		default:
+			return nil, fmt.Errorf("field %q is neither a list nor a map", segments[0].name)
+		}
+	}
+
+	if list, ok := value.([]any); ok {
+		var values []string
+		for _, item := range list {
+			v, err := genericFieldPathValues(item, segments[1:])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v...)
+		}
+		return values, nil
+	}
+
+	return genericFieldPathValues(value, segments[1:])
+}
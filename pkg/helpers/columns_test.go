@@ -0,0 +1,237 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_ParseCustomColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []CustomColumn
+		wantErr string
+	}{
+		{
+			name: "columns",
+			spec: "ID:.uuid,RACK:.rack,SIZE:.size.id",
+			want: []CustomColumn{
+				{Header: "ID", Path: ".uuid"},
+				{Header: "RACK", Path: ".rack"},
+				{Header: "SIZE", Path: ".size.id"},
+			},
+		},
+		{
+			name: "indexed paths",
+			spec: "IP:.allocation.networks[0].ips[0], LABEL:.meta.labels.labels[c]",
+			want: []CustomColumn{
+				{Header: "IP", Path: ".allocation.networks[0].ips[0]"},
+				{Header: "LABEL", Path: ".meta.labels.labels[c]"},
+			},
+		},
+		{
+			name:    "missing path",
+			spec:    "ID:.uuid,RACK",
+			wantErr: `custom column "RACK" must be given in the form <header>:<field path>`,
+		},
+		{
+			name:    "invalid path",
+			spec:    "ID:.allocation..uuid",
+			wantErr: `invalid field path ".allocation..uuid"`,
+		},
+		{
+			name:    "unterminated index",
+			spec:    "IP:.allocation.networks[0",
+			wantErr: `invalid field path ".allocation.networks[0"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCustomColumns(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_FieldPathValue(t *testing.T) {
+	var (
+		created = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		machine = &apiv2.Machine{
+			Uuid: "m1",
+			Rack: "rack-1",
+			Size: &apiv2.Size{Id: "c1-large-x86"},
+			Meta: &apiv2.Meta{
+				Labels: &apiv2.Labels{
+					Labels: map[string]string{
+						"b":         "2",
+						"a":         "1",
+						"team.io/x": "y",
+					},
+				},
+			},
+			Allocation: &apiv2.MachineAllocation{
+				Meta: &apiv2.Meta{
+					CreatedAt: timestamppb.New(created),
+				},
+				AllocationType: apiv2.MachineAllocationType_MACHINE_ALLOCATION_TYPE_FIREWALL,
+				Networks: []*apiv2.MachineNetwork{
+					{Network: "internet", Ips: []string{"1.2.3.4"}},
+					{Network: "tenant", Ips: []string{"10.0.0.1", "10.0.0.2"}},
+				},
+			},
+		}
+		forecast = &CapacityForecast{
+			Partition: "partition-1",
+			Size:      "c1-large-x86",
+			Free:      1000000,
+			Warnings:  []string{"less than 5 free", "runs out within 7d"},
+		}
+	)
+
+	tests := []struct {
+		name    string
+		v       any
+		path    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "field",
+			v:    machine,
+			path: ".uuid",
+			want: "m1",
+		},
+		{
+			name: "nested field",
+			v:    machine,
+			path: ".size.id",
+			want: "c1-large-x86",
+		},
+		{
+			name: "json name",
+			v:    machine,
+			path: ".allocation.allocationType",
+			want: "MACHINE_ALLOCATION_TYPE_FIREWALL",
+		},
+		{
+			name: "timestamp",
+			v:    machine,
+			path: ".allocation.meta.created_at",
+			want: "2000-01-01T00:00:00Z",
+		},
+		{
+			name: "list elements are joined",
+			v:    machine,
+			path: ".allocation.networks.ips",
+			want: "1.2.3.4,10.0.0.1,10.0.0.2",
+		},
+		{
+			name: "list index",
+			v:    machine,
+			path: ".allocation.networks[1].ips[0]",
+			want: "10.0.0.1",
+		},
+		{
+			name: "list index out of range",
+			v:    machine,
+			path: ".allocation.networks[2].ips",
+			want: "",
+		},
+		{
+			name: "map",
+			v:    machine,
+			path: ".meta.labels.labels",
+			want: "a=1,b=2,team.io/x=y",
+		},
+		{
+			name: "map key",
+			v:    machine,
+			path: ".meta.labels.labels.b",
+			want: "2",
+		},
+		{
+			name: "map key with dots",
+			v:    machine,
+			path: ".meta.labels.labels[team.io/x]",
+			want: "y",
+		},
+		{
+			name: "unset message",
+			v:    machine,
+			path: ".partition.id",
+			want: "",
+		},
+		{
+			name:    "unknown field",
+			v:       machine,
+			path:    ".foo",
+			wantErr: `unable to evaluate field path ".foo": field "foo" does not exist in metalstack.api.v2.Machine`,
+		},
+		{
+			name:    "scalar is not a message",
+			v:       machine,
+			path:    ".uuid.foo",
+			wantErr: `unable to evaluate field path ".uuid.foo": field "uuid" is not a message`,
+		},
+		{
+			name: "non proto value",
+			v:    forecast,
+			path: ".free",
+			want: "1000000",
+		},
+		{
+			name: "non proto list",
+			v:    forecast,
+			path: ".warnings",
+			want: "less than 5 free,runs out within 7d",
+		},
+		{
+			name: "non proto list index",
+			v:    forecast,
+			path: ".warnings[1]",
+			want: "runs out within 7d",
+		},
+		{
+			name: "non proto unset field",
+			v:    forecast,
+			path: ".exhausted_at",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FieldPathValue(tt.v, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package helpers
+
+import "sync"
+
+// RunConcurrently calls fn for every index in [0, n) with at most the given amount of concurrent workers and waits until all calls have finished.
+func RunConcurrently(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan int)
+	)
+
+	for range min(workers, n) {
+		wg.Go(func() {
+			for i := range jobs {
+				fn(i)
+			}
+		})
+	}
+
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_RunConcurrently(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		workers int
+	}{
+		{
+			name:    "no items",
+			n:       0,
+			workers: 3,
+		},
+		{
+			name:    "less items than workers",
+			n:       2,
+			workers: 5,
+		},
+		{
+			name:    "more items than workers",
+			n:       20,
+			workers: 3,
+		},
+		{
+			name:    "invalid worker count",
+			n:       4,
+			workers: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				running   atomic.Int32
+				maxActive int32
+				got       = make([]bool, tt.n)
+				want      = make([]bool, tt.n)
+			)
+
+			for i := range want {
+				want[i] = true
+			}
+
+			RunConcurrently(tt.n, tt.workers, func(i int) {
+				active := running.Add(1)
+				defer running.Add(-1)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				maxActive = max(maxActive, active)
+				got[i] = true
+			})
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			if limit := int32(max(tt.workers, 1)); maxActive > limit {
+				t.Errorf("%d workers were running concurrently, limit was %d", maxActive, limit)
+			}
+		})
+	}
+}
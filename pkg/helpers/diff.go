@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"maps"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// diffIgnoredFields are not relevant for comparing the state of an entity, they only control how an update is applied
+var diffIgnoredFields = []protoreflect.Name{"update_meta"}
+
+// ProtoDiff returns a field-level diff between the current and the desired state of an entity.
+// Lines prefixed with "-" are removed, lines prefixed with "+" are added. An empty string is returned when there are no changes.
+func ProtoDiff(current, desired proto.Message) string {
+	return cmp.Diff(withoutIgnoredFields(current), withoutIgnoredFields(desired), protocmp.Transform())
+}
+
+func withoutIgnoredFields(m proto.Message) proto.Message {
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return m
+	}
+
+	m = proto.Clone(m)
+
+	msg := m.ProtoReflect()
+	for _, name := range diffIgnoredFields {
+		if fd := msg.Descriptor().Fields().ByName(name); fd != nil {
+			msg.Clear(fd)
+		}
+	}
+
+	return m
+}
+
+// ApplyUpdate returns the desired state of an entity by applying an update request to the update request representing its current state.
+// Every populated field of the update replaces the current value, such that repeated fields are not appended.
+// Label patches are applied explicitly, including the removal of labels.
+func ApplyUpdate(current, update proto.Message) proto.Message {
+	desired := proto.Clone(current)
+
+	msg := desired.ProtoReflect()
+	proto.Clone(update).ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if labels, ok := asUpdateLabels(fd, v); ok {
+			current, _ := msg.Get(fd).Message().Interface().(*apiv2.UpdateLabels)
+
+			patched := patchLabels(current, labels)
+			if patched == nil {
+				msg.Clear(fd)
+				return true
+			}
+
+			msg.Set(fd, protoreflect.ValueOfMessage(patched.ProtoReflect()))
+			return true
+		}
+
+		msg.Set(fd, v)
+		return true
+	})
+
+	return desired
+}
+
+func asUpdateLabels(fd protoreflect.FieldDescriptor, v protoreflect.Value) (*apiv2.UpdateLabels, bool) {
+	if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+		return nil, false
+	}
+
+	labels, ok := v.Message().Interface().(*apiv2.UpdateLabels)
+	return labels, ok
+}
+
+// patchLabels returns the labels resulting from the update as a replacement, nil is returned when no labels remain for an entity without labels.
+func patchLabels(current, update *apiv2.UpdateLabels) *apiv2.UpdateLabels {
+	if update.GetReplace() != nil {
+		return update
+	}
+
+	labels := maps.Clone(current.GetReplace().GetLabels())
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	maps.Copy(labels, update.GetPatch().GetUpdate().GetLabels())
+	for _, key := range update.GetPatch().GetRemove() {
+		delete(labels, key)
+	}
+
+	if len(labels) == 0 && current == nil {
+		return nil
+	}
+
+	return &apiv2.UpdateLabels{
+		Strategy: &apiv2.UpdateLabels_Replace{
+			Replace: &apiv2.Labels{
+				Labels: labels,
+			},
+		},
+	}
+}
@@ -0,0 +1,179 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_ProtoDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     proto.Message
+		desired     proto.Message
+		wantChanged bool
+		wantFields  []string
+	}{
+		{
+			name: "no changes",
+			current: &apiv2.IPServiceUpdateRequest{
+				Ip:   "1.1.1.1",
+				Name: new("a"),
+			},
+			desired: &apiv2.IPServiceUpdateRequest{
+				Ip:   "1.1.1.1",
+				Name: new("a"),
+			},
+			wantChanged: false,
+		},
+		{
+			name: "update meta is ignored",
+			current: &apiv2.IPServiceUpdateRequest{
+				Ip: "1.1.1.1",
+				UpdateMeta: &apiv2.UpdateMeta{
+					LockingStrategy: apiv2.OptimisticLockingStrategy_OPTIMISTIC_LOCKING_STRATEGY_CLIENT,
+				},
+			},
+			desired: &apiv2.IPServiceUpdateRequest{
+				Ip: "1.1.1.1",
+				UpdateMeta: &apiv2.UpdateMeta{
+					LockingStrategy: apiv2.OptimisticLockingStrategy_OPTIMISTIC_LOCKING_STRATEGY_SERVER,
+				},
+			},
+			wantChanged: false,
+		},
+		{
+			name: "changed field",
+			current: &apiv2.IPServiceUpdateRequest{
+				Ip:          "1.1.1.1",
+				Description: new("old"),
+			},
+			desired: &apiv2.IPServiceUpdateRequest{
+				Ip:          "1.1.1.1",
+				Description: new("new"),
+			},
+			wantChanged: true,
+			wantFields:  []string{"description", "old", "new"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProtoDiff(tt.current, tt.desired)
+
+			if changed := got != ""; changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v, diff:\n%s", changed, tt.wantChanged, got)
+			}
+
+			for _, field := range tt.wantFields {
+				if !strings.Contains(got, field) {
+					t.Errorf("diff does not contain %q:\n%s", field, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_ApplyUpdate(t *testing.T) {
+	current := func() *apiv2.MachineServiceUpdateRequest {
+		return &apiv2.MachineServiceUpdateRequest{
+			Uuid:          "m1",
+			Description:   new("machine"),
+			SshPublicKeys: []string{"key-a", "key-b"},
+			Labels: &apiv2.UpdateLabels{
+				Strategy: &apiv2.UpdateLabels_Replace{
+					Replace: &apiv2.Labels{
+						Labels: map[string]string{"a": "1", "b": "2"},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		current proto.Message
+		update  proto.Message
+		want    proto.Message
+	}{
+		{
+			name:    "repeated field is replaced instead of appended",
+			current: current(),
+			update: &apiv2.MachineServiceUpdateRequest{
+				Uuid:          "m1",
+				SshPublicKeys: []string{"key-c"},
+			},
+			want: func() proto.Message {
+				want := current()
+				want.SshPublicKeys = []string{"key-c"}
+				return want
+			}(),
+		},
+		{
+			name:    "labels are added and removed",
+			current: current(),
+			update: &apiv2.MachineServiceUpdateRequest{
+				Uuid: "m1",
+				Labels: &apiv2.UpdateLabels{
+					Strategy: &apiv2.UpdateLabels_Patch{
+						Patch: &apiv2.LabelsPatch{
+							Update: &apiv2.Labels{Labels: map[string]string{"c": "3"}},
+							Remove: []string{"a"},
+						},
+					},
+				},
+			},
+			want: func() proto.Message {
+				want := current()
+				want.Labels.GetReplace().Labels = map[string]string{"b": "2", "c": "3"}
+				return want
+			}(),
+		},
+		{
+			name:    "labels are replaced",
+			current: current(),
+			update: &apiv2.MachineServiceUpdateRequest{
+				Uuid: "m1",
+				Labels: &apiv2.UpdateLabels{
+					Strategy: &apiv2.UpdateLabels_Replace{
+						Replace: &apiv2.Labels{Labels: map[string]string{"c": "3"}},
+					},
+				},
+			},
+			want: func() proto.Message {
+				want := current()
+				want.Labels.GetReplace().Labels = map[string]string{"c": "3"}
+				return want
+			}(),
+		},
+		{
+			name: "removing labels of an entity without labels is no change",
+			current: &apiv2.MachineServiceUpdateRequest{
+				Uuid: "m1",
+			},
+			update: &apiv2.MachineServiceUpdateRequest{
+				Uuid: "m1",
+				Labels: &apiv2.UpdateLabels{
+					Strategy: &apiv2.UpdateLabels_Patch{
+						Patch: &apiv2.LabelsPatch{
+							Remove: []string{"a"},
+						},
+					},
+				},
+			},
+			want: &apiv2.MachineServiceUpdateRequest{
+				Uuid: "m1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyUpdate(tt.current, tt.update)
+
+			if diff := ProtoDiff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_ParseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Duration
+		wantErr string
+	}{
+		{
+			name: "days",
+			s:    "7d",
+			want: 7 * 24 * time.Hour,
+		},
+		{
+			name: "go duration",
+			s:    "1h30m",
+			want: 90 * time.Minute,
+		},
+		{
+			name:    "invalid days",
+			s:       "1.5d",
+			wantErr: `invalid duration "1.5d"`,
+		},
+		{
+			name:    "invalid duration",
+			s:       "foo",
+			wantErr: `time: invalid duration "foo"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuration(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
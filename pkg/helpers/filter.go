@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/interpreter"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Filter is a compiled CEL expression which decides if an entity is listed.
+// the fields of the entity are available as variables, additionally the variable now contains the current time.
+type Filter struct {
+	program cel.Program
+}
+
+// NewFilter compiles the given CEL expression for entities of the given type, e.g.
+//
+//	recent_provisioning_events.last_event_time < now - duration("1h")
+func NewFilter(expression string, descriptor protoreflect.MessageDescriptor) (*Filter, error) {
+	env, err := cel.NewEnv(
+		cel.DeclareContextProto(descriptor),
+		cel.Variable("now", cel.TimestampType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("invalid filter expression: %w", issues.Err())
+	}
+
+	if out := ast.OutputType(); out != cel.BoolType && out != cel.DynType {
+		return nil, fmt.Errorf("filter expression must evaluate to a bool, got %s", out)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression: %w", err)
+	}
+
+	return &Filter{program: program}, nil
+}
+
+// Match returns true if the given entity matches the filter expression.
+func (f *Filter) Match(m proto.Message, now time.Time) (bool, error) {
+	vars, err := cel.ContextProtoVars(m)
+	if err != nil {
+		return false, err
+	}
+
+	builtins, err := interpreter.NewActivation(map[string]any{
+		"now": now,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	out, _, err := f.program.Eval(interpreter.NewHierarchicalActivation(builtins, vars))
+	if err != nil {
+		return false, fmt.Errorf("unable to evaluate filter expression: %w", err)
+	}
+
+	match, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("filter expression must evaluate to a bool, got %s", out.Type())
+	}
+
+	return match, nil
+}
+
+// FilterEntities returns the entities which match the given CEL expression.
+func FilterEntities[R proto.Message](entities []R, expression string, now time.Time) ([]R, error) {
+	var zero R
+
+	filter, err := NewFilter(expression, zero.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []R
+	for _, entity := range entities {
+		match, err := filter.Match(entity, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			result = append(result, entity)
+		}
+	}
+
+	return result, nil
+}
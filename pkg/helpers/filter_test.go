@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_FilterEntities(t *testing.T) {
+	var (
+		now      = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		machines = []*apiv2.Machine{
+			{
+				Uuid: "m1",
+				Rack: "rack-1",
+				Size: &apiv2.Size{Id: "c1-large-x86"},
+				RecentProvisioningEvents: &apiv2.MachineRecentProvisioningEvents{
+					LastEventTime: timestamppb.New(now.Add(-2 * time.Hour)),
+				},
+			},
+			{
+				Uuid: "m2",
+				Rack: "rack-2",
+				Size: &apiv2.Size{Id: "c1-large-x86"},
+				RecentProvisioningEvents: &apiv2.MachineRecentProvisioningEvents{
+					LastEventTime: timestamppb.New(now.Add(-time.Minute)),
+				},
+			},
+			{
+				Uuid: "m3",
+				Rack: "rack-1",
+				Size: &apiv2.Size{Id: "c1-medium-x86"},
+			},
+		}
+	)
+
+	tests := []struct {
+		name       string
+		expression string
+		want       []string
+		wantErr    string
+	}{
+		{
+			name:       "field",
+			expression: `rack == "rack-1"`,
+			want:       []string{"m1", "m3"},
+		},
+		{
+			name:       "nested fields",
+			expression: `rack == "rack-1" && size.id.endsWith("large-x86")`,
+			want:       []string{"m1"},
+		},
+		{
+			name:       "time",
+			expression: `has(recent_provisioning_events.last_event_time) && recent_provisioning_events.last_event_time < now - duration("1h")`,
+			want:       []string{"m1"},
+		},
+		{
+			name:       "nothing matches",
+			expression: `rack == "rack-3"`,
+			want:       nil,
+		},
+		{
+			name:       "not a bool",
+			expression: `rack`,
+			wantErr:    "filter expression must evaluate to a bool, got string",
+		},
+		{
+			name:       "unknown field",
+			expression: `foo == "bar"`,
+			wantErr:    "invalid filter expression: ERROR: <input>:1:1: undeclared reference to 'foo'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterEntities(machines, tt.expression, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			var ids []string
+			for _, m := range got {
+				ids = append(ids, m.Uuid)
+			}
+
+			if diff := cmp.Diff(tt.want, ids); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
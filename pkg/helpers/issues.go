@@ -0,0 +1,584 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+)
+
+type IssueSeverity string
+
+const (
+	IssueSeverityMinor    IssueSeverity = "minor"
+	IssueSeverityMajor    IssueSeverity = "major"
+	IssueSeverityCritical IssueSeverity = "critical"
+)
+
+// Rank orders severities, higher ranks are more severe.
+func (s IssueSeverity) Rank() int {
+	switch s {
+	case IssueSeverityCritical:
+		return 3
+	case IssueSeverityMajor:
+		return 2
+	case IssueSeverityMinor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type IssueType string
+
+const (
+	IssueTypeNoPartition            IssueType = "no-partition"
+	IssueTypeLivelinessDead         IssueType = "liveliness-dead"
+	IssueTypeLivelinessUnknown      IssueType = "liveliness-unknown"
+	IssueTypeFailedMachineReclaim   IssueType = "failed-machine-reclaim"
+	IssueTypeCrashLoop              IssueType = "crashloop"
+	IssueTypeLastEventError         IssueType = "last-event-error"
+	IssueTypeBMCWithoutMAC          IssueType = "bmc-without-mac"
+	IssueTypeBMCWithoutIP           IssueType = "bmc-without-ip"
+	IssueTypeBMCInfoOutdated        IssueType = "bmc-info-outdated"
+	IssueTypeNonDistinctBMCIP       IssueType = "bmc-no-distinct-ip"
+	IssueTypePowerSupplyFailure     IssueType = "power-supply-failure"
+	IssueTypeASNUniqueness          IssueType = "asn-not-unique"
+	IssueTypeNoSwitchConnection     IssueType = "no-switch-connection"
+	IssueTypeSingleSwitchConnection IssueType = "single-switch-connection"
+	IssueTypeSwitchPortDown         IssueType = "switch-port-down"
+)
+
+// bmcInfoOutdatedThreshold is the age after which a bmc report is considered outdated
+const bmcInfoOutdatedThreshold = 20 * time.Minute
+
+// IssueSpec describes an issue which can be detected by an issue check.
+type IssueSpec struct {
+	Type        IssueType     `json:"type"`
+	Severity    IssueSeverity `json:"severity"`
+	Description string        `json:"description"`
+	// NeedsBMC is true if the check requires the bmc details of the machines in the issue context.
+	NeedsBMC bool `json:"-"`
+	// NeedsSwitches is true if the check requires the switches in the issue context.
+	NeedsSwitches bool `json:"-"`
+}
+
+// IssueCheck evaluates whether a machine has a certain issue, found issues can be described by details.
+type IssueCheck interface {
+	Spec() IssueSpec
+	Evaluate(m *apiv2.Machine, ic *IssueContext) (details string, found bool)
+}
+
+// MachineIssue is an issue found for a machine.
+type MachineIssue struct {
+	IssueSpec
+	Machine *apiv2.Machine `json:"machine"`
+	Details string         `json:"details,omitempty"`
+}
+
+// IssueContext contains the data the issue checks are evaluated against.
+type IssueContext struct {
+	Machines []*apiv2.Machine
+	// BMCDetails of the machines by machine id, checks which need bmc details are skipped if not present
+	BMCDetails map[string]*apiv2.MachineBMCDetails
+	// Switches of the machines, checks which need switches are skipped if not present
+	Switches                []*apiv2.Switch
+	LastEventErrorThreshold time.Duration
+	Now                     time.Time
+
+	bmcAddresses map[string][]string
+	asns         map[uint32][]string
+	connections  map[string][]*switchConnection
+}
+
+type switchConnection struct {
+	switchID string
+	nic      *apiv2.SwitchNic
+}
+
+// NewIssueContext creates an issue context, bmc details and switches are optional.
+func NewIssueContext(machines []*apiv2.Machine, bmcDetails []*apiv2.MachineBMCDetails, switches []*apiv2.Switch, lastEventErrorThreshold time.Duration) *IssueContext {
+	ic := &IssueContext{
+		Machines:                machines,
+		Switches:                switches,
+		LastEventErrorThreshold: lastEventErrorThreshold,
+		Now:                     time.Now(),
+		bmcAddresses:            map[string][]string{},
+		asns:                    map[uint32][]string{},
+		connections:             map[string][]*switchConnection{},
+	}
+
+	if bmcDetails != nil {
+		ic.BMCDetails = map[string]*apiv2.MachineBMCDetails{}
+	}
+
+	for _, d := range bmcDetails {
+		ic.BMCDetails[d.Uuid] = d
+
+		if d.BmcReport != nil && d.BmcReport.Bmc != nil && d.BmcReport.Bmc.Address != "" {
+			ic.bmcAddresses[d.BmcReport.Bmc.Address] = append(ic.bmcAddresses[d.BmcReport.Bmc.Address], d.Uuid)
+		}
+	}
+
+	for _, m := range machines {
+		if m.Allocation == nil {
+			continue
+		}
+
+		var asns []uint32
+		for _, nw := range m.Allocation.Networks {
+			if nw.Asn != 0 {
+				asns = append(asns, nw.Asn)
+			}
+		}
+
+		slices.Sort(asns)
+		for _, asn := range slices.Compact(asns) {
+			ic.asns[asn] = append(ic.asns[asn], m.Uuid)
+		}
+	}
+
+	for _, sw := range switches {
+		for _, con := range sw.MachineConnections {
+			ic.connections[con.MachineId] = append(ic.connections[con.MachineId], &switchConnection{
+				switchID: sw.Id,
+				nic:      con.Nic,
+			})
+		}
+	}
+
+	return ic
+}
+
+// AllIssueChecks returns all available issue checks.
+func AllIssueChecks() []IssueCheck {
+	return []IssueCheck{
+		&issueNoPartition{},
+		&issueLivelinessDead{},
+		&issueLivelinessUnknown{},
+		&issueFailedMachineReclaim{},
+		&issueCrashLoop{},
+		&issueLastEventError{},
+		&issueBMCWithoutMAC{},
+		&issueBMCWithoutIP{},
+		&issueBMCInfoOutdated{},
+		&issueNonDistinctBMCIP{},
+		&issuePowerSupplyFailure{},
+		&issueASNUniqueness{},
+		&issueNoSwitchConnection{},
+		&issueSingleSwitchConnection{},
+		&issueSwitchPortDown{},
+	}
+}
+
+// AllIssueTypes returns the types of all available issue checks.
+func AllIssueTypes() []string {
+	var types []string
+	for _, c := range AllIssueChecks() {
+		types = append(types, string(c.Spec().Type))
+	}
+	return types
+}
+
+// SelectIssueChecks returns the issue checks of the given types, if only is empty all checks are selected.
+// checks which are contained in omit are excluded.
+func SelectIssueChecks(only, omit []string) ([]IssueCheck, error) {
+	all := AllIssueTypes()
+
+	for _, t := range append(slices.Clone(only), omit...) {
+		if !slices.Contains(all, t) {
+			return nil, fmt.Errorf("unknown issue type %q, possible values: %s", t, strings.Join(all, "|"))
+		}
+	}
+
+	var checks []IssueCheck
+	for _, c := range AllIssueChecks() {
+		t := string(c.Spec().Type)
+
+		if len(only) > 0 && !slices.Contains(only, t) {
+			continue
+		}
+		if slices.Contains(omit, t) {
+			continue
+		}
+
+		checks = append(checks, c)
+	}
+
+	return checks, nil
+}
+
+// EvaluateIssues runs the given checks against all machines of the issue context.
+// the issues are ordered by severity, type and machine id such that they can be printed grouped by type.
+func EvaluateIssues(ic *IssueContext, checks []IssueCheck) []*MachineIssue {
+	var issues []*MachineIssue
+
+	for _, check := range checks {
+		spec := check.Spec()
+
+		if spec.NeedsBMC && ic.BMCDetails == nil {
+			continue
+		}
+		if spec.NeedsSwitches && ic.Switches == nil {
+			continue
+		}
+
+		for _, m := range ic.Machines {
+			details, found := check.Evaluate(m, ic)
+			if !found {
+				continue
+			}
+
+			issues = append(issues, &MachineIssue{
+				IssueSpec: spec,
+				Machine:   m,
+				Details:   details,
+			})
+		}
+	}
+
+	slices.SortStableFunc(issues, func(a, b *MachineIssue) int {
+		return cmp.Or(
+			cmp.Compare(b.Severity.Rank(), a.Severity.Rank()),
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.Machine.Uuid, b.Machine.Uuid),
+		)
+	})
+
+	return issues
+}
+
+type issueNoPartition struct{}
+
+func (*issueNoPartition) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeNoPartition,
+		Severity:    IssueSeverityMajor,
+		Description: "the machine is not assigned to a partition",
+	}
+}
+
+func (*issueNoPartition) Evaluate(m *apiv2.Machine, _ *IssueContext) (string, bool) {
+	return "", m.Partition == nil || m.Partition.Id == ""
+}
+
+type issueLivelinessDead struct{}
+
+func (*issueLivelinessDead) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeLivelinessDead,
+		Severity:    IssueSeverityMajor,
+		Description: "the machine does not send any events anymore",
+	}
+}
+
+func (*issueLivelinessDead) Evaluate(m *apiv2.Machine, _ *IssueContext) (string, bool) {
+	return "", m.Status != nil && m.Status.Liveliness == apiv2.MachineLiveliness_MACHINE_LIVELINESS_DEAD
+}
+
+type issueLivelinessUnknown struct{}
+
+func (*issueLivelinessUnknown) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeLivelinessUnknown,
+		Severity:    IssueSeverityMajor,
+		Description: "the liveliness of the machine is unknown",
+	}
+}
+
+func (*issueLivelinessUnknown) Evaluate(m *apiv2.Machine, _ *IssueContext) (string, bool) {
+	if m.Status == nil {
+		return "", true
+	}
+
+	switch m.Status.Liveliness {
+	case apiv2.MachineLiveliness_MACHINE_LIVELINESS_ALIVE, apiv2.MachineLiveliness_MACHINE_LIVELINESS_DEAD:
+		return "", false
+	default:
+		return "", true
+	}
+}
+
+type issueFailedMachineReclaim struct{}
+
+func (*issueFailedMachineReclaim) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeFailedMachineReclaim,
+		Severity:    IssueSeverityCritical,
+		Description: "the machine was deleted but it is not going back into the available machine pool",
+	}
+}
+
+func (*issueFailedMachineReclaim) Evaluate(m *apiv2.Machine, _ *IssueContext) (string, bool) {
+	return "", m.RecentProvisioningEvents != nil &&
+		m.RecentProvisioningEvents.State == apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_FAILED_RECLAIM
+}
+
+type issueCrashLoop struct{}
+
+func (*issueCrashLoop) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeCrashLoop,
+		Severity:    IssueSeverityMajor,
+		Description: "the machine is in a provisioning crash loop",
+	}
+}
+
+func (*issueCrashLoop) Evaluate(m *apiv2.Machine, _ *IssueContext) (string, bool) {
+	return "", m.RecentProvisioningEvents != nil &&
+		m.RecentProvisioningEvents.State == apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_CRASHLOOP
+}
+
+type issueLastEventError struct{}
+
+func (*issueLastEventError) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeLastEventError,
+		Severity:    IssueSeverityMinor,
+		Description: "the machine has recently encountered an error during the provisioning lifecycle",
+	}
+}
+
+func (*issueLastEventError) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	if m.RecentProvisioningEvents == nil || m.RecentProvisioningEvents.LastErrorEvent == nil || m.RecentProvisioningEvents.LastErrorEvent.Time == nil {
+		return "", false
+	}
+
+	e := m.RecentProvisioningEvents.LastErrorEvent
+	if ic.Now.Sub(e.Time.AsTime()) >= ic.LastEventErrorThreshold {
+		return "", false
+	}
+
+	return fmt.Sprintf("occurred at %s: %s", e.Time.AsTime().Format(time.RFC3339), e.Message), true
+}
+
+type issueBMCWithoutMAC struct{}
+
+func (*issueBMCWithoutMAC) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeBMCWithoutMAC,
+		Severity:    IssueSeverityMajor,
+		Description: "the bmc of the machine has no mac address",
+		NeedsBMC:    true,
+	}
+}
+
+func (*issueBMCWithoutMAC) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	bmc := ic.bmc(m.Uuid)
+	return "", bmc == nil || bmc.Mac == ""
+}
+
+type issueBMCWithoutIP struct{}
+
+func (*issueBMCWithoutIP) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeBMCWithoutIP,
+		Severity:    IssueSeverityMajor,
+		Description: "the bmc of the machine has no ip address",
+		NeedsBMC:    true,
+	}
+}
+
+func (*issueBMCWithoutIP) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	bmc := ic.bmc(m.Uuid)
+	return "", bmc == nil || bmc.Address == ""
+}
+
+type issueBMCInfoOutdated struct{}
+
+func (*issueBMCInfoOutdated) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeBMCInfoOutdated,
+		Severity:    IssueSeverityMajor,
+		Description: fmt.Sprintf("the bmc of the machine was not reported within the last %s", bmcInfoOutdatedThreshold),
+		NeedsBMC:    true,
+	}
+}
+
+func (*issueBMCInfoOutdated) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	d, ok := ic.BMCDetails[m.Uuid]
+	if !ok || d.BmcReport == nil || d.BmcReport.UpdatedAt == nil {
+		return "the bmc was never reported", true
+	}
+
+	updated := d.BmcReport.UpdatedAt.AsTime()
+	if ic.Now.Sub(updated) < bmcInfoOutdatedThreshold {
+		return "", false
+	}
+
+	return fmt.Sprintf("last reported at %s", updated.Format(time.RFC3339)), true
+}
+
+type issueNonDistinctBMCIP struct{}
+
+func (*issueNonDistinctBMCIP) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeNonDistinctBMCIP,
+		Severity:    IssueSeverityCritical,
+		Description: "the bmc ip address of the machine is also used by other machines",
+		NeedsBMC:    true,
+	}
+}
+
+func (*issueNonDistinctBMCIP) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	bmc := ic.bmc(m.Uuid)
+	if bmc == nil || bmc.Address == "" {
+		return "", false
+	}
+
+	others := without(ic.bmcAddresses[bmc.Address], m.Uuid)
+	if len(others) == 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s is also used by %s", bmc.Address, strings.Join(others, ", ")), true
+}
+
+type issuePowerSupplyFailure struct{}
+
+func (*issuePowerSupplyFailure) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypePowerSupplyFailure,
+		Severity:    IssueSeverityMajor,
+		Description: "a power supply of the machine is not healthy",
+		NeedsBMC:    true,
+	}
+}
+
+func (*issuePowerSupplyFailure) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	d, ok := ic.BMCDetails[m.Uuid]
+	if !ok || d.BmcReport == nil {
+		return "", false
+	}
+
+	var failures []string
+	for i, ps := range d.BmcReport.PowerSupplies {
+		if ps.Health != "OK" {
+			failures = append(failures, fmt.Sprintf("power supply %d is %s (%s)", i+1, ps.Health, ps.State))
+		}
+	}
+
+	return strings.Join(failures, ", "), len(failures) > 0
+}
+
+type issueASNUniqueness struct{}
+
+func (*issueASNUniqueness) Spec() IssueSpec {
+	return IssueSpec{
+		Type:        IssueTypeASNUniqueness,
+		Severity:    IssueSeverityMinor,
+		Description: "the asn of the machine is shared with other machines",
+	}
+}
+
+func (*issueASNUniqueness) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	if m.Allocation == nil {
+		return "", false
+	}
+
+	var shared []string
+	for asn, ids := range ic.asns {
+		if !slices.Contains(ids, m.Uuid) {
+			continue
+		}
+
+		if others := without(ids, m.Uuid); len(others) > 0 {
+			shared = append(shared, fmt.Sprintf("asn %d is also used by %s", asn, strings.Join(others, ", ")))
+		}
+	}
+
+	slices.Sort(shared)
+
+	return strings.Join(shared, ", "), len(shared) > 0
+}
+
+type issueNoSwitchConnection struct{}
+
+func (*issueNoSwitchConnection) Spec() IssueSpec {
+	return IssueSpec{
+		Type:          IssueTypeNoSwitchConnection,
+		Severity:      IssueSeverityMajor,
+		Description:   "the machine is not connected to any switch",
+		NeedsSwitches: true,
+	}
+}
+
+func (*issueNoSwitchConnection) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	return "", len(ic.connections[m.Uuid]) == 0
+}
+
+type issueSingleSwitchConnection struct{}
+
+func (*issueSingleSwitchConnection) Spec() IssueSpec {
+	return IssueSpec{
+		Type:          IssueTypeSingleSwitchConnection,
+		Severity:      IssueSeverityMinor,
+		Description:   "the machine is only connected to a single switch and has no redundant uplink",
+		NeedsSwitches: true,
+	}
+}
+
+func (*issueSingleSwitchConnection) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	var switches []string
+	for _, con := range ic.connections[m.Uuid] {
+		switches = append(switches, con.switchID)
+	}
+
+	slices.Sort(switches)
+	switches = slices.Compact(switches)
+
+	if len(switches) != 1 {
+		return "", false
+	}
+
+	return "connected to " + switches[0], true
+}
+
+type issueSwitchPortDown struct{}
+
+func (*issueSwitchPortDown) Spec() IssueSpec {
+	return IssueSpec{
+		Type:          IssueTypeSwitchPortDown,
+		Severity:      IssueSeverityMajor,
+		Description:   "a switch port the machine is connected to is not up",
+		NeedsSwitches: true,
+	}
+}
+
+func (*issueSwitchPortDown) Evaluate(m *apiv2.Machine, ic *IssueContext) (string, bool) {
+	var down []string
+	for _, con := range ic.connections[m.Uuid] {
+		if con.nic == nil {
+			continue
+		}
+
+		if con.nic.State == nil {
+			down = append(down, fmt.Sprintf("%s/%s is unknown", con.switchID, con.nic.Name))
+			continue
+		}
+
+		if con.nic.State.Actual != apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_UP {
+			down = append(down, fmt.Sprintf("%s/%s is %s", con.switchID, con.nic.Name, con.nic.State.Actual.String()))
+		}
+	}
+
+	return strings.Join(down, ", "), len(down) > 0
+}
+
+func (ic *IssueContext) bmc(machineID string) *apiv2.MachineBMC {
+	d, ok := ic.BMCDetails[machineID]
+	if !ok || d.BmcReport == nil {
+		return nil
+	}
+
+	return d.BmcReport.Bmc
+}
+
+func without(ids []string, id string) []string {
+	return slices.DeleteFunc(slices.Clone(ids), func(s string) bool {
+		return s == id
+	})
+}
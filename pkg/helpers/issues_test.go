@@ -0,0 +1,177 @@
+package helpers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/api/go/errorutil"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+)
+
+func Test_EvaluateIssues(t *testing.T) {
+	type finding struct {
+		Type      IssueType
+		MachineID string
+		Details   string
+	}
+
+	var (
+		m1 = testresources.Machine1()
+		m2 = testresources.Machine2()
+		m3 = &apiv2.Machine{
+			Uuid:      "m3",
+			Partition: testresources.Partition1(),
+			Status: &apiv2.MachineStatus{
+				Liveliness: apiv2.MachineLiveliness_MACHINE_LIVELINESS_ALIVE,
+			},
+		}
+
+		leaf01 = testresources.Switch1()
+		leaf02 = testresources.Switch2()
+	)
+
+	leaf01.MachineConnections = []*apiv2.MachineConnection{{MachineId: m1.Uuid, Nic: testresources.Nic1()}}
+	leaf02.MachineConnections = []*apiv2.MachineConnection{{MachineId: m1.Uuid, Nic: testresources.Nic2()}}
+
+	tests := []struct {
+		name       string
+		machines   []*apiv2.Machine
+		bmcDetails []*apiv2.MachineBMCDetails
+		switches   []*apiv2.Switch
+		threshold  time.Duration
+		only       []string
+		omit       []string
+		want       []finding
+	}{
+		{
+			name:      "machine checks",
+			machines:  []*apiv2.Machine{m2, m1},
+			threshold: 2 * time.Hour,
+			want: []finding{
+				{Type: IssueTypeLastEventError, MachineID: m1.Uuid, Details: "occurred at 1999-12-31T23:00:00Z: waiting"},
+				{Type: IssueTypeLastEventError, MachineID: m2.Uuid, Details: "occurred at 1999-12-31T23:00:00Z: waiting"},
+			},
+		},
+		{
+			name:      "last event error outside of threshold",
+			machines:  []*apiv2.Machine{m1, m2},
+			threshold: time.Hour,
+			want:      nil,
+		},
+		{
+			name:       "bmc checks",
+			machines:   []*apiv2.Machine{m1, m2, m3},
+			bmcDetails: []*apiv2.MachineBMCDetails{testresources.Machine1BmcDetails, testresources.Machine2BmcDetails},
+			want: []finding{
+				{Type: IssueTypeBMCInfoOutdated, MachineID: "m3", Details: "the bmc was never reported"},
+				{Type: IssueTypeBMCWithoutIP, MachineID: "m3"},
+				{Type: IssueTypeBMCWithoutMAC, MachineID: "m3"},
+				{Type: IssueTypePowerSupplyFailure, MachineID: m2.Uuid, Details: "power supply 1 is Warning (Absent)"},
+			},
+		},
+		{
+			name:       "non distinct bmc ip",
+			machines:   []*apiv2.Machine{m1, m3},
+			bmcDetails: []*apiv2.MachineBMCDetails{testresources.Machine1BmcDetails, {Uuid: "m3", BmcReport: testresources.Machine1BmcDetails.BmcReport}},
+			only:       []string{string(IssueTypeNonDistinctBMCIP)},
+			want: []finding{
+				{Type: IssueTypeNonDistinctBMCIP, MachineID: m1.Uuid, Details: "10.0.0.1:623 is also used by m3"},
+				{Type: IssueTypeNonDistinctBMCIP, MachineID: "m3", Details: "10.0.0.1:623 is also used by " + m1.Uuid},
+			},
+		},
+		{
+			name:     "switch checks",
+			machines: []*apiv2.Machine{m1, m2},
+			switches: []*apiv2.Switch{leaf01, leaf02},
+			want: []finding{
+				{Type: IssueTypeNoSwitchConnection, MachineID: m2.Uuid},
+				{Type: IssueTypeSwitchPortDown, MachineID: m1.Uuid, Details: "leaf02/Ethernet4 is SWITCH_PORT_STATUS_DOWN"},
+			},
+		},
+		{
+			name:     "single switch connection",
+			machines: []*apiv2.Machine{m1},
+			switches: []*apiv2.Switch{leaf01},
+			want: []finding{
+				{Type: IssueTypeSingleSwitchConnection, MachineID: m1.Uuid, Details: "connected to leaf01"},
+			},
+		},
+		{
+			name:     "omit checks",
+			machines: []*apiv2.Machine{m1, m2},
+			switches: []*apiv2.Switch{leaf01, leaf02},
+			omit:     []string{string(IssueTypeSwitchPortDown)},
+			want: []finding{
+				{Type: IssueTypeNoSwitchConnection, MachineID: m2.Uuid},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := SelectIssueChecks(tt.only, tt.omit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ic := NewIssueContext(tt.machines, tt.bmcDetails, tt.switches, tt.threshold)
+			ic.Now = e2e.TimeBubbleStartTime()
+
+			var got []finding
+			for _, issue := range EvaluateIssues(ic, checks) {
+				got = append(got, finding{Type: issue.Type, MachineID: issue.Machine.Uuid, Details: issue.Details})
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_SelectIssueChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		only    []string
+		omit    []string
+		want    []IssueType
+		wantErr error
+	}{
+		{
+			name: "only",
+			only: []string{"crashloop", "no-partition"},
+			want: []IssueType{IssueTypeNoPartition, IssueTypeCrashLoop},
+		},
+		{
+			name: "only and omit",
+			only: []string{"crashloop", "no-partition"},
+			omit: []string{"crashloop"},
+			want: []IssueType{IssueTypeNoPartition},
+		},
+		{
+			name:    "unknown type",
+			omit:    []string{"foo"},
+			wantErr: fmt.Errorf(`unknown issue type "foo", possible values: no-partition|liveliness-dead|liveliness-unknown|failed-machine-reclaim|crashloop|last-event-error|bmc-without-mac|bmc-without-ip|bmc-info-outdated|bmc-no-distinct-ip|power-supply-failure|asn-not-unique|no-switch-connection|single-switch-connection|switch-port-down`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks, err := SelectIssueChecks(tt.only, tt.omit)
+			if diff := cmp.Diff(tt.wantErr, err, errorutil.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			var got []IssueType
+			for _, c := range checks {
+				got = append(got, c.Spec().Type)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
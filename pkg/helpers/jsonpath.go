@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a template of literal text and field paths in curly braces, e.g. {.uuid}{"\t"}{.size.id}
+type JSONPath struct {
+	parts []jsonPathPart
+}
+
+type jsonPathPart struct {
+	text string
+	path string
+}
+
+// ParseJSONPath parses the given template. within curly braces either a field path or a quoted string can be given.
+func ParseJSONPath(template string) (*JSONPath, error) {
+	var (
+		parts []jsonPathPart
+		rest  = template
+	)
+
+	for rest != "" {
+		text, expression, found := strings.Cut(rest, "{")
+		if text != "" {
+			parts = append(parts, jsonPathPart{text: text})
+		}
+		if !found {
+			break
+		}
+
+		expression, rest, found = strings.Cut(expression, "}")
+		if !found {
+			return nil, fmt.Errorf("unclosed expression in jsonpath %q", template)
+		}
+
+		expression = strings.TrimSpace(expression)
+
+		switch {
+		case strings.HasPrefix(expression, `"`):
+			text, err := strconv.Unquote(expression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s in jsonpath %q", expression, template)
+			}
+			parts = append(parts, jsonPathPart{text: text})
+		case strings.HasPrefix(expression, "."):
+			_, err := parseFieldPath(expression)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, jsonPathPart{path: expression})
+		default:
+			return nil, fmt.Errorf("expression {%s} in jsonpath %q must either be a field path starting with a dot or a quoted string", expression, template)
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("jsonpath must not be empty")
+	}
+
+	return &JSONPath{parts: parts}, nil
+}
+
+// Execute renders the template for the given entity.
+func (j *JSONPath) Execute(v any) (string, error) {
+	var sb strings.Builder
+
+	for _, part := range j.parts {
+		if part.path == "" {
+			sb.WriteString(part.text)
+			continue
+		}
+
+		value, err := FieldPathValue(v, part.path)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(value)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+)
+
+func Test_JSONPath(t *testing.T) {
+	machine := &apiv2.Machine{
+		Uuid: "m1",
+		Rack: "rack-1",
+		Size: &apiv2.Size{Id: "c1-large-x86"},
+		Allocation: &apiv2.MachineAllocation{
+			Networks: []*apiv2.MachineNetwork{
+				{Network: "internet", Ips: []string{"1.2.3.4"}},
+				{Network: "tenant", Ips: []string{"10.0.0.1"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "field path",
+			template: "{.uuid}",
+			want:     "m1",
+		},
+		{
+			name:     "text and quoted strings",
+			template: `id={.uuid}{"\t"}size={.size.id}`,
+			want:     "id=m1\tsize=c1-large-x86",
+		},
+		{
+			name:     "all list elements",
+			template: "{.allocation.networks[*].ips}",
+			want:     "1.2.3.4,10.0.0.1",
+		},
+		{
+			name:     "entity",
+			template: "{.size}",
+			want:     `{"id":"c1-large-x86"}`,
+		},
+		{
+			name:     "unclosed expression",
+			template: "{.uuid",
+			wantErr:  `unclosed expression in jsonpath "{.uuid"`,
+		},
+		{
+			name:     "invalid expression",
+			template: "{uuid}",
+			wantErr:  `expression {uuid} in jsonpath "{uuid}" must either be a field path starting with a dot or a quoted string`,
+		},
+		{
+			name:     "unknown field",
+			template: "{.foo}",
+			wantErr:  `unable to evaluate field path ".foo": field "foo" does not exist in metalstack.api.v2.Machine`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := func() (string, error) {
+				jsonPath, err := ParseJSONPath(tt.template)
+				if err != nil {
+					return "", err
+				}
+				return jsonPath.Execute(machine)
+			}()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
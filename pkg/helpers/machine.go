@@ -7,7 +7,9 @@ import (
 	"os"
 	osuser "os/user"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/metal-stack/api/go/enum"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
@@ -530,6 +532,41 @@ func parseNetworks(possibleNetworks []string) ([]*apiv2.MachineAllocationNetwork
 	return result, nil
 }
 
+// ProvisioningEventsSince returns the recent provisioning events of a machine which occurred after the given time, oldest first.
+func ProvisioningEventsSince(recent *apiv2.MachineRecentProvisioningEvents, since time.Time) []*apiv2.MachineProvisioningEvent {
+	if recent == nil {
+		return nil
+	}
+
+	var events []*apiv2.MachineProvisioningEvent
+	for _, e := range recent.Events {
+		if e.Time == nil || !e.Time.AsTime().After(since) {
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	slices.SortStableFunc(events, func(a, b *apiv2.MachineProvisioningEvent) int {
+		return a.Time.AsTime().Compare(b.Time.AsTime())
+	})
+
+	return events
+}
+
+// IsRecentProvisioningErrorEvent returns true if the given event is the last error event of a machine and occurred within the given threshold.
+func IsRecentProvisioningErrorEvent(recent *apiv2.MachineRecentProvisioningEvents, e *apiv2.MachineProvisioningEvent, threshold time.Duration) bool {
+	if recent == nil || recent.LastErrorEvent == nil || recent.LastErrorEvent.Time == nil || e == nil || e.Time == nil {
+		return false
+	}
+
+	if e.Event != recent.LastErrorEvent.Event || !e.Time.AsTime().Equal(recent.LastErrorEvent.Time.AsTime()) {
+		return false
+	}
+
+	return time.Since(e.Time.AsTime()) < threshold
+}
+
 func intSliceToUint[T ~uint32 | ~uint64](values []int) []T {
 	result := make([]T, 0, len(values))
 	for _, v := range values {
@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_ProvisioningEventsSince(t *testing.T) {
+	var (
+		now     = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		alive   = &apiv2.MachineProvisioningEvent{Time: timestamppb.New(now.Add(-time.Minute)), Event: apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_ALIVE}
+		waiting = &apiv2.MachineProvisioningEvent{Time: timestamppb.New(now.Add(time.Minute)), Event: apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING}
+		phoned  = &apiv2.MachineProvisioningEvent{Time: timestamppb.New(now.Add(2 * time.Minute)), Event: apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_PHONED_HOME}
+	)
+
+	tests := []struct {
+		name   string
+		recent *apiv2.MachineRecentProvisioningEvents
+		since  time.Time
+		want   []*apiv2.MachineProvisioningEvent
+	}{
+		{
+			name:   "nil events",
+			recent: nil,
+			since:  now,
+			want:   nil,
+		},
+		{
+			name:   "no events",
+			recent: &apiv2.MachineRecentProvisioningEvents{},
+			since:  now,
+			want:   nil,
+		},
+		{
+			name: "only newer events, oldest first",
+			recent: &apiv2.MachineRecentProvisioningEvents{
+				Events: []*apiv2.MachineProvisioningEvent{phoned, waiting, alive},
+			},
+			since: now,
+			want:  []*apiv2.MachineProvisioningEvent{waiting, phoned},
+		},
+		{
+			name: "events at the given time are excluded",
+			recent: &apiv2.MachineRecentProvisioningEvents{
+				Events: []*apiv2.MachineProvisioningEvent{phoned, waiting},
+			},
+			since: now.Add(time.Minute),
+			want:  []*apiv2.MachineProvisioningEvent{phoned},
+		},
+		{
+			name: "all events with zero time",
+			recent: &apiv2.MachineRecentProvisioningEvents{
+				Events: []*apiv2.MachineProvisioningEvent{phoned, waiting, alive},
+			},
+			since: time.Time{},
+			want:  []*apiv2.MachineProvisioningEvent{alive, waiting, phoned},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ProvisioningEventsSince(tt.recent, tt.since)
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_IsRecentProvisioningErrorEvent(t *testing.T) {
+	var (
+		errorTime = time.Now().Add(-10 * time.Minute)
+		errEvent  = &apiv2.MachineProvisioningEvent{Time: timestamppb.New(errorTime), Event: apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING}
+		recent    = &apiv2.MachineRecentProvisioningEvents{
+			LastErrorEvent: &apiv2.MachineProvisioningEvent{Time: timestamppb.New(errorTime), Event: apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING},
+		}
+	)
+
+	tests := []struct {
+		name      string
+		recent    *apiv2.MachineRecentProvisioningEvents
+		event     *apiv2.MachineProvisioningEvent
+		threshold time.Duration
+		want      bool
+	}{
+		{
+			name:      "error event within threshold",
+			recent:    recent,
+			event:     errEvent,
+			threshold: time.Hour,
+			want:      true,
+		},
+		{
+			name:      "error event outside threshold",
+			recent:    recent,
+			event:     errEvent,
+			threshold: time.Minute,
+			want:      false,
+		},
+		{
+			name:   "other event",
+			recent: recent,
+			event: &apiv2.MachineProvisioningEvent{
+				Time:  timestamppb.New(errorTime.Add(time.Second)),
+				Event: apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING,
+			},
+			threshold: time.Hour,
+			want:      false,
+		},
+		{
+			name:      "no last error event",
+			recent:    &apiv2.MachineRecentProvisioningEvents{},
+			event:     errEvent,
+			threshold: time.Hour,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRecentProvisioningErrorEvent(tt.recent, tt.event, tt.threshold); got != tt.want {
+				t.Errorf("IsRecentProvisioningErrorEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package helpers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/api/go/permissions"
+)
+
+// the visibilities of the api methods, they determine the subject an api method is called on
+const (
+	VisibilityPublic  = "public"
+	VisibilitySelf    = "self"
+	VisibilityProject = "project"
+	VisibilityTenant  = "tenant"
+	VisibilityMachine = "machine"
+	VisibilityInfra   = "infra"
+	VisibilityAdmin   = "admin"
+)
+
+// MethodAccess tells if a token is allowed to call an api method and what grants the access.
+type MethodAccess struct {
+	Method     string `json:"method"`
+	Visibility string `json:"visibility"`
+	Subject    string `json:"subject,omitempty"`
+	Allowed    bool   `json:"allowed"`
+	GrantedBy  string `json:"granted-by,omitempty"`
+}
+
+// PermissionMatrix shows for every api method with project, tenant or admin visibility what grants a token access to the method.
+type PermissionMatrix struct {
+	Projects []string                  `json:"projects,omitempty"`
+	Tenants  []string                  `json:"tenants,omitempty"`
+	Admin    bool                      `json:"admin"`
+	Methods  []*PermissionMatrixMethod `json:"methods"`
+}
+
+// PermissionMatrixMethod contains the access to an api method per subject, admin methods use the subject admin.
+type PermissionMatrixMethod struct {
+	Method     string            `json:"method"`
+	Visibility string            `json:"visibility"`
+	Grants     map[string]string `json:"grants,omitempty"`
+}
+
+// MethodVisibility returns the visibility of the given api method.
+func MethodVisibility(method string) (string, error) {
+	visibility := permissions.GetServicePermissions().Visibility
+
+	switch {
+	case has(visibility.Admin, method):
+		return VisibilityAdmin, nil
+	case has(visibility.Infra, method):
+		return VisibilityInfra, nil
+	case has(visibility.Machine, method):
+		return VisibilityMachine, nil
+	case has(visibility.Project, method):
+		return VisibilityProject, nil
+	case has(visibility.Public, method):
+		return VisibilityPublic, nil
+	case has(visibility.Self, method):
+		return VisibilitySelf, nil
+	case has(visibility.Tenant, method):
+		return VisibilityTenant, nil
+	default:
+		return "", fmt.Errorf("method is not part of the api: %s", method)
+	}
+}
+
+// APIMethods returns all methods of the api in sorted order.
+func APIMethods() []string {
+	var (
+		visibility = permissions.GetServicePermissions().Visibility
+		methods    []string
+	)
+
+	methods = appendKeys(methods, visibility.Admin)
+	methods = appendKeys(methods, visibility.Infra)
+	methods = appendKeys(methods, visibility.Machine)
+	methods = appendKeys(methods, visibility.Project)
+	methods = appendKeys(methods, visibility.Public)
+	methods = appendKeys(methods, visibility.Self)
+	methods = appendKeys(methods, visibility.Tenant)
+
+	slices.Sort(methods)
+
+	return slices.Compact(methods)
+}
+
+// ResolveMethod returns the api method for the given full method (/metalstack.api.v2.MachineService/Delete),
+// short method (MachineService/Delete) or cli command (machine delete, admin machine list).
+func ResolveMethod(s string) (string, error) {
+	var (
+		methods    = APIMethods()
+		candidates []string
+	)
+
+	s = strings.TrimSpace(s)
+	if slices.Contains(methods, s) {
+		return s, nil
+	}
+
+	if strings.Contains(s, "/") {
+		suffix := strings.TrimPrefix(s, "/")
+
+		for _, method := range methods {
+			if strings.HasSuffix(method, "."+suffix) {
+				candidates = append(candidates, method)
+			}
+		}
+	} else {
+		pkg := "metalstack.api.v2"
+
+		words := strings.Fields(s)
+		if len(words) > 0 && words[0] == "admin" {
+			pkg = "metalstack.admin.v2"
+			words = words[1:]
+		}
+
+		if len(words) != 2 {
+			return "", fmt.Errorf("command %q must consist of an entity and a verb, e.g. machine delete", s)
+		}
+
+		var (
+			service = strings.ReplaceAll(words[0], "-", "") + "service"
+			rpc     = strings.ReplaceAll(words[1], "-", "")
+		)
+
+		switch rpc {
+		case "describe":
+			rpc = "get"
+		case "rm":
+			rpc = "delete"
+		case "ls":
+			rpc = "list"
+		case "edit":
+			rpc = "update"
+		}
+
+		for _, method := range methods {
+			methodPkg, methodService, methodRPC := splitMethod(method)
+			if methodPkg == pkg && strings.EqualFold(methodService, service) && strings.EqualFold(methodRPC, rpc) {
+				candidates = append(candidates, method)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("%q is neither an api method nor a command calling an api method", s)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("%q is ambiguous, it matches the api methods: %s", s, strings.Join(candidates, ", "))
+	}
+}
+
+// CanI resolves if a token with the given scoped permissions is allowed to call the given api method.
+// the subject is the project or tenant on which the method is called, it is required for methods of these visibilities.
+func CanI(scoped *apiv2.MethodServiceTokenScopedListResponse, method, subject string) (*MethodAccess, error) {
+	visibility, err := MethodVisibility(method)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &MethodAccess{
+		Method:     method,
+		Visibility: visibility,
+	}
+
+	switch visibility {
+	case VisibilityPublic:
+		access.Allowed = true
+		access.GrantedBy = "public method"
+		return access, nil
+	case VisibilitySelf:
+		access.Allowed = true
+		access.GrantedBy = "method for the owner of the token"
+		return access, nil
+	case VisibilityProject, VisibilityTenant, VisibilityMachine:
+		if subject == "" {
+			return nil, fmt.Errorf("method %s is called on a %s, please specify the %s", method, visibility, visibility)
+		}
+		access.Subject = subject
+	}
+
+	access.GrantedBy = grantedBy(scoped, method, visibility, subject)
+	access.Allowed = access.GrantedBy != ""
+
+	return access, nil
+}
+
+// NewPermissionMatrix resolves what grants a token with the given scoped permissions access to the api methods
+// of project, tenant and admin visibility. the projects and tenants are the ones the token has a role or permissions for.
+func NewPermissionMatrix(scoped *apiv2.MethodServiceTokenScopedListResponse) *PermissionMatrix {
+	matrix := &PermissionMatrix{
+		Admin: scoped.AdminRole != nil,
+	}
+
+	for project := range scoped.ProjectRoles {
+		matrix.Projects = append(matrix.Projects, project)
+	}
+	for tenant := range scoped.TenantRoles {
+		matrix.Tenants = append(matrix.Tenants, tenant)
+	}
+
+	for _, perm := range scoped.Permissions {
+		for _, method := range perm.Methods {
+			visibility, err := MethodVisibility(method)
+			if err != nil {
+				continue
+			}
+
+			switch {
+			case visibility == VisibilityAdmin:
+				matrix.Admin = true
+			case perm.Subject == "" || perm.Subject == "*":
+				continue
+			case visibility == VisibilityProject:
+				matrix.Projects = append(matrix.Projects, perm.Subject)
+			case visibility == VisibilityTenant:
+				matrix.Tenants = append(matrix.Tenants, perm.Subject)
+			}
+		}
+	}
+
+	slices.Sort(matrix.Projects)
+	matrix.Projects = slices.Compact(matrix.Projects)
+	slices.Sort(matrix.Tenants)
+	matrix.Tenants = slices.Compact(matrix.Tenants)
+
+	for _, method := range APIMethods() {
+		visibility, err := MethodVisibility(method)
+		if err != nil {
+			continue
+		}
+
+		var subjects []string
+		switch visibility {
+		case VisibilityProject:
+			subjects = matrix.Projects
+		case VisibilityTenant:
+			subjects = matrix.Tenants
+		case VisibilityAdmin:
+			if matrix.Admin {
+				subjects = []string{VisibilityAdmin}
+			}
+		}
+
+		if len(subjects) == 0 {
+			continue
+		}
+
+		m := &PermissionMatrixMethod{
+			Method:     method,
+			Visibility: visibility,
+		}
+
+		for _, subject := range subjects {
+			grant := grantedBy(scoped, method, visibility, subject)
+			if grant == "" {
+				continue
+			}
+
+			if m.Grants == nil {
+				m.Grants = map[string]string{}
+			}
+			m.Grants[subject] = grant
+		}
+
+		matrix.Methods = append(matrix.Methods, m)
+	}
+
+	return matrix
+}
+
+// SuggestPermissions returns the least permissions required for the api calls of the given audit traces, sorted by subject.
+// methods are granted on the project or tenant they were called on, public and self methods do not require any permission.
+// methods of machines are skipped because audit traces do not contain the machine a method was called on.
+func SuggestPermissions(traces []*apiv2.AuditTrace) []*apiv2.MethodPermission {
+	methodsBySubject := map[string][]string{}
+
+	for _, trace := range traces {
+		visibility, err := MethodVisibility(trace.Method)
+		if err != nil {
+			continue
+		}
+
+		var subject string
+		switch visibility {
+		case VisibilityPublic, VisibilitySelf, VisibilityMachine:
+			continue
+		case VisibilityProject:
+			if trace.GetProject() == "" {
+				continue
+			}
+			subject = trace.GetProject()
+		case VisibilityTenant:
+			subject = trace.Tenant
+		}
+
+		methodsBySubject[subject] = append(methodsBySubject[subject], trace.Method)
+	}
+
+	subjects := appendKeys(nil, methodsBySubject)
+	slices.Sort(subjects)
+
+	var res []*apiv2.MethodPermission
+	for _, subject := range subjects {
+		methods := methodsBySubject[subject]
+		slices.Sort(methods)
+
+		res = append(res, &apiv2.MethodPermission{
+			Subject: subject,
+			Methods: slices.Compact(methods),
+		})
+	}
+
+	return res
+}
+
+// GrantedTraces returns the audit traces of api calls which the given token is allowed to make.
+// audit traces do not contain the token used for a call, so calls of other tokens of the same user
+// are only excluded if they are not granted to the given token.
+func GrantedTraces(token *apiv2.Token, traces []*apiv2.AuditTrace) []*apiv2.AuditTrace {
+	scoped := &apiv2.MethodServiceTokenScopedListResponse{
+		Permissions:  token.Permissions,
+		ProjectRoles: token.ProjectRoles,
+		TenantRoles:  token.TenantRoles,
+		AdminRole:    token.AdminRole,
+	}
+
+	var res []*apiv2.AuditTrace
+	for _, trace := range traces {
+		visibility, err := MethodVisibility(trace.Method)
+		if err != nil {
+			continue
+		}
+
+		var subject string
+		switch visibility {
+		case VisibilityProject:
+			subject = trace.GetProject()
+		case VisibilityTenant:
+			subject = trace.Tenant
+		}
+
+		access, err := CanI(scoped, trace.Method, subject)
+		if err != nil || !access.Allowed {
+			continue
+		}
+
+		res = append(res, trace)
+	}
+
+	return res
+}
+
+// grantedBy returns the role or permission which grants access to the method on the given subject, it is empty if the access is denied.
+func grantedBy(scoped *apiv2.MethodServiceTokenScopedListResponse, method, visibility, subject string) string {
+	roles := permissions.GetServicePermissions().Roles
+
+	for _, perm := range scoped.Permissions {
+		if !slices.Contains(perm.Methods, method) {
+			continue
+		}
+
+		switch {
+		case perm.Subject == "*":
+			return "permission on all subjects"
+		case perm.Subject == "" && visibility != VisibilityProject && visibility != VisibilityTenant && visibility != VisibilityMachine:
+			return "permission"
+		case perm.Subject == subject && visibility != VisibilityAdmin:
+			return fmt.Sprintf("permission on %s %s", visibility, subject)
+		}
+	}
+
+	switch visibility {
+	case VisibilityProject:
+		if role, ok := scoped.ProjectRoles[subject]; ok && slices.Contains(roles.Project[role.String()], method) {
+			return fmt.Sprintf("%s on project %s", role.String(), subject)
+		}
+	case VisibilityTenant:
+		if role, ok := scoped.TenantRoles[subject]; ok && slices.Contains(roles.Tenant[role.String()], method) {
+			return fmt.Sprintf("%s on tenant %s", role.String(), subject)
+		}
+	}
+
+	if scoped.AdminRole != nil && slices.Contains(roles.Admin[scoped.AdminRole.String()], method) {
+		return scoped.AdminRole.String()
+	}
+
+	return ""
+}
+
+// splitMethod splits an api method like /metalstack.api.v2.MachineService/Delete into package, service and rpc.
+func splitMethod(method string) (string, string, string) {
+	service, rpc, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+
+	idx := strings.LastIndex(service, ".")
+	if idx < 0 {
+		return "", service, rpc
+	}
+
+	return service[:idx], service[idx+1:], rpc
+}
+
+func has[V any](m map[string]V, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func appendKeys[V any](keys []string, m map[string]V) []string {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
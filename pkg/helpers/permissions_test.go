@@ -0,0 +1,359 @@
+package helpers
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/api/go/errorutil"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/api/go/metalstack/api/v2/apiv2connect"
+	"github.com/metal-stack/api/go/permissions"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func Test_ResolveMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "full method",
+			s:    apiv2connect.MachineServiceDeleteProcedure,
+			want: apiv2connect.MachineServiceDeleteProcedure,
+		},
+		{
+			name: "method without leading slash",
+			s:    "metalstack.api.v2.IPService/Create",
+			want: apiv2connect.IPServiceCreateProcedure,
+		},
+		{
+			name: "command",
+			s:    "machine delete",
+			want: apiv2connect.MachineServiceDeleteProcedure,
+		},
+		{
+			name: "command with verb alias",
+			s:    "ip describe",
+			want: apiv2connect.IPServiceGetProcedure,
+		},
+		{
+			name: "admin command",
+			s:    "admin machine list",
+			want: adminv2connect.MachineServiceListProcedure,
+		},
+		{
+			name:    "ambiguous method",
+			s:       "MachineService/Get",
+			wantErr: fmt.Errorf(`"MachineService/Get" is ambiguous, it matches the api methods: %s, %s`, adminv2connect.MachineServiceGetProcedure, apiv2connect.MachineServiceGetProcedure),
+		},
+		{
+			name:    "unknown command",
+			s:       "foo bar",
+			wantErr: fmt.Errorf(`"foo bar" is neither an api method nor a command calling an api method`),
+		},
+		{
+			name:    "incomplete command",
+			s:       "machine",
+			wantErr: fmt.Errorf(`command "machine" must consist of an entity and a verb, e.g. machine delete`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := ResolveMethod(tt.s)
+			if diff := cmp.Diff(gotErr, tt.wantErr, errorutil.ErrorStringComparer()); diff != "" {
+				t.Errorf("diff = %s", diff)
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_CanI(t *testing.T) {
+	machineMethods := slices.Sorted(maps.Keys(permissions.GetServicePermissions().Visibility.Machine))
+	if len(machineMethods) == 0 {
+		t.Fatal("no api methods of machine visibility found")
+	}
+	machineMethod := machineMethods[0]
+
+	scoped := &apiv2.MethodServiceTokenScopedListResponse{
+		Permissions: []*apiv2.MethodPermission{
+			{
+				Subject: "project-b",
+				Methods: []string{apiv2connect.IPServiceCreateProcedure},
+			},
+			{
+				Subject: "machine-a",
+				Methods: []string{machineMethod},
+			},
+		},
+		ProjectRoles: map[string]apiv2.ProjectRole{
+			"project-a": apiv2.ProjectRole_PROJECT_ROLE_VIEWER,
+		},
+		TenantRoles: map[string]apiv2.TenantRole{
+			"tenant-a": apiv2.TenantRole_TENANT_ROLE_OWNER,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		subject string
+		want    *MethodAccess
+		wantErr error
+	}{
+		{
+			name:   "public method",
+			method: apiv2connect.HealthServiceGetProcedure,
+			want: &MethodAccess{
+				Method:     apiv2connect.HealthServiceGetProcedure,
+				Visibility: VisibilityPublic,
+				Allowed:    true,
+				GrantedBy:  "public method",
+			},
+		},
+		{
+			name:   "self method",
+			method: apiv2connect.TenantServiceListProcedure,
+			want: &MethodAccess{
+				Method:     apiv2connect.TenantServiceListProcedure,
+				Visibility: VisibilitySelf,
+				Allowed:    true,
+				GrantedBy:  "method for the owner of the token",
+			},
+		},
+		{
+			name:    "granted by project role",
+			method:  apiv2connect.IPServiceGetProcedure,
+			subject: "project-a",
+			want: &MethodAccess{
+				Method:     apiv2connect.IPServiceGetProcedure,
+				Visibility: VisibilityProject,
+				Subject:    "project-a",
+				Allowed:    true,
+				GrantedBy:  "PROJECT_ROLE_VIEWER on project project-a",
+			},
+		},
+		{
+			name:    "not granted by project role",
+			method:  apiv2connect.IPServiceCreateProcedure,
+			subject: "project-a",
+			want: &MethodAccess{
+				Method:     apiv2connect.IPServiceCreateProcedure,
+				Visibility: VisibilityProject,
+				Subject:    "project-a",
+				Allowed:    false,
+			},
+		},
+		{
+			name:    "granted by permission",
+			method:  apiv2connect.IPServiceCreateProcedure,
+			subject: "project-b",
+			want: &MethodAccess{
+				Method:     apiv2connect.IPServiceCreateProcedure,
+				Visibility: VisibilityProject,
+				Subject:    "project-b",
+				Allowed:    true,
+				GrantedBy:  "permission on project project-b",
+			},
+		},
+		{
+			name:    "granted by tenant role",
+			method:  apiv2connect.AuditServiceGetProcedure,
+			subject: "tenant-a",
+			want: &MethodAccess{
+				Method:     apiv2connect.AuditServiceGetProcedure,
+				Visibility: VisibilityTenant,
+				Subject:    "tenant-a",
+				Allowed:    true,
+				GrantedBy:  "TENANT_ROLE_OWNER on tenant tenant-a",
+			},
+		},
+		{
+			name:    "granted by permission on machine",
+			method:  machineMethod,
+			subject: "machine-a",
+			want: &MethodAccess{
+				Method:     machineMethod,
+				Visibility: VisibilityMachine,
+				Subject:    "machine-a",
+				Allowed:    true,
+				GrantedBy:  "permission on machine machine-a",
+			},
+		},
+		{
+			name:    "not granted on other machine",
+			method:  machineMethod,
+			subject: "machine-b",
+			want: &MethodAccess{
+				Method:     machineMethod,
+				Visibility: VisibilityMachine,
+				Subject:    "machine-b",
+				Allowed:    false,
+			},
+		},
+		{
+			name:    "machine method without machine",
+			method:  machineMethod,
+			wantErr: fmt.Errorf("method %s is called on a machine, please specify the machine", machineMethod),
+		},
+		{
+			name:   "admin method without admin role",
+			method: adminv2connect.AuditServiceListProcedure,
+			want: &MethodAccess{
+				Method:     adminv2connect.AuditServiceListProcedure,
+				Visibility: VisibilityAdmin,
+				Allowed:    false,
+			},
+		},
+		{
+			name:    "project method without project",
+			method:  apiv2connect.IPServiceCreateProcedure,
+			wantErr: fmt.Errorf("method %s is called on a project, please specify the project", apiv2connect.IPServiceCreateProcedure),
+		},
+		{
+			name:    "unknown method",
+			method:  "/foo",
+			wantErr: fmt.Errorf("method is not part of the api: /foo"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := CanI(scoped, tt.method, tt.subject)
+			if diff := cmp.Diff(gotErr, tt.wantErr, errorutil.ErrorStringComparer()); diff != "" {
+				t.Errorf("diff = %s", diff)
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_NewPermissionMatrix(t *testing.T) {
+	matrix := NewPermissionMatrix(&apiv2.MethodServiceTokenScopedListResponse{
+		Permissions: []*apiv2.MethodPermission{
+			{
+				Subject: "project-a",
+				Methods: []string{apiv2connect.IPServiceCreateProcedure},
+			},
+			{
+				Subject: "",
+				Methods: []string{adminv2connect.AuditServiceListProcedure},
+			},
+		},
+	})
+
+	if diff := cmp.Diff([]string{"project-a"}, matrix.Projects); diff != "" {
+		t.Errorf("projects diff (+got -want):\n %s", diff)
+	}
+	if len(matrix.Tenants) > 0 {
+		t.Errorf("expected no tenants, got %v", matrix.Tenants)
+	}
+	if !matrix.Admin {
+		t.Errorf("expected admin column")
+	}
+
+	grants := map[string]map[string]string{}
+	for _, m := range matrix.Methods {
+		if m.Visibility == VisibilityTenant {
+			t.Errorf("tenant method %s must not be part of the matrix without tenants", m.Method)
+		}
+		grants[m.Method] = m.Grants
+	}
+
+	want := map[string]map[string]string{
+		apiv2connect.IPServiceCreateProcedure:    {"project-a": "permission on project project-a"},
+		apiv2connect.IPServiceDeleteProcedure:    nil,
+		adminv2connect.AuditServiceListProcedure: {"admin": "permission"},
+	}
+	for method, wantGrants := range want {
+		gotGrants, ok := grants[method]
+		if !ok {
+			t.Errorf("method %s is missing in the matrix", method)
+			continue
+		}
+		if diff := cmp.Diff(wantGrants, gotGrants); diff != "" {
+			t.Errorf("grants of %s diff (+got -want):\n %s", method, diff)
+		}
+	}
+}
+
+func Test_SuggestPermissions(t *testing.T) {
+	traces := []*apiv2.AuditTrace{
+		{Method: apiv2connect.IPServiceCreateProcedure, Project: new("project-b"), Tenant: "tenant-a"},
+		{Method: apiv2connect.IPServiceListProcedure, Project: new("project-a"), Tenant: "tenant-a"},
+		{Method: apiv2connect.IPServiceCreateProcedure, Project: new("project-b"), Tenant: "tenant-a"},
+		{Method: apiv2connect.AuditServiceListProcedure, Tenant: "tenant-a"},
+		{Method: adminv2connect.MachineServiceListProcedure, Tenant: "tenant-a"},
+		{Method: apiv2connect.HealthServiceGetProcedure, Tenant: "tenant-a"},
+		{Method: apiv2connect.TenantServiceListProcedure, Tenant: "tenant-a"},
+		{Method: "/foo", Tenant: "tenant-a"},
+	}
+
+	want := []*apiv2.MethodPermission{
+		{
+			Subject: "",
+			Methods: []string{adminv2connect.MachineServiceListProcedure},
+		},
+		{
+			Subject: "project-a",
+			Methods: []string{apiv2connect.IPServiceListProcedure},
+		},
+		{
+			Subject: "project-b",
+			Methods: []string{apiv2connect.IPServiceCreateProcedure},
+		},
+		{
+			Subject: "tenant-a",
+			Methods: []string{apiv2connect.AuditServiceListProcedure},
+		},
+	}
+
+	if diff := cmp.Diff(want, SuggestPermissions(traces), protocmp.Transform()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func Test_GrantedTraces(t *testing.T) {
+	token := &apiv2.Token{
+		Permissions: []*apiv2.MethodPermission{
+			{
+				Subject: "project-b",
+				Methods: []string{apiv2connect.IPServiceCreateProcedure},
+			},
+		},
+		ProjectRoles: map[string]apiv2.ProjectRole{
+			"project-a": apiv2.ProjectRole_PROJECT_ROLE_VIEWER,
+		},
+	}
+
+	traces := []*apiv2.AuditTrace{
+		{Method: apiv2connect.IPServiceListProcedure, Project: new("project-a"), Tenant: "tenant-a"},
+		{Method: apiv2connect.IPServiceCreateProcedure, Project: new("project-a"), Tenant: "tenant-a"},
+		{Method: apiv2connect.IPServiceCreateProcedure, Project: new("project-b"), Tenant: "tenant-a"},
+		{Method: apiv2connect.AuditServiceListProcedure, Tenant: "tenant-a"},
+		{Method: adminv2connect.MachineServiceListProcedure, Tenant: "tenant-a"},
+		{Method: apiv2connect.HealthServiceGetProcedure, Tenant: "tenant-a"},
+		{Method: "/foo", Tenant: "tenant-a"},
+	}
+
+	want := []*apiv2.AuditTrace{
+		{Method: apiv2connect.IPServiceListProcedure, Project: new("project-a"), Tenant: "tenant-a"},
+		{Method: apiv2connect.IPServiceCreateProcedure, Project: new("project-b"), Tenant: "tenant-a"},
+		{Method: apiv2connect.HealthServiceGetProcedure, Tenant: "tenant-a"},
+	}
+
+	if diff := cmp.Diff(want, GrantedTraces(token, traces), protocmp.Transform()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
@@ -0,0 +1,213 @@
+package helpers
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/api/go/enum"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+)
+
+type SwitchFindingType string
+
+const (
+	SwitchFindingTypeNoSwitchPair         SwitchFindingType = "no-switch-pair"
+	SwitchFindingTypeSingleLeafConnection SwitchFindingType = "single-leaf-connection"
+	SwitchFindingTypePortNameMismatch     SwitchFindingType = "port-name-mismatch"
+	SwitchFindingTypeVRFMismatch          SwitchFindingType = "vrf-mismatch"
+	SwitchFindingTypeVNIMismatch          SwitchFindingType = "vni-mismatch"
+	SwitchFindingTypeOSMismatch           SwitchFindingType = "os-mismatch"
+	SwitchFindingTypeSyncOutdated         SwitchFindingType = "sync-outdated"
+	SwitchFindingTypeSyncError            SwitchFindingType = "sync-error"
+)
+
+// SwitchFinding is a problem found in the configuration of the switches of a rack.
+type SwitchFinding struct {
+	Type      SwitchFindingType `json:"type"`
+	Partition string            `json:"partition"`
+	Rack      string            `json:"rack"`
+	Switches  []string          `json:"switches"`
+	MachineID string            `json:"machine_id,omitempty"`
+	Details   string            `json:"details"`
+}
+
+type leafConnection struct {
+	switchID string
+	nic      *apiv2.SwitchNic
+}
+
+// CheckSwitchPairs groups the given switches by partition and rack and looks for inconsistencies
+// between the leaf switches of a group. machines are expected to be connected to every switch of
+// their group in the same way.
+//
+// the findings are ordered by partition, rack, type and machine.
+func CheckSwitchPairs(switches []*apiv2.Switch, syncThreshold time.Duration, now time.Time) []*SwitchFinding {
+	var (
+		findings []*SwitchFinding
+		groups   = map[[2]string][]*apiv2.Switch{}
+	)
+
+	for _, sw := range switches {
+		key := [2]string{sw.Partition, pointer.SafeDeref(sw.Rack)}
+		groups[key] = append(groups[key], sw)
+	}
+
+	for key, group := range groups {
+		var (
+			partition, rack = key[0], key[1]
+			switchIDs       []string
+			connections     = map[string][]*leafConnection{}
+			oses            []string
+		)
+
+		slices.SortFunc(group, func(a, b *apiv2.Switch) int {
+			return cmp.Compare(a.Id, b.Id)
+		})
+
+		newFinding := func(t SwitchFindingType, switchIDs []string, machineID, details string) {
+			findings = append(findings, &SwitchFinding{
+				Type:      t,
+				Partition: partition,
+				Rack:      rack,
+				Switches:  switchIDs,
+				MachineID: machineID,
+				Details:   details,
+			})
+		}
+
+		for _, sw := range group {
+			switchIDs = append(switchIDs, sw.Id)
+			oses = append(oses, fmt.Sprintf("%s: %s", sw.Id, switchOS(sw.Os)))
+
+			if details, ok := syncProblem(sw, syncThreshold, now); ok {
+				newFinding(SwitchFindingTypeSyncOutdated, []string{sw.Id}, "", details)
+			}
+
+			if sw.LastSyncError != nil && sw.LastSyncError.Time != nil &&
+				(sw.LastSync == nil || sw.LastSync.Time == nil || sw.LastSyncError.Time.AsTime().After(sw.LastSync.Time.AsTime())) {
+				newFinding(SwitchFindingTypeSyncError, []string{sw.Id}, "", fmt.Sprintf("sync failed at %s: %s", sw.LastSyncError.Time.AsTime().Format(time.RFC3339), pointer.SafeDeref(sw.LastSyncError.Error)))
+			}
+
+			for _, con := range sw.MachineConnections {
+				if con.Nic == nil {
+					continue
+				}
+
+				connections[con.MachineId] = append(connections[con.MachineId], &leafConnection{
+					switchID: sw.Id,
+					nic:      con.Nic,
+				})
+			}
+		}
+
+		if len(group) < 2 {
+			newFinding(SwitchFindingTypeNoSwitchPair, switchIDs, "", "no second leaf switch found in the rack")
+			continue
+		}
+
+		for i := range group[1:] {
+			if switchOS(group[i].Os) != switchOS(group[i+1].Os) {
+				newFinding(SwitchFindingTypeOSMismatch, switchIDs, "", strings.Join(oses, ", "))
+				break
+			}
+		}
+
+		for machineID, cons := range connections {
+			if len(cons) < 2 {
+				newFinding(SwitchFindingTypeSingleLeafConnection, []string{cons[0].switchID}, machineID, fmt.Sprintf("only connected to %s/%s", cons[0].switchID, cons[0].nic.Name))
+				continue
+			}
+
+			var conSwitchIDs []string
+			for _, con := range cons {
+				conSwitchIDs = append(conSwitchIDs, con.switchID)
+			}
+
+			if details, differ := compareConnections(cons, func(nic *apiv2.SwitchNic) string {
+				return nic.Name
+			}); differ {
+				newFinding(SwitchFindingTypePortNameMismatch, conSwitchIDs, machineID, details)
+			}
+
+			if details, differ := compareConnections(cons, func(nic *apiv2.SwitchNic) string {
+				return pointer.SafeDeref(nic.Vrf)
+			}); differ {
+				newFinding(SwitchFindingTypeVRFMismatch, conSwitchIDs, machineID, details)
+			}
+
+			if details, differ := compareConnections(cons, func(nic *apiv2.SwitchNic) string {
+				if nic.BgpFilter == nil {
+					return ""
+				}
+				vnis := slices.Clone(nic.BgpFilter.Vnis)
+				slices.Sort(vnis)
+				return strings.Join(vnis, ",")
+			}); differ {
+				newFinding(SwitchFindingTypeVNIMismatch, conSwitchIDs, machineID, details)
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b *SwitchFinding) int {
+		return cmp.Or(
+			cmp.Compare(a.Partition, b.Partition),
+			cmp.Compare(a.Rack, b.Rack),
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.MachineID, b.MachineID),
+			cmp.Compare(strings.Join(a.Switches, ","), strings.Join(b.Switches, ",")),
+		)
+	})
+
+	return findings
+}
+
+func syncProblem(sw *apiv2.Switch, threshold time.Duration, now time.Time) (string, bool) {
+	if sw.LastSync == nil || sw.LastSync.Time == nil || sw.LastSync.Time.AsTime().IsZero() {
+		return "switch has never synced", true
+	}
+
+	if age := now.Sub(sw.LastSync.Time.AsTime()); age > threshold {
+		return fmt.Sprintf("last sync was %s ago", HumanizeDuration(age)), true
+	}
+
+	return "", false
+}
+
+func switchOS(os *apiv2.SwitchOS) string {
+	if os == nil {
+		return "unknown"
+	}
+
+	vendor := os.Vendor.String()
+	if name, err := enum.GetStringValue(os.Vendor); err == nil {
+		vendor = *name
+	}
+
+	return strings.TrimSpace(vendor + " " + os.Version)
+}
+
+// compareConnections returns a description of the values of all connections if they differ.
+func compareConnections(cons []*leafConnection, value func(nic *apiv2.SwitchNic) string) (string, bool) {
+	var (
+		parts  []string
+		differ bool
+	)
+
+	for _, con := range cons {
+		v := value(con.nic)
+		if v != value(cons[0].nic) {
+			differ = true
+		}
+		if v == "" {
+			v = "none"
+		}
+
+		parts = append(parts, fmt.Sprintf("%s: %s", con.switchID, v))
+	}
+
+	return strings.Join(parts, ", "), differ
+}
@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_CheckSwitchPairs(t *testing.T) {
+	consistentPair := func() []*apiv2.Switch {
+		leaf01 := testresources.Switch1()
+		leaf02 := testresources.Switch2()
+		leaf02.Nics = []*apiv2.SwitchNic{testresources.Nic1(), testresources.Nic2()}
+		leaf02.MachineConnections = []*apiv2.MachineConnection{{MachineId: "id1", Nic: testresources.Nic1()}}
+
+		return []*apiv2.Switch{leaf01, leaf02}
+	}
+
+	tests := []struct {
+		name     string
+		switches func() []*apiv2.Switch
+		want     []*SwitchFinding
+	}{
+		{
+			name:     "consistent pair",
+			switches: consistentPair,
+			want:     nil,
+		},
+		{
+			name: "inconsistent pair",
+			switches: func() []*apiv2.Switch {
+				switches := consistentPair()
+
+				switches[0].MachineConnections = append(switches[0].MachineConnections, &apiv2.MachineConnection{MachineId: "id2", Nic: testresources.Nic2()})
+				switches[1].MachineConnections = []*apiv2.MachineConnection{{MachineId: "id1", Nic: testresources.Nic2()}}
+				switches[1].Os.Version = "4.3.0"
+				switches[1].LastSync.Time = timestamppb.New(e2e.TimeBubbleStartTime().Add(-30 * time.Minute))
+
+				return switches
+			},
+			want: []*SwitchFinding{
+				{Type: SwitchFindingTypeOSMismatch, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf01", "leaf02"}, Details: "leaf01: SONiC 4.2.0, leaf02: SONiC 4.3.0"},
+				{Type: SwitchFindingTypePortNameMismatch, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf01", "leaf02"}, MachineID: "id1", Details: "leaf01: Ethernet0, leaf02: Ethernet4"},
+				{Type: SwitchFindingTypeSingleLeafConnection, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf01"}, MachineID: "id2", Details: "only connected to leaf01/Ethernet4"},
+				{Type: SwitchFindingTypeSyncOutdated, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf02"}, Details: "last sync was 30m ago"},
+				{Type: SwitchFindingTypeVNIMismatch, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf01", "leaf02"}, MachineID: "id1", Details: "leaf01: 10001,10002, leaf02: 20001"},
+				{Type: SwitchFindingTypeVRFMismatch, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf01", "leaf02"}, MachineID: "id1", Details: "leaf01: default, leaf02: none"},
+			},
+		},
+		{
+			name: "switch without pair",
+			switches: func() []*apiv2.Switch {
+				leaf01 := testresources.Switch1()
+				leaf01.Rack = new("rack-2")
+				leaf01.LastSync = nil
+
+				return []*apiv2.Switch{leaf01, testresources.Switch2()}
+			},
+			want: []*SwitchFinding{
+				{Type: SwitchFindingTypeNoSwitchPair, Partition: "fra-equ01", Rack: "rack-1", Switches: []string{"leaf02"}, Details: "no second leaf switch found in the rack"},
+				{Type: SwitchFindingTypeNoSwitchPair, Partition: "fra-equ01", Rack: "rack-2", Switches: []string{"leaf01"}, Details: "no second leaf switch found in the rack"},
+				{Type: SwitchFindingTypeSyncOutdated, Partition: "fra-equ01", Rack: "rack-2", Switches: []string{"leaf01"}, Details: "switch has never synced"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckSwitchPairs(tt.switches(), 10*time.Minute, e2e.TimeBubbleStartTime())
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+)
+
+var switchPortNumberRegex = regexp.MustCompile("([0-9]+)")
+
+// SwitchPort is a port of a switch together with the machine connected to it.
+type SwitchPort struct {
+	SwitchID  string
+	Nic       *apiv2.SwitchNic
+	MachineID string
+}
+
+// SwitchPorts returns the ports of the switch nics and the ports which only occur in the machine connections,
+// ordered by their interface numbers. the nic of a machine connection is preferred as it carries the actual port state.
+func SwitchPorts(sw *apiv2.Switch) []*SwitchPort {
+	var (
+		ports  []*SwitchPort
+		byName = map[string]*SwitchPort{}
+	)
+
+	for _, nic := range sw.Nics {
+		if nic == nil {
+			continue
+		}
+
+		p := &SwitchPort{SwitchID: sw.Id, Nic: nic}
+		byName[nic.Name] = p
+		ports = append(ports, p)
+	}
+
+	for _, con := range sw.MachineConnections {
+		if con == nil || con.Nic == nil {
+			continue
+		}
+
+		p, ok := byName[con.Nic.Name]
+		if !ok {
+			p = &SwitchPort{SwitchID: sw.Id, Nic: con.Nic}
+			byName[con.Nic.Name] = p
+			ports = append(ports, p)
+		}
+
+		if con.Nic.State != nil {
+			p.Nic = con.Nic
+		}
+		p.MachineID = con.MachineId
+	}
+
+	slices.SortStableFunc(ports, func(a, b *SwitchPort) int {
+		return CompareSwitchPortNames(a.Nic.Name, b.Nic.Name)
+	})
+
+	return ports
+}
+
+// CompareSwitchPortNames compares switch port names by the numbers they contain, such that Ethernet4 is ordered before Ethernet12.
+func CompareSwitchPortNames(a, b string) int {
+	var (
+		aMatch = switchPortNumberRegex.FindAllString(a, -1)
+		bMatch = switchPortNumberRegex.FindAllString(b, -1)
+	)
+
+	for i := range aMatch {
+		if i >= len(bMatch) {
+			// a has more numbers than b with an equal prefix, e.g. swp1s1 and swp1
+			return 1
+		}
+
+		numberA, aErr := strconv.Atoi(aMatch[i])
+		numberB, bErr := strconv.Atoi(bMatch[i])
+
+		if aErr == nil && bErr == nil && numberA != numberB {
+			if numberA < numberB {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	if len(aMatch) < len(bMatch) {
+		return -1
+	}
+
+	return strings.Compare(a, b)
+}
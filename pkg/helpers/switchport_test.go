@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+)
+
+func Test_SwitchPorts(t *testing.T) {
+	tests := []struct {
+		name string
+		sw   func() *apiv2.Switch
+		want []string
+	}{
+		{
+			name: "nics and connections",
+			sw:   testresources.Switch1,
+			want: []string{"Ethernet0 id1", "Ethernet4 "},
+		},
+		{
+			name: "ordered by interface numbers",
+			sw: func() *apiv2.Switch {
+				return &apiv2.Switch{
+					Id: "leaf01",
+					Nics: []*apiv2.SwitchNic{
+						{Name: "swp10s0"},
+						{Name: "swp2s1"},
+						{Name: "swp2s0"},
+					},
+					MachineConnections: []*apiv2.MachineConnection{
+						{MachineId: "m1", Nic: &apiv2.SwitchNic{Name: "swp1s0"}},
+					},
+				}
+			},
+			want: []string{"swp1s0 m1", "swp2s0 ", "swp2s1 ", "swp10s0 "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range SwitchPorts(tt.sw()) {
+				got = append(got, p.Nic.Name+" "+p.MachineID)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_CompareSwitchPortNames(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "Ethernet4", b: "Ethernet12", want: -1},
+		{a: "Ethernet12", b: "Ethernet4", want: 1},
+		{a: "swp1s1", b: "swp1", want: 1},
+		{a: "swp1", b: "swp1s1", want: -1},
+		{a: "swp2s0", b: "swp2s1", want: -1},
+		{a: "swp2s1", b: "swp2s1", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := CompareSwitchPortNames(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareSwitchPortNames(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"slices"
+	"time"
+
+	"github.com/metal-stack/api/go/enum"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+)
+
+// SwitchSnapshot is the state of the ports of a switch before it gets replaced or migrated.
+// it is persisted such that an interrupted workflow can be resumed.
+type SwitchSnapshot struct {
+	Operation      string                `json:"operation"`
+	SwitchID       string                `json:"switch_id"`
+	TargetSwitchID string                `json:"target_switch_id,omitempty"`
+	Phase          string                `json:"phase"`
+	PhaseChangedAt time.Time             `json:"phase_changed_at"`
+	TakenAt        time.Time             `json:"taken_at"`
+	Ports          []*SwitchSnapshotPort `json:"ports"`
+}
+
+type SwitchSnapshotPort struct {
+	Name      string `json:"name"`
+	Vrf       string `json:"vrf,omitempty"`
+	State     string `json:"state,omitempty"`
+	MachineID string `json:"machine_id,omitempty"`
+}
+
+// SwitchPortChange is a difference between a port of a switch snapshot and the current state of the port.
+type SwitchPortChange struct {
+	Port      string `json:"port"`
+	MachineID string `json:"machine_id,omitempty"`
+	Change    string `json:"change"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+	// LostConnectivity is true if the machine connected to the port before is not properly connected anymore
+	LostConnectivity bool `json:"lost_connectivity"`
+}
+
+func NewSwitchSnapshot(operation string, sw *apiv2.Switch, now time.Time) *SwitchSnapshot {
+	return &SwitchSnapshot{
+		Operation:      operation,
+		SwitchID:       sw.Id,
+		PhaseChangedAt: now,
+		TakenAt:        now,
+		Ports:          switchSnapshotPorts(sw),
+	}
+}
+
+// switchSnapshotPorts returns the ports of the switch nics, followed by ports which only occur in the machine connections.
+func switchSnapshotPorts(sw *apiv2.Switch) []*SwitchSnapshotPort {
+	var (
+		ports  []*SwitchSnapshotPort
+		byName = map[string]*SwitchSnapshotPort{}
+	)
+
+	port := func(nic *apiv2.SwitchNic) *SwitchSnapshotPort {
+		p, ok := byName[nic.Name]
+		if !ok {
+			p = &SwitchSnapshotPort{
+				Name: nic.Name,
+				Vrf:  pointer.SafeDeref(nic.Vrf),
+			}
+			byName[nic.Name] = p
+			ports = append(ports, p)
+		}
+
+		if nic.State != nil {
+			p.State = switchPortStatusString(nic.State.Actual)
+		}
+
+		return p
+	}
+
+	for _, nic := range sw.Nics {
+		port(nic)
+	}
+
+	for _, con := range sw.MachineConnections {
+		if con.Nic == nil {
+			continue
+		}
+
+		port(con.Nic).MachineID = con.MachineId
+	}
+
+	return ports
+}
+
+func switchPortStatusString(status apiv2.SwitchPortStatus) string {
+	if s, err := enum.GetStringValue(status); err == nil {
+		return *s
+	}
+
+	return status.String()
+}
+
+// DiffSwitchSnapshot compares the ports of the snapshot with the current ports of the given switch.
+func DiffSwitchSnapshot(snapshot *SwitchSnapshot, sw *apiv2.Switch) []*SwitchPortChange {
+	var (
+		changes []*SwitchPortChange
+		current = map[string]*SwitchSnapshotPort{}
+		known   = map[string]bool{}
+	)
+
+	up := switchPortStatusString(apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_UP)
+
+	ports := switchSnapshotPorts(sw)
+	for _, p := range ports {
+		current[p.Name] = p
+	}
+
+	for _, before := range snapshot.Ports {
+		known[before.Name] = true
+
+		after, ok := current[before.Name]
+		if !ok {
+			changes = append(changes, &SwitchPortChange{
+				Port:             before.Name,
+				MachineID:        before.MachineID,
+				Change:           "port missing",
+				Before:           before.Name,
+				LostConnectivity: before.MachineID != "",
+			})
+			continue
+		}
+
+		if before.MachineID != after.MachineID {
+			change := &SwitchPortChange{
+				Port:             before.Name,
+				MachineID:        before.MachineID,
+				Change:           "machine changed",
+				Before:           before.MachineID,
+				After:            after.MachineID,
+				LostConnectivity: before.MachineID != "",
+			}
+
+			switch {
+			case before.MachineID == "":
+				change.MachineID = after.MachineID
+				change.Change = "machine connected"
+			case after.MachineID == "":
+				change.Change = "machine disconnected"
+			}
+
+			changes = append(changes, change)
+		}
+
+		if before.State != after.State {
+			changes = append(changes, &SwitchPortChange{
+				Port:             before.Name,
+				MachineID:        before.MachineID,
+				Change:           "state changed",
+				Before:           before.State,
+				After:            after.State,
+				LostConnectivity: before.MachineID != "" && before.MachineID == after.MachineID && before.State == up,
+			})
+		}
+
+		if before.Vrf != after.Vrf {
+			changes = append(changes, &SwitchPortChange{
+				Port:      before.Name,
+				MachineID: before.MachineID,
+				Change:    "vrf changed",
+				Before:    before.Vrf,
+				After:     after.Vrf,
+			})
+		}
+	}
+
+	for _, after := range ports {
+		if known[after.Name] {
+			continue
+		}
+
+		changes = append(changes, &SwitchPortChange{
+			Port:      after.Name,
+			MachineID: after.MachineID,
+			Change:    "port added",
+			After:     after.Name,
+		})
+	}
+
+	return changes
+}
+
+// MachinesWithLostConnectivity returns the ids of the machines which lost connectivity according to the given changes.
+func MachinesWithLostConnectivity(changes []*SwitchPortChange) []string {
+	var ids []string
+
+	for _, c := range changes {
+		if c.LostConnectivity && c.MachineID != "" {
+			ids = append(ids, c.MachineID)
+		}
+	}
+
+	slices.Sort(ids)
+
+	return slices.Compact(ids)
+}
@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+)
+
+func Test_DiffSwitchSnapshot(t *testing.T) {
+	snapshot := NewSwitchSnapshot("replace", testresources.Switch1(), e2e.TimeBubbleStartTime())
+
+	tests := []struct {
+		name     string
+		sw       func() *apiv2.Switch
+		want     []*SwitchPortChange
+		wantLost []string
+	}{
+		{
+			name: "unchanged",
+			sw:   testresources.Switch1,
+			want: nil,
+		},
+		{
+			name: "port down",
+			sw: func() *apiv2.Switch {
+				sw := testresources.Switch1()
+				sw.MachineConnections[0].Nic.State.Actual = apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN
+				return sw
+			},
+			want: []*SwitchPortChange{
+				{Port: "Ethernet0", MachineID: "id1", Change: "state changed", Before: "up", After: "down", LostConnectivity: true},
+			},
+			wantLost: []string{"id1"},
+		},
+		{
+			name: "machine moved to another port",
+			sw: func() *apiv2.Switch {
+				sw := testresources.Switch1()
+				sw.Nics = []*apiv2.SwitchNic{testresources.Nic2()}
+				sw.MachineConnections = []*apiv2.MachineConnection{
+					{MachineId: "id1", Nic: &apiv2.SwitchNic{Name: "Ethernet8"}},
+				}
+				return sw
+			},
+			want: []*SwitchPortChange{
+				{Port: "Ethernet0", MachineID: "id1", Change: "port missing", Before: "Ethernet0", LostConnectivity: true},
+				{Port: "Ethernet8", MachineID: "id1", Change: "port added", After: "Ethernet8"},
+			},
+			wantLost: []string{"id1"},
+		},
+		{
+			name: "vrf changed",
+			sw: func() *apiv2.Switch {
+				sw := testresources.Switch1()
+				sw.Nics[1].Vrf = new("vrf-1")
+				return sw
+			},
+			want: []*SwitchPortChange{
+				{Port: "Ethernet4", Change: "vrf changed", After: "vrf-1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffSwitchSnapshot(snapshot, tt.sw())
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantLost, MachinesWithLostConnectivity(got)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
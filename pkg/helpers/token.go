@@ -9,6 +9,42 @@ import (
 	"github.com/metal-stack/api/go/permissions"
 )
 
+// ToMethodPermissions parses permissions given in the form [<subject>=]<methods-colon-separated>.
+func ToMethodPermissions(perms []string) []*apiv2.MethodPermission {
+	var res []*apiv2.MethodPermission
+
+	for _, perm := range perms {
+		subject, colonSeparatedMethods, ok := strings.Cut(perm, "=")
+		if !ok {
+			subject, colonSeparatedMethods = "", perm
+		}
+
+		res = append(res, &apiv2.MethodPermission{
+			Subject: subject,
+			Methods: strings.Split(colonSeparatedMethods, ":"),
+		})
+	}
+
+	return res
+}
+
+// FromMethodPermissions formats permissions into the form [<subject>=]<methods-colon-separated>, it is the inverse of ToMethodPermissions.
+func FromMethodPermissions(perms []*apiv2.MethodPermission) []string {
+	var res []string
+
+	for _, perm := range perms {
+		methods := strings.Join(perm.Methods, ":")
+		if perm.Subject == "" {
+			res = append(res, methods)
+			continue
+		}
+
+		res = append(res, perm.Subject+"="+methods)
+	}
+
+	return res
+}
+
 func ToPermissionsByVisibility(perms []*apiv2.MethodPermission) ([]*apiv2.PermissionsByVisibility, error) {
 	var res []*apiv2.PermissionsByVisibility
 
@@ -155,3 +155,29 @@ func Test_toPermissionsByVisibility(t *testing.T) {
 		})
 	}
 }
+
+func Test_MethodPermissions(t *testing.T) {
+	var (
+		flags = []string{
+			"project-a=" + apiv2connect.IPServiceCreateProcedure + ":" + apiv2connect.IPServiceDeleteProcedure,
+			adminv2connect.AuditServiceListProcedure,
+		}
+		perms = []*apiv2.MethodPermission{
+			{
+				Subject: "project-a",
+				Methods: []string{apiv2connect.IPServiceCreateProcedure, apiv2connect.IPServiceDeleteProcedure},
+			},
+			{
+				Subject: "",
+				Methods: []string{adminv2connect.AuditServiceListProcedure},
+			},
+		}
+	)
+
+	if diff := cmp.Diff(perms, ToMethodPermissions(flags), protocmp.Transform()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+	if diff := cmp.Diff(flags, FromMethodPermissions(perms)); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
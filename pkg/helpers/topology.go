@@ -0,0 +1,323 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+)
+
+type TopologyNodeKind string
+
+const (
+	TopologyNodeKindPartition  TopologyNodeKind = "partition"
+	TopologyNodeKindRack       TopologyNodeKind = "rack"
+	TopologyNodeKindSwitchPair TopologyNodeKind = "switch-pair"
+	TopologyNodeKindSwitch     TopologyNodeKind = "switch"
+	TopologyNodeKindPort       TopologyNodeKind = "port"
+	TopologyNodeKindMachine    TopologyNodeKind = "machine"
+)
+
+// TopologyNode is a node of the switch topology graph including the ids of the nodes it is connected to.
+type TopologyNode struct {
+	ID    string           `json:"id"`
+	Kind  TopologyNodeKind `json:"kind"`
+	Label string           `json:"label"`
+	// Issue describes why the node is highlighted, e.g. because the port is down
+	Issue    string   `json:"issue,omitempty"`
+	Adjacent []string `json:"adjacent"`
+}
+
+// Topology is a directed graph of partition → rack → switch pair → switch → port → machine.
+type Topology struct {
+	Nodes []*TopologyNode `json:"nodes"`
+
+	byID map[string]*TopologyNode
+}
+
+// switchGroup are the switches of a rack, which are usually a pair of leaf switches.
+type switchGroup struct {
+	partition string
+	rack      string
+	switches  []*apiv2.Switch
+}
+
+// NewSwitchTopology builds the topology graph from the given switches, the switches with machines
+// are optional and used for enriching the machine nodes.
+//
+// the switches are grouped into pairs by partition and rack, like for the switch check.
+// all nics of a switch are part of the graph as ports. they are highlighted when they are down, when their bgp session
+// is not established, when no machine is connected or when the machine connection differs from the desired switch nic.
+func NewSwitchTopology(switches []*apiv2.Switch, switchesWithMachines []*apiv2.SwitchWithMachines) *Topology {
+	var (
+		t = &Topology{
+			byID: map[string]*TopologyNode{},
+		}
+		machines = map[string]*apiv2.Machine{}
+		groups   []*switchGroup
+		byKey    = map[[2]string]*switchGroup{}
+	)
+
+	for _, sw := range switchesWithMachines {
+		for _, con := range sw.Connections {
+			if con.Machine != nil {
+				machines[con.Machine.Uuid] = con.Machine
+			}
+		}
+	}
+
+	for _, sw := range switches {
+		key := [2]string{sw.Partition, pointer.SafeDeref(sw.Rack)}
+
+		group, ok := byKey[key]
+		if !ok {
+			group = &switchGroup{
+				partition: key[0],
+				rack:      key[1],
+			}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+
+		group.switches = append(group.switches, sw)
+	}
+
+	for _, group := range groups {
+		var (
+			partitionID = "partition:" + group.partition
+			parentID    = partitionID
+			pairID      = "pair:" + group.partition + "/" + group.rack
+			switchIDs   []string
+		)
+
+		t.addNode(partitionID, TopologyNodeKindPartition, group.partition)
+
+		if group.rack != "" {
+			parentID = "rack:" + group.partition + "/" + group.rack
+			t.addNode(parentID, TopologyNodeKindRack, group.rack)
+			t.addEdge(partitionID, parentID)
+		}
+
+		for _, sw := range group.switches {
+			switchIDs = append(switchIDs, sw.Id)
+		}
+
+		pair := t.addNode(pairID, TopologyNodeKindSwitchPair, strings.Join(switchIDs, " + "))
+		if group.rack != "" && len(group.switches) < 2 {
+			pair.Issue = "no second leaf switch found in the rack"
+		}
+		t.addEdge(parentID, pairID)
+
+		for _, sw := range group.switches {
+			t.addSwitch(pairID, sw, machines)
+		}
+	}
+
+	return t
+}
+
+func (t *Topology) addSwitch(parentID string, sw *apiv2.Switch, machines map[string]*apiv2.Machine) {
+	var (
+		switchID    = "switch:" + sw.Id
+		desired     = map[string]bool{}
+		connections = map[string]*apiv2.MachineConnection{}
+	)
+
+	t.addNode(switchID, TopologyNodeKindSwitch, sw.Id)
+	t.addEdge(parentID, switchID)
+
+	for _, con := range sw.MachineConnections {
+		if con.Nic != nil {
+			connections[con.Nic.Name] = con
+		}
+	}
+
+	addPort := func(name, issue string, con *apiv2.MachineConnection) {
+		portID := "port:" + sw.Id + "/" + name
+
+		port := t.addNode(portID, TopologyNodeKindPort, name)
+		port.Issue = issue
+		t.addEdge(switchID, portID)
+
+		if con == nil {
+			return
+		}
+
+		machineID := "machine:" + con.MachineId
+		t.addNode(machineID, TopologyNodeKindMachine, machineLabel(con.MachineId, machines[con.MachineId]))
+		t.addEdge(portID, machineID)
+	}
+
+	for _, nic := range sw.Nics {
+		desired[nic.Name] = true
+		con := connections[nic.Name]
+		addPort(nic.Name, portIssue(nic, con), con)
+	}
+
+	for _, con := range sw.MachineConnections {
+		if con.Nic == nil || desired[con.Nic.Name] {
+			continue
+		}
+		addPort(con.Nic.Name, "not part of the switch nics", con)
+	}
+}
+
+func (t *Topology) addNode(id string, kind TopologyNodeKind, label string) *TopologyNode {
+	if n, ok := t.byID[id]; ok {
+		return n
+	}
+
+	n := &TopologyNode{
+		ID:       id,
+		Kind:     kind,
+		Label:    label,
+		Adjacent: []string{},
+	}
+
+	t.byID[id] = n
+	t.Nodes = append(t.Nodes, n)
+
+	return n
+}
+
+func (t *Topology) addEdge(from, to string) {
+	n := t.byID[from]
+
+	for _, id := range n.Adjacent {
+		if id == to {
+			return
+		}
+	}
+
+	n.Adjacent = append(n.Adjacent, to)
+}
+
+// portIssue compares the desired nic of a switch with the nic of its machine connection, which is nil if no machine is connected.
+func portIssue(desired *apiv2.SwitchNic, con *apiv2.MachineConnection) string {
+	var (
+		issues []string
+		state  = desired.State
+	)
+
+	differs := func(field, actual, desired string) {
+		if actual != "" && desired != "" && actual != desired {
+			issues = append(issues, fmt.Sprintf("%s is %s but should be %s", field, actual, desired))
+		}
+	}
+
+	if con == nil {
+		issues = append(issues, "no machine connected")
+	} else {
+		differs("identifier", con.Nic.Identifier, desired.Identifier)
+		differs("mac", pointer.SafeDeref(con.Nic.Mac), pointer.SafeDeref(desired.Mac))
+		differs("vrf", pointer.SafeDeref(con.Nic.Vrf), pointer.SafeDeref(desired.Vrf))
+
+		if state == nil {
+			state = con.Nic.State
+		}
+	}
+
+	switch {
+	case state == nil:
+		issues = append(issues, "port status is unknown")
+	case state.Desired != nil && *state.Desired != state.Actual:
+		issues = append(issues, fmt.Sprintf("%s but should be %s", state.Actual.String(), state.Desired.String()))
+	case state.Actual == apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN:
+		issues = append(issues, state.Actual.String())
+	}
+
+	if bgp := desired.BgpPortState; bgp != nil && bgp.BgpState != apiv2.BGPState_BGP_STATE_ESTABLISHED {
+		issues = append(issues, fmt.Sprintf("bgp is %s", bgp.BgpState.String()))
+	}
+
+	return strings.Join(issues, "; ")
+}
+
+func machineLabel(id string, m *apiv2.Machine) string {
+	if m == nil || m.Allocation == nil || m.Allocation.Hostname == "" {
+		return id
+	}
+
+	return m.Allocation.Hostname + "\n" + id
+}
+
+// DOT renders the topology in the Graphviz DOT language, highlighted nodes are colored red.
+func (t *Topology) DOT() string {
+	shapes := map[TopologyNodeKind]string{
+		TopologyNodeKindPartition:  "folder",
+		TopologyNodeKindRack:       "box3d",
+		TopologyNodeKindSwitchPair: "tab",
+		TopologyNodeKindSwitch:     "box",
+		TopologyNodeKindPort:       "ellipse",
+		TopologyNodeKindMachine:    "component",
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("digraph topology {\n")
+	sb.WriteString("  rankdir=LR;\n")
+
+	for _, n := range t.Nodes {
+		label := n.Label
+		attrs := fmt.Sprintf("shape=%s", shapes[n.Kind])
+
+		if n.Issue != "" {
+			label += "\n" + n.Issue
+			attrs += ", color=red, fontcolor=red"
+		}
+
+		fmt.Fprintf(&sb, "  %q [label=%q, %s];\n", n.ID, label, attrs)
+	}
+
+	for _, n := range t.Nodes {
+		for _, to := range n.Adjacent {
+			fmt.Fprintf(&sb, "  %q -> %q;\n", n.ID, to)
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// Mermaid renders the topology as a Mermaid flowchart, highlighted nodes are colored red.
+func (t *Topology) Mermaid() string {
+	var (
+		sb     strings.Builder
+		ids    = map[string]string{}
+		issues []string
+	)
+
+	// mermaid node ids may not contain special characters, so the nodes are enumerated
+	for i, n := range t.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	sb.WriteString("flowchart LR\n")
+
+	for _, n := range t.Nodes {
+		label := n.Label
+		if n.Issue != "" {
+			label += "\n" + n.Issue
+			issues = append(issues, ids[n.ID])
+		}
+
+		label = strings.NewReplacer(`"`, "#quot;", "\n", "<br/>").Replace(label)
+
+		fmt.Fprintf(&sb, "    %s[\"%s\"]\n", ids[n.ID], label)
+	}
+
+	for _, n := range t.Nodes {
+		for _, to := range n.Adjacent {
+			fmt.Fprintf(&sb, "    %s --> %s\n", ids[n.ID], ids[to])
+		}
+	}
+
+	if len(issues) > 0 {
+		sb.WriteString("    classDef issue stroke:#f00,color:#f00\n")
+		fmt.Fprintf(&sb, "    class %s issue\n", strings.Join(issues, ","))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+)
+
+func Test_SwitchTopology(t *testing.T) {
+	// the machine is connected to a different nic than the desired one
+	recabled := testresources.Nic2()
+	recabled.Mac = new("52:54:00:ab:cd:ff")
+
+	leaf02 := testresources.Switch2()
+	leaf02.Nics = []*apiv2.SwitchNic{testresources.Nic1(), testresources.Nic2()}
+	leaf02.MachineConnections = []*apiv2.MachineConnection{
+		{MachineId: "id1", Nic: recabled},
+		{MachineId: "id2", Nic: &apiv2.SwitchNic{Name: "Ethernet8"}},
+	}
+
+	tests := []struct {
+		name                 string
+		switches             []*apiv2.Switch
+		switchesWithMachines []*apiv2.SwitchWithMachines
+		wantDOT              string
+		wantMermaid          string
+	}{
+		{
+			name: "no switches",
+			wantDOT: `digraph topology {
+  rankdir=LR;
+}
+`,
+			wantMermaid: `flowchart LR
+`,
+		},
+		{
+			name:     "switch pair",
+			switches: []*apiv2.Switch{testresources.Switch1(), leaf02},
+			switchesWithMachines: []*apiv2.SwitchWithMachines{
+				testresources.SwitchWithMachines1(),
+				{
+					Id: leaf02.Id,
+					Connections: []*apiv2.SwitchNicWithMachine{
+						{
+							Nic: &apiv2.SwitchNic{Name: "Ethernet8"},
+							Machine: &apiv2.Machine{
+								Uuid:       "id2",
+								Allocation: &apiv2.MachineAllocation{Hostname: "worker-2"},
+							},
+						},
+					},
+				},
+			},
+			wantDOT: `digraph topology {
+  rankdir=LR;
+  "partition:fra-equ01" [label="fra-equ01", shape=folder];
+  "rack:fra-equ01/rack-1" [label="rack-1", shape=box3d];
+  "pair:fra-equ01/rack-1" [label="leaf01 + leaf02", shape=tab];
+  "switch:leaf01" [label="leaf01", shape=box];
+  "port:leaf01/Ethernet0" [label="Ethernet0", shape=ellipse];
+  "machine:id1" [label="id1", shape=component];
+  "port:leaf01/Ethernet4" [label="Ethernet4\nno machine connected; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE", shape=ellipse, color=red, fontcolor=red];
+  "switch:leaf02" [label="leaf02", shape=box];
+  "port:leaf02/Ethernet0" [label="Ethernet0\nno machine connected", shape=ellipse, color=red, fontcolor=red];
+  "port:leaf02/Ethernet4" [label="Ethernet4\nmac is 52:54:00:ab:cd:ff but should be 52:54:00:ab:cd:02; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE", shape=ellipse, color=red, fontcolor=red];
+  "port:leaf02/Ethernet8" [label="Ethernet8\nnot part of the switch nics", shape=ellipse, color=red, fontcolor=red];
+  "machine:id2" [label="worker-2\nid2", shape=component];
+  "partition:fra-equ01" -> "rack:fra-equ01/rack-1";
+  "rack:fra-equ01/rack-1" -> "pair:fra-equ01/rack-1";
+  "pair:fra-equ01/rack-1" -> "switch:leaf01";
+  "pair:fra-equ01/rack-1" -> "switch:leaf02";
+  "switch:leaf01" -> "port:leaf01/Ethernet0";
+  "switch:leaf01" -> "port:leaf01/Ethernet4";
+  "port:leaf01/Ethernet0" -> "machine:id1";
+  "switch:leaf02" -> "port:leaf02/Ethernet0";
+  "switch:leaf02" -> "port:leaf02/Ethernet4";
+  "switch:leaf02" -> "port:leaf02/Ethernet8";
+  "port:leaf02/Ethernet4" -> "machine:id1";
+  "port:leaf02/Ethernet8" -> "machine:id2";
+}
+`,
+			wantMermaid: `flowchart LR
+    n0["fra-equ01"]
+    n1["rack-1"]
+    n2["leaf01 + leaf02"]
+    n3["leaf01"]
+    n4["Ethernet0"]
+    n5["id1"]
+    n6["Ethernet4<br/>no machine connected; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE"]
+    n7["leaf02"]
+    n8["Ethernet0<br/>no machine connected"]
+    n9["Ethernet4<br/>mac is 52:54:00:ab:cd:ff but should be 52:54:00:ab:cd:02; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE"]
+    n10["Ethernet8<br/>not part of the switch nics"]
+    n11["worker-2<br/>id2"]
+    n0 --> n1
+    n1 --> n2
+    n2 --> n3
+    n2 --> n7
+    n3 --> n4
+    n3 --> n6
+    n4 --> n5
+    n7 --> n8
+    n7 --> n9
+    n7 --> n10
+    n9 --> n5
+    n10 --> n11
+    classDef issue stroke:#f00,color:#f00
+    class n6,n8,n9,n10 issue
+`,
+		},
+		{
+			name:     "single switch in rack",
+			switches: []*apiv2.Switch{testresources.Switch1()},
+			wantDOT: `digraph topology {
+  rankdir=LR;
+  "partition:fra-equ01" [label="fra-equ01", shape=folder];
+  "rack:fra-equ01/rack-1" [label="rack-1", shape=box3d];
+  "pair:fra-equ01/rack-1" [label="leaf01\nno second leaf switch found in the rack", shape=tab, color=red, fontcolor=red];
+  "switch:leaf01" [label="leaf01", shape=box];
+  "port:leaf01/Ethernet0" [label="Ethernet0", shape=ellipse];
+  "machine:id1" [label="id1", shape=component];
+  "port:leaf01/Ethernet4" [label="Ethernet4\nno machine connected; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE", shape=ellipse, color=red, fontcolor=red];
+  "partition:fra-equ01" -> "rack:fra-equ01/rack-1";
+  "rack:fra-equ01/rack-1" -> "pair:fra-equ01/rack-1";
+  "pair:fra-equ01/rack-1" -> "switch:leaf01";
+  "switch:leaf01" -> "port:leaf01/Ethernet0";
+  "switch:leaf01" -> "port:leaf01/Ethernet4";
+  "port:leaf01/Ethernet0" -> "machine:id1";
+}
+`,
+			wantMermaid: `flowchart LR
+    n0["fra-equ01"]
+    n1["rack-1"]
+    n2["leaf01<br/>no second leaf switch found in the rack"]
+    n3["leaf01"]
+    n4["Ethernet0"]
+    n5["id1"]
+    n6["Ethernet4<br/>no machine connected; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE"]
+    n0 --> n1
+    n1 --> n2
+    n2 --> n3
+    n3 --> n4
+    n3 --> n6
+    n4 --> n5
+    classDef issue stroke:#f00,color:#f00
+    class n2,n6 issue
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology := NewSwitchTopology(tt.switches, tt.switchesWithMachines)
+
+			if diff := cmp.Diff(tt.wantDOT, topology.DOT()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantMermaid, topology.Mermaid()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
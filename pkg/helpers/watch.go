@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type WatchEventType string
+
+const (
+	WatchEventAdded   WatchEventType = "added"
+	WatchEventChanged WatchEventType = "changed"
+	WatchEventRemoved WatchEventType = "removed"
+)
+
+// protoIDFields are the field names which identify an entity, checked in this order
+var protoIDFields = []protoreflect.Name{"uuid", "id", "login", "task_id", "name"}
+
+type WatchEvent[R proto.Message] struct {
+	Type   WatchEventType
+	ID     string
+	Object R
+}
+
+// ProtoID returns the identifier of the given proto message, which is the first set field out of uuid, id, login, task_id and name.
+func ProtoID(m proto.Message) string {
+	if m == nil {
+		return ""
+	}
+
+	msg := m.ProtoReflect()
+	if !msg.IsValid() {
+		return ""
+	}
+
+	fields := msg.Descriptor().Fields()
+	for _, name := range protoIDFields {
+		fd := fields.ByName(name)
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+			continue
+		}
+
+		if id := msg.Get(fd).String(); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// WatchDiff compares two consecutive results of a list by the ids of the entities.
+// added and changed events are returned in order of the current result, removed events in order of the previous result.
+func WatchDiff[R proto.Message](previous, current []R) []WatchEvent[R] {
+	var (
+		events  []WatchEvent[R]
+		prevIDs = map[string]R{}
+		currIDs = map[string]bool{}
+	)
+
+	for _, p := range previous {
+		prevIDs[ProtoID(p)] = p
+	}
+
+	for _, c := range current {
+		id := ProtoID(c)
+		currIDs[id] = true
+
+		p, ok := prevIDs[id]
+		switch {
+		case !ok:
+			events = append(events, WatchEvent[R]{Type: WatchEventAdded, ID: id, Object: c})
+		case !proto.Equal(p, c):
+			events = append(events, WatchEvent[R]{Type: WatchEventChanged, ID: id, Object: c})
+		}
+	}
+
+	for _, p := range previous {
+		id := ProtoID(p)
+		if !currIDs[id] {
+			events = append(events, WatchEvent[R]{Type: WatchEventRemoved, ID: id, Object: p})
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func Test_ProtoID(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  proto.Message
+		want string
+	}{
+		{
+			name: "uuid",
+			msg:  &apiv2.Machine{Uuid: "m1"},
+			want: "m1",
+		},
+		{
+			name: "id",
+			msg:  &apiv2.Switch{Id: "leaf01"},
+			want: "leaf01",
+		},
+		{
+			name: "login",
+			msg:  &apiv2.Tenant{Login: "tenant-a"},
+			want: "tenant-a",
+		},
+		{
+			name: "nil",
+			msg:  (*apiv2.Machine)(nil),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ProtoID(tt.msg)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_WatchDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []*apiv2.Switch
+		current  []*apiv2.Switch
+		want     []WatchEvent[*apiv2.Switch]
+	}{
+		{
+			name:    "initial result",
+			current: []*apiv2.Switch{{Id: "leaf01"}, {Id: "leaf02"}},
+			want: []WatchEvent[*apiv2.Switch]{
+				{Type: WatchEventAdded, ID: "leaf01", Object: &apiv2.Switch{Id: "leaf01"}},
+				{Type: WatchEventAdded, ID: "leaf02", Object: &apiv2.Switch{Id: "leaf02"}},
+			},
+		},
+		{
+			name:     "no changes",
+			previous: []*apiv2.Switch{{Id: "leaf01", Partition: "a"}},
+			current:  []*apiv2.Switch{{Id: "leaf01", Partition: "a"}},
+			want:     nil,
+		},
+		{
+			name:     "added, changed and removed",
+			previous: []*apiv2.Switch{{Id: "leaf01", Partition: "a"}, {Id: "leaf02"}},
+			current:  []*apiv2.Switch{{Id: "leaf01", Partition: "b"}, {Id: "leaf03"}},
+			want: []WatchEvent[*apiv2.Switch]{
+				{Type: WatchEventChanged, ID: "leaf01", Object: &apiv2.Switch{Id: "leaf01", Partition: "b"}},
+				{Type: WatchEventAdded, ID: "leaf03", Object: &apiv2.Switch{Id: "leaf03"}},
+				{Type: WatchEventRemoved, ID: "leaf02", Object: &apiv2.Switch{Id: "leaf02"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WatchDiff(tt.previous, tt.current)
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// SplitYAMLDocuments splits a multi-document yaml into its documents, empty documents are skipped.
+func SplitYAMLDocuments(r io.Reader) ([][]byte, error) {
+	var (
+		docs   [][]byte
+		reader = utilyaml.NewYAMLReader(bufio.NewReader(r))
+	)
+
+	for {
+		raw, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, fmt.Errorf("decode error: %w", err)
+		}
+
+		if strings.TrimSpace(string(raw)) == "" {
+			continue
+		}
+
+		docs = append(docs, raw)
+	}
+}
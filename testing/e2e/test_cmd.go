@@ -67,6 +67,9 @@ func NewRootCmd(t *testing.T, c *TestConfig) e2e_test.NewRootCmdFunc {
 				Client: cl,
 			},
 			Client: cl,
+			NewClient: func(string, string) (client.Client, error) {
+				return cl, nil
+			},
 		}), &out
 	}
 }
@@ -0,0 +1,102 @@
+package admin_e2e
+
+import (
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/api/go/client"
+	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
+	helpersaudit "github.com/metal-stack/cli/pkg/helpers/audit"
+	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func Test_AdminAccessCmd_History(t *testing.T) {
+	var (
+		user = testresources.User().Login
+
+		listTraces = func(method string, traces ...*apiv2.AuditTrace) client.ClientCall {
+			return client.ClientCall{
+				WantRequest: &adminv2.AuditServiceListRequest{
+					Query: &apiv2.AuditQuery{
+						From:   timestamppb.New(e2e.TimeBubbleStartTime().Add(-24 * time.Hour)),
+						To:     timestamppb.New(e2e.TimeBubbleStartTime()),
+						User:   &user,
+						Method: &method,
+						Phase:  new(apiv2.AuditPhase_AUDIT_PHASE_REQUEST),
+						Limit:  new(helpersaudit.ListLimit),
+					},
+				},
+				WantResponse: func() connect.AnyResponse {
+					return connect.NewResponse(&adminv2.AuditServiceListResponse{
+						Traces: traces,
+					})
+				},
+			}
+		}
+	)
+
+	tests := []*e2e.Test[any, []*helpers.AccessHistoryEntry]{
+		{
+			Name:    "history",
+			CmdArgs: []string{"admin", "access", "history", "--config", "/config.yaml"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(fs *afero.Afero) {
+					require.NoError(t, fs.WriteFile("/config.yaml", []byte(`current-context: test
+contexts:
+- name: test
+  api-token: token
+`), 0600))
+					require.NoError(t, fs.WriteFile("/access-history.jsonl", []byte(`{"time":"1999-12-30T12:00:00Z","method":"/metalstack.admin.v2.MachineService/ConsolePassword","target":"m0","reason":"too old","context":"test","user":"larry@metal-stack.io@openid-connect"}
+{"time":"1999-12-31T22:00:00Z","method":"/metalstack.admin.v2.VPNService/AuthKey","target":"p1","reason":"[OPS-2] firewall debugging","context":"test","user":"larry@metal-stack.io@openid-connect","transcript":"/transcripts/ssh-fw1-19991231T220000Z.cast.gz"}
+{"time":"1999-12-31T22:30:00Z","method":"/metalstack.admin.v2.VPNService/AuthKey","target":"p3","reason":"other context","context":"other","user":"larry@metal-stack.io@openid-connect"}
+{"time":"1999-12-31T22:45:00Z","method":"/metalstack.admin.v2.MachineService/ConsolePassword","target":"m2","reason":"other user","context":"test","user":"harry@metal-stack.io@openid-connect"}
+{"time":"1999-12-31T23:00:00Z","method":"/metalstack.admin.v2.MachineService/ConsolePassword","target":"m1","reason":"[OPS-1] boot issue","context":"test","user":"larry@metal-stack.io@openid-connect"}
+`), 0600))
+				},
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.UserServiceGetRequest{},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.UserServiceGetResponse{
+								User: testresources.User(),
+							})
+						},
+					},
+					listTraces(adminv2connect.MachineServiceConsolePasswordProcedure, &apiv2.AuditTrace{
+						Uuid:      "c6b5a3e2-0e5c-4d1c-8a55-0f2b3d4e5f60",
+						Timestamp: timestamppb.New(e2e.TimeBubbleStartTime().Add(-time.Hour + 5*time.Second)),
+						User:      user,
+						Method:    adminv2connect.MachineServiceConsolePasswordProcedure,
+						Phase:     apiv2.AuditPhase_AUDIT_PHASE_REQUEST,
+					}),
+					listTraces(adminv2connect.VPNServiceAuthKeyProcedure, &apiv2.AuditTrace{
+						Uuid:      "5f0e7a8b-3c2d-4e1f-9a8b-7c6d5e4f3a2b",
+						Timestamp: timestamppb.New(e2e.TimeBubbleStartTime().Add(-3 * time.Hour)),
+						User:      user,
+						Project:   new("p2"),
+						Method:    adminv2connect.VPNServiceAuthKeyProcedure,
+						Phase:     apiv2.AuditPhase_AUDIT_PHASE_REQUEST,
+					}),
+				},
+			}),
+			WantTable: new(`
+            TIME                 REQUEST ID                            METHOD                                               TARGET  REASON                      RECORDED
+            1999-12-31 21:00:00  5f0e7a8b-3c2d-4e1f-9a8b-7c6d5e4f3a2b  /metalstack.admin.v2.VPNService/AuthKey              p2                                  audit
+            1999-12-31 22:00:00                                        /metalstack.admin.v2.VPNService/AuthKey              p1      [OPS-2] firewall debugging  local
+            1999-12-31 23:00:05  c6b5a3e2-0e5c-4d1c-8a55-0f2b3d4e5f60  /metalstack.admin.v2.MachineService/ConsolePassword  m1      [OPS-1] boot issue          local+audit
+			`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
@@ -1,22 +1,26 @@
 package admin_e2e
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/metal-stack/api/go/client"
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
 	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
 	"github.com/metal-stack/cli/tests/e2e/testresources"
 	"github.com/metal-stack/metal-lib/pkg/genericcli"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func Test_MachineCmd_List(t *testing.T) {
@@ -451,6 +455,39 @@ func Test_MachineCmd_Delete(t *testing.T) {
             673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  🛡  Phoned Home  1m    1m   machine-2  f3b4e6a1-2c8d-4e5f-a7b9-1d3e5f7a9b0c  v1-medium-x86  Ubuntu 24.04  partition-2  rack-1
 			`),
 		},
+		{
+			Name:    "delete many",
+			CmdArgs: []string{"admin", "machine", "delete", testresources.Machine2().Uuid, testresources.Machine1().Uuid, "--skip-security-prompts", "--concurrency", "1"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceDeleteRequest{
+							Uuid: testresources.Machine1().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceDeleteResponse{
+								Machine: testresources.Machine1(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceDeleteRequest{
+							Uuid: testresources.Machine2().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceDeleteResponse{
+								Machine: testresources.Machine2(),
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            ID                                     OPERATION  RESULT  DURATION  ERROR
+            5fa2bbe1-407c-4142-92d5-e4419daf9646   delete     ✔       0s
+            673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  delete     ✔       0s
+			`),
+		},
 	}
 	for _, tt := range tests {
 		tt.TestCmd(t)
@@ -900,6 +937,408 @@ func Test_MachineCmd_BmcCommand(t *testing.T) {
 			}),
 			WantDefault: new(``),
 		},
+		{
+			Name:    "bmc command with wait",
+			CmdArgs: []string{"admin", "machine", "bmc", "command", testresources.Machine1().Uuid, "--command", "MACHINE_BMC_COMMAND_ON", "--wait", "--wait-interval", "5s"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceBMCCommandRequest{
+							Uuid:    testresources.Machine1().Uuid,
+							Command: apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_ON,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceBMCCommandResponse{})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceGetBMCRequest{
+							Uuid: testresources.Machine1().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceGetBMCResponse{
+								BmcDetails: testresources.Machine1BmcDetails,
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceGetBMCRequest{
+							Uuid: testresources.Machine1().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceGetBMCResponse{
+								BmcDetails: &apiv2.MachineBMCDetails{
+									Uuid: testresources.Machine1().Uuid,
+									BmcReport: &apiv2.MachineBMCReport{
+										Bmc: &apiv2.MachineBMC{
+											PowerState: "on",
+										},
+										UpdatedAt: timestamppb.New(e2e.TimeBubbleStartTime().Add(5 * time.Second)),
+									},
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(``),
+		},
+		{
+			Name:       "wait for unsupported bmc command",
+			CmdArgs:    []string{"admin", "machine", "bmc", "command", testresources.Machine1().Uuid, "--command", "MACHINE_BMC_COMMAND_BOOT_FROM_PXE", "--wait"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{}),
+			WantErr:    fmt.Errorf("waiting is not supported for bmc command MACHINE_BMC_COMMAND_BOOT_FROM_PXE"),
+		},
+		{
+			Name:    "bmc command from stdin",
+			CmdArgs: []string{"admin", "machine", "bmc", "command", "-", "--command", "MACHINE_BMC_COMMAND_ON", "--skip-security-prompts", "--concurrency", "1"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				MockStdin: bytes.NewBufferString(testresources.Machine2().Uuid + "\n# comment\n\n" + testresources.Machine1().Uuid + "\n"),
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceBMCCommandRequest{
+							Uuid:    testresources.Machine1().Uuid,
+							Command: apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_ON,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceBMCCommandResponse{})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceBMCCommandRequest{
+							Uuid:    testresources.Machine2().Uuid,
+							Command: apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_ON,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceBMCCommandResponse{})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            ID                                     OPERATION               RESULT  DURATION  ERROR
+            5fa2bbe1-407c-4142-92d5-e4419daf9646   MACHINE_BMC_COMMAND_ON  ✔       0s
+            673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  MACHINE_BMC_COMMAND_ON  ✔       0s
+			`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_MachineCmd_Lock(t *testing.T) {
+	tests := []*e2e.Test[adminv2.MachineServiceSetStateResponse, *apiv2.Machine]{
+		{
+			Name:    "lock many",
+			CmdArgs: []string{"admin", "machine", "lock", testresources.Machine2().Uuid, testresources.Machine1().Uuid, "--description", "maintenance", "--skip-security-prompts", "--concurrency", "1"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceSetStateRequest{
+							Uuid:        testresources.Machine1().Uuid,
+							Description: "maintenance",
+							State:       apiv2.MachineState_MACHINE_STATE_LOCKED,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceSetStateResponse{
+								Machine: testresources.Machine1(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceSetStateRequest{
+							Uuid:        testresources.Machine2().Uuid,
+							Description: "maintenance",
+							State:       apiv2.MachineState_MACHINE_STATE_LOCKED,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceSetStateResponse{
+								Machine: testresources.Machine2(),
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            ID                                     OPERATION  RESULT  DURATION  ERROR
+            5fa2bbe1-407c-4142-92d5-e4419daf9646   lock       ✔       0s
+            673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  lock       ✔       0s
+			`),
+		},
+		{
+			Name:       "lock without selection",
+			CmdArgs:    []string{"admin", "machine", "lock"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{}),
+			WantErr:    fmt.Errorf("either provide machine ids, - for reading ids from stdin or select machines with the query flags"),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_MachineCmd_Taint(t *testing.T) {
+	tests := []*e2e.Test[adminv2.MachineServiceSetStateResponse, *apiv2.Machine]{
+		{
+			Name:    "untaint selected by query",
+			CmdArgs: []string{"admin", "machine", "taint", "--remove", "--partition", "partition-1", "--skip-security-prompts"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceListRequest{
+							Query: &apiv2.MachineQuery{
+								Partition: new("partition-1"),
+							},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{testresources.Machine1()},
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceSetStateRequest{
+							Uuid:  testresources.Machine1().Uuid,
+							State: apiv2.MachineState_MACHINE_STATE_AVAILABLE,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceSetStateResponse{
+								Machine: testresources.Machine1(),
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            ID                                    OPERATION  RESULT  DURATION  ERROR
+            5fa2bbe1-407c-4142-92d5-e4419daf9646  untaint    ✔       0s
+			`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_MachineCmd_Reprovision(t *testing.T) {
+	tests := []*e2e.Test[adminv2.MachineServiceGetResponse, *apiv2.Machine]{
+		{
+			Name:    "reprovision",
+			CmdArgs: []string{"admin", "machine", "reprovision", testresources.Machine1().Uuid, "--skip-security-prompts", "--wait-interval", "5s"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceBMCCommandRequest{
+							Uuid:    testresources.Machine1().Uuid,
+							Command: apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_BOOT_FROM_PXE,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceBMCCommandResponse{})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceBMCCommandRequest{
+							Uuid:    testresources.Machine1().Uuid,
+							Command: apiv2.MachineBMCCommand_MACHINE_BMC_COMMAND_CYCLE,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceBMCCommandResponse{})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceGetRequest{
+							Uuid: testresources.Machine1().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceGetResponse{
+								Machine: testresources.Machine1(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.MachineServiceGetRequest{
+							Uuid: testresources.Machine1().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							m := testresources.Machine1()
+							m.RecentProvisioningEvents.Events = []*apiv2.MachineProvisioningEvent{
+								{
+									Time:    timestamppb.New(e2e.TimeBubbleStartTime().Add(4 * time.Second)),
+									Event:   apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING,
+									Message: "waiting",
+								},
+								{
+									Time:    timestamppb.New(e2e.TimeBubbleStartTime().Add(3 * time.Second)),
+									Event:   apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_ALIVE,
+									Message: "alive",
+								},
+							}
+
+							return connect.NewResponse(&adminv2.MachineServiceGetResponse{
+								Machine: m,
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`
+✔ sent MACHINE_BMC_COMMAND_BOOT_FROM_PXE to machine "5fa2bbe1-407c-4142-92d5-e4419daf9646"
+✔ sent MACHINE_BMC_COMMAND_CYCLE to machine "5fa2bbe1-407c-4142-92d5-e4419daf9646"
+2000-01-01T00:00:03Z  Alive         alive
+2000-01-01T00:00:04Z  Waiting       waiting
+✔ machine "5fa2bbe1-407c-4142-92d5-e4419daf9646" was reprovisioned
+`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_MachineCmd_Events(t *testing.T) {
+	tests := []*e2e.Test[adminv2.MachineServiceGetResponse, *apiv2.MachineRecentProvisioningEvents]{
+		{
+			Name:    "events",
+			CmdArgs: []string{"admin", "machine", "events", testresources.Machine2().Uuid},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceGetRequest{
+							Uuid: testresources.Machine2().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceGetResponse{
+								Machine: testresources.Machine2(),
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+                TIME                  EVENT        DURATION  MESSAGE
+                1999-12-31T23:58:00Z  Alive        1m        alive
+                1999-12-31T23:59:00Z  Phoned Home  1m        phoned home
+			`),
+		},
+		{
+			Name:    "events of a machine in a crash loop",
+			CmdArgs: []string{"admin", "machine", "logs", testresources.Machine1().Uuid},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceGetRequest{
+							Uuid: testresources.Machine1().Uuid,
+						},
+						WantResponse: func() connect.AnyResponse {
+							m := testresources.Machine1()
+							m.RecentProvisioningEvents.State = apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_CRASHLOOP
+							m.RecentProvisioningEvents.LastErrorEvent = &apiv2.MachineProvisioningEvent{
+								Time:    timestamppb.New(e2e.TimeBubbleStartTime().Add(-45 * time.Minute)),
+								Event:   apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING,
+								Message: "waiting",
+							}
+							m.RecentProvisioningEvents.Events = append(m.RecentProvisioningEvents.Events, &apiv2.MachineProvisioningEvent{
+								Time:    timestamppb.New(e2e.TimeBubbleStartTime().Add(-30 * time.Minute)),
+								Event:   apiv2.MachineProvisioningEventType_MACHINE_PROVISIONING_EVENT_TYPE_WAITING,
+								Message: "waiting",
+							}, m.RecentProvisioningEvents.LastErrorEvent)
+
+							return connect.NewResponse(&adminv2.MachineServiceGetResponse{
+								Machine: m,
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+                TIME                  EVENT    DURATION  MESSAGE
+            ❗  1999-12-31T23:15:00Z  Waiting  15m       waiting
+                1999-12-31T23:30:00Z  Waiting  29m       waiting
+            ⭕  1999-12-31T23:59:00Z  Alive    1m        alive
+			`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_MachineCmd_Issues(t *testing.T) {
+	tests := []*e2e.Test[adminv2.MachineServiceListResponse, []*helpers.MachineIssue]{
+		{
+			Name:    "issues",
+			CmdArgs: []string{"admin", "machine", "issues", "--only", "last-event-error,crashloop", "--last-event-error-threshold", "2h"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceListRequest{
+							Query: &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							m := testresources.Machine1()
+							m.RecentProvisioningEvents.State = apiv2.MachineProvisioningEventState_MACHINE_PROVISIONING_EVENT_STATE_CRASHLOOP
+
+							return connect.NewResponse(&adminv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{m, testresources.Machine2()},
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            SEVERITY  ISSUE             ID                                     PARTITION    RACK    DETAILS
+            major     crashloop         5fa2bbe1-407c-4142-92d5-e4419daf9646   partition-1  rack-1
+            minor     last-event-error  5fa2bbe1-407c-4142-92d5-e4419daf9646   partition-1  rack-1  occurred at 1999-12-31T23:00:00Z: waiting
+                                        673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  partition-2  rack-1  occurred at 1999-12-31T23:00:00Z: waiting
+			`),
+		},
+		{
+			Name:    "switch connection issues",
+			CmdArgs: []string{"admin", "machine", "issues", "--only", "no-switch-connection,switch-port-down", "--partition", "partition-1"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.MachineServiceListRequest{
+							Query: &apiv2.MachineQuery{
+								Partition: new("partition-1"),
+							},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{testresources.Machine1(), testresources.Machine2()},
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.SwitchServiceListRequest{
+							Query: &apiv2.SwitchQuery{
+								Partition: new("partition-1"),
+							},
+						},
+						WantResponse: func() connect.AnyResponse {
+							sw := testresources.Switch1()
+							sw.MachineConnections = []*apiv2.MachineConnection{{MachineId: testresources.Machine1().Uuid, Nic: testresources.Nic2()}}
+
+							return connect.NewResponse(&adminv2.SwitchServiceListResponse{
+								Switches: []*apiv2.Switch{sw},
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            SEVERITY  ISSUE                 ID                                     PARTITION    RACK    DETAILS
+            major     no-switch-connection  673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  partition-2  rack-1
+            major     switch-port-down      5fa2bbe1-407c-4142-92d5-e4419daf9646   partition-1  rack-1  leaf01/Ethernet4 is SWITCH_PORT_STATUS_DOWN
+			`),
+		},
+		{
+			Name:       "unknown issue type",
+			CmdArgs:    []string{"admin", "machine", "issues", "--omit", "foo"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{}),
+			WantErr:    fmt.Errorf(`unknown issue type "foo", possible values: no-partition|liveliness-dead|liveliness-unknown|failed-machine-reclaim|crashloop|last-event-error|bmc-without-mac|bmc-without-ip|bmc-info-outdated|bmc-no-distinct-ip|power-supply-failure|asn-not-unique|no-switch-connection|single-switch-connection|switch-port-down`),
+		},
 	}
 	for _, tt := range tests {
 		tt.TestCmd(t)
@@ -1,16 +1,24 @@
 package admin_e2e
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/metal-stack/api/go/client"
 
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
+	"github.com/metal-stack/api/go/metalstack/admin/v2/adminv2connect"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/api/go/metalstack/api/v2/apiv2connect"
+	helpersaudit "github.com/metal-stack/cli/pkg/helpers/audit"
 	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
 	"github.com/metal-stack/cli/tests/e2e/testresources"
 	e2e "github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func Test_AdminPartitionCmd_List(t *testing.T) {
@@ -188,3 +196,140 @@ partition-1 size-1
 		tt.TestCmd(t)
 	}
 }
+
+func Test_AdminPartitionCmd_CapacityForecast(t *testing.T) {
+	var (
+		machineBody = func(size string) *string {
+			return new(fmt.Sprintf(`{"machine":{"uuid":"m","size":{"id":%q},"partition":{"id":"partition-1"}}}`, size))
+		}
+
+		clientCalls = func() []client.ClientCall {
+			listTraces := func(method string, traces ...*apiv2.AuditTrace) client.ClientCall {
+				return client.ClientCall{
+					WantRequest: &adminv2.AuditServiceListRequest{
+						Query: &apiv2.AuditQuery{
+							From:   timestamppb.New(e2e.TimeBubbleStartTime().Add(-10 * 24 * time.Hour)),
+							To:     timestamppb.New(e2e.TimeBubbleStartTime()),
+							Method: &method,
+							Phase:  new(apiv2.AuditPhase_AUDIT_PHASE_RESPONSE),
+							Limit:  new(helpersaudit.ListLimit),
+						},
+					},
+					WantResponse: func() connect.AnyResponse {
+						return connect.NewResponse(&adminv2.AuditServiceListResponse{
+							Traces: traces,
+						})
+					},
+				}
+			}
+
+			return []client.ClientCall{
+				{
+					WantRequest: &adminv2.PartitionServiceCapacityRequest{},
+					WantResponse: func() connect.AnyResponse {
+						return connect.NewResponse(&adminv2.PartitionServiceCapacityResponse{
+							PartitionCapacity: []*adminv2.PartitionCapacity{
+								{
+									Partition: "partition-1",
+									MachineSizeCapacities: []*adminv2.MachineSizeCapacity{
+										{Size: "size-2", Free: 10, Total: 12},
+										{Size: "size-1", Free: 3, Total: 8},
+									},
+								},
+							},
+						})
+					},
+				},
+				listTraces(apiv2connect.MachineServiceCreateProcedure,
+					&apiv2.AuditTrace{Method: apiv2connect.MachineServiceCreateProcedure, Body: machineBody("size-1")},
+					&apiv2.AuditTrace{Method: apiv2connect.MachineServiceCreateProcedure, Body: machineBody("size-1")},
+					&apiv2.AuditTrace{Method: apiv2connect.MachineServiceCreateProcedure, Body: machineBody("size-1")},
+				),
+				listTraces(apiv2connect.MachineServiceDeleteProcedure),
+				listTraces(adminv2connect.MachineServiceDeleteProcedure,
+					&apiv2.AuditTrace{Method: adminv2connect.MachineServiceDeleteProcedure, Body: machineBody("size-1")},
+				),
+			}
+		}
+
+		fsMocks = func(fs *afero.Afero) {
+			require.NoError(t, fs.WriteFile("/config.yaml", []byte(`current-context: test
+contexts:
+- name: test
+  api-token: token
+  capacity-thresholds:
+    min-free:
+      "*": 5
+`), 0600))
+		}
+	)
+
+	tests := []*e2e.Test[any, any]{
+		{
+			Name:    "forecast",
+			CmdArgs: []string{"admin", "partition", "capacity", "--forecast", "--forecast-window", "240h", "--config", "/config.yaml"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks:     fsMocks,
+				ClientCalls: clientCalls(),
+			}),
+			WantTable: new(`
+			PARTITION    SIZE    FREE  ALLOCATED  RELEASED  NET/DAY  RUNS OUT  STATUS
+			partition-1  size-1  3     3          1         0.20     in 15d    less than 5 free
+			partition-1  size-2  10    0          0         0.00     never     ok
+			`),
+			WantWideTable: new(`
+			PARTITION    SIZE    FREE  ALLOCATED  RELEASED  NET/DAY  RUNS OUT  EXHAUSTED AT         STATUS
+			partition-1  size-1  3     3          1         0.20     in 15d    2000-01-16 00:00:00  less than 5 free
+			partition-1  size-2  10    0          0         0.00     never                          ok
+			`),
+		},
+		{
+			Name:    "forecast as csv",
+			CmdArgs: []string{"admin", "partition", "capacity", "--forecast", "--forecast-window", "240h", "--config", "/config.yaml", "-o", "csv"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks:     fsMocks,
+				ClientCalls: clientCalls(),
+			}),
+			WantDefault: new(`
+Partition,Size,Free,Allocated,Released,Net/Day,Runs Out,Exhausted At,Status
+partition-1,size-1,3,3,1,0.20,in 15d,2000-01-16 00:00:00,less than 5 free
+partition-1,size-2,10,0,0,0.00,never,,ok
+			`),
+		},
+		{
+			Name:    "forecast as json",
+			CmdArgs: []string{"admin", "partition", "capacity", "--forecast", "--forecast-window", "240h", "--config", "/config.yaml", "-o", "json"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks:     fsMocks,
+				ClientCalls: clientCalls(),
+			}),
+			WantDefault: new(`
+[
+    {
+        "partition": "partition-1",
+        "size": "size-1",
+        "free": 3,
+        "allocations": 3,
+        "releases": 1,
+        "net_per_day": 0.2,
+        "exhausted_at": "2000-01-16T00:00:00Z",
+        "warnings": [
+            "less than 5 free"
+        ]
+    },
+    {
+        "partition": "partition-1",
+        "size": "size-2",
+        "free": 10,
+        "allocations": 0,
+        "releases": 0,
+        "net_per_day": 0
+    }
+]
+			`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
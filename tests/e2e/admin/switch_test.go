@@ -1,17 +1,22 @@
 package admin_e2e
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/metal-stack/api/go/client"
 	adminv2 "github.com/metal-stack/api/go/metalstack/admin/v2"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/pkg/helpers"
 	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
 	"github.com/metal-stack/cli/tests/e2e/testresources"
 	e2e "github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func Test_AdminSwitchCmd_Describe(t *testing.T) {
@@ -266,10 +271,75 @@ func Test_AdminSwitchCmd_Detail(t *testing.T) {
 func Test_AdminSwitchCmd_Migrate(t *testing.T) {
 	tests := []*e2e.Test[adminv2.SwitchServiceMigrateResponse, *apiv2.Switch]{
 		{
-			Name:    "describe",
-			CmdArgs: []string{"admin", "switch", "migrate", testresources.Switch1().Id, testresources.Switch2().Id},
+			Name:    "migrate",
+			CmdArgs: []string{"admin", "switch", "migrate", testresources.Switch1().Id, testresources.Switch2().Id, "--skip-security-prompts"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: testresources.Switch1(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.SwitchServiceMigrateRequest{
+							OldSwitch: testresources.Switch1().Id,
+							NewSwitch: testresources.Switch2().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceMigrateResponse{
+								Switch: testresources.Switch2(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch2().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: migratedSwitch(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch2().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: migratedSwitch(),
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`
+✔ stored snapshot of 2 port(s) of switch "leaf01" in switch-leaf01-snapshot.yaml
+✔ migrated switch "leaf01" to "leaf02"
+waiting for switch "leaf02" to sync, use --resume to continue if this gets interrupted
+✔ all machines of switch "leaf01" are still connected
+`),
+		},
+		{
+			Name:    "migrate without waiting",
+			CmdArgs: []string{"admin", "switch", "migrate", testresources.Switch1().Id, testresources.Switch2().Id, "--skip-security-prompts", "--no-wait"},
 			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
 				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: testresources.Switch1(),
+							})
+						},
+					},
 					{
 						WantRequest: &adminv2.SwitchServiceMigrateRequest{
 							OldSwitch: testresources.Switch1().Id,
@@ -281,34 +351,104 @@ func Test_AdminSwitchCmd_Migrate(t *testing.T) {
 							})
 						},
 					},
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch2().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: testresources.Switch2(),
+							})
+						},
+					},
 				},
 			}),
 			WantDefault: new(`
-switch:
-    id: leaf02
-    meta:
-        createdAt: "2000-01-01T00:00:00Z"
-    description: leaf switch 2
-    rack: rack-1
-    room: room-2
-    partition: fra-equ01
-    managementIp: 10.0.0.2
-    managementUser: admin
-    os:
-        vendor: SWITCH_OS_VENDOR_SONIC
-        version: 4.2.0
-        metalCoreVersion: v0.9.1 (abc1234), tags/v0.9.1
-    lastSync:
-        time: "2000-01-01T00:00:00Z"
-        duration: 0.200s
+✔ stored snapshot of 2 port(s) of switch "leaf01" in switch-leaf01-snapshot.yaml
+✔ migrated switch "leaf01" to "leaf02"
+switch "leaf02" has not synced yet, use --resume to wait for it and compare its ports against the snapshot
 `),
 		},
+		{
+			Name:    "migrate with existing snapshot",
+			CmdArgs: []string{"admin", "switch", "migrate", testresources.Switch1().Id, testresources.Switch2().Id},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(fs *afero.Afero) {
+					require.NoError(t, fs.WriteFile("switch-leaf01-snapshot.yaml", []byte("operation: migrate"), 0600))
+				},
+			}),
+			WantErr: fmt.Errorf("snapshot switch-leaf01-snapshot.yaml already exists, use --resume to continue the operation or remove the file to start over"),
+		},
 	}
 	for _, tt := range tests {
 		tt.TestCmd(t)
 	}
 }
 
+func Test_AdminSwitchCmd_Replace(t *testing.T) {
+	tests := []*e2e.Test[adminv2.SwitchServiceGetResponse, *apiv2.Switch]{
+		{
+			Name:    "resume replace with lost machine",
+			CmdArgs: []string{"admin", "switch", "replace", testresources.Switch1().Id, "--resume"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(fs *afero.Afero) {
+					require.NoError(t, fs.WriteFile("switch-leaf01-snapshot.yaml", []byte(`
+operation: replace
+switch_id: leaf01
+phase: replace-mode
+phase_changed_at: "1999-12-31T23:00:00Z"
+taken_at: "1999-12-31T22:59:00Z"
+ports:
+- name: Ethernet0
+  vrf: default
+  state: up
+  machine_id: id1
+- name: Ethernet4
+  state: up
+  machine_id: id2
+`), 0600))
+				},
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: testresources.Switch1(),
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: testresources.Switch1(),
+							})
+						},
+					},
+				},
+			}),
+			WantErr: fmt.Errorf("1 machine(s) lost connectivity: id2, the snapshot is kept in switch-leaf01-snapshot.yaml"),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+// migratedSwitch returns the second switch after the connections of the first switch were migrated to it and it has synced.
+func migratedSwitch() *apiv2.Switch {
+	sw := testresources.Switch2()
+	sw.Nics = []*apiv2.SwitchNic{testresources.Nic1(), testresources.Nic2()}
+	sw.MachineConnections = []*apiv2.MachineConnection{{MachineId: "id1", Nic: testresources.Nic1()}}
+	sw.LastSync.Time = timestamppb.New(e2e.TimeBubbleStartTime().Add(time.Minute))
+
+	return sw
+}
+
 func Test_AdminSwitchCmd_Port(t *testing.T) {
 	tests := []*e2e.Test[adminv2.SwitchServicePortResponse, *apiv2.Switch]{
 		{
@@ -466,3 +606,319 @@ Desired:
 		tt.TestCmd(t)
 	}
 }
+
+func Test_AdminSwitchCmd_PortBulk(t *testing.T) {
+	getSwitch := func(sw func() *apiv2.Switch) client.ClientCall {
+		s := sw()
+		return client.ClientCall{
+			WantRequest: &adminv2.SwitchServiceGetRequest{
+				Id: s.Id,
+			},
+			WantResponse: func() connect.AnyResponse {
+				return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+					Switch: s,
+				})
+			},
+		}
+	}
+	setPort := func(sw func() *apiv2.Switch, port string, status apiv2.SwitchPortStatus) client.ClientCall {
+		return client.ClientCall{
+			WantRequest: &adminv2.SwitchServicePortRequest{
+				Id:      sw().Id,
+				NicName: port,
+				Status:  status,
+			},
+			WantResponse: func() connect.AnyResponse {
+				return connect.NewResponse(&adminv2.SwitchServicePortResponse{
+					Switch: sw(),
+				})
+			},
+		}
+	}
+	portsDown := func() *apiv2.Switch {
+		sw := testresources.Switch1()
+		sw.MachineConnections[0].Nic.State.Actual = apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN
+		return sw
+	}
+	leaf02 := func() *apiv2.Switch {
+		sw := testresources.Switch2()
+		sw.MachineConnections = []*apiv2.MachineConnection{{MachineId: "id1", Nic: testresources.Nic1()}}
+		return sw
+	}
+
+	tests := []*e2e.Test[adminv2.SwitchServicePortResponse, *apiv2.Switch]{
+		{
+			Name:    "down by pattern",
+			CmdArgs: []string{"admin", "switch", "port", "down", testresources.Switch1().Id, "--port", "Ethernet*", "--skip-security-prompts"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					getSwitch(testresources.Switch1),
+					setPort(testresources.Switch1, testresources.Nic1().Name, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN),
+					getSwitch(portsDown),
+					setPort(testresources.Switch1, testresources.Nic2().Name, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_DOWN),
+					getSwitch(portsDown),
+				},
+			}),
+			WantTable: new(`
+            ID                OPERATION  RESULT  DURATION  ERROR
+            leaf01/Ethernet0  down       ✔       0s
+            leaf01/Ethernet4  down       ✔       0s
+            `),
+		},
+		{
+			Name:    "up by machine on all switches",
+			CmdArgs: []string{"admin", "switch", "port", "up", "--machine", "id1", "--skip-security-prompts"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceListRequest{
+							Query: &apiv2.SwitchQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceListResponse{
+								Switches: []*apiv2.Switch{testresources.Switch1(), leaf02()},
+							})
+						},
+					},
+					setPort(testresources.Switch1, testresources.Nic1().Name, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_UP),
+					getSwitch(testresources.Switch1),
+					setPort(leaf02, testresources.Nic1().Name, apiv2.SwitchPortStatus_SWITCH_PORT_STATUS_UP),
+					getSwitch(leaf02),
+				},
+			}),
+			WantTable: new(`
+            ID                OPERATION  RESULT  DURATION  ERROR
+            leaf01/Ethernet0  up         ✔       0s
+            leaf02/Ethernet0  up         ✔       0s
+            `),
+		},
+		{
+			Name:    "no matching ports",
+			CmdArgs: []string{"admin", "switch", "port", "down", testresources.Switch1().Id, "--port", "swp1s*", "--skip-security-prompts"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					getSwitch(testresources.Switch1),
+				},
+			}),
+			WantErr: fmt.Errorf("no switch ports matched the selection"),
+		},
+		{
+			Name:       "pattern without switch",
+			CmdArgs:    []string{"admin", "switch", "port", "down", "--port", "swp1s*"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{}),
+			WantErr:    fmt.Errorf("a switch id is required when selecting ports by pattern"),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_AdminSwitchCmd_PortList(t *testing.T) {
+	tests := []*e2e.Test[adminv2.SwitchServiceGetResponse, []*helpers.SwitchPort]{
+		{
+			Name:    "list",
+			CmdArgs: []string{"admin", "switch", "port", "list", testresources.Switch1().Id},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							sw := testresources.Switch1()
+							sw.Nics[1].BgpPortState = nil
+
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: sw,
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            PORT       DESIRED  ACTUAL  BGP STATE         MACHINE
+            Ethernet0  up       up      Established (2h)  id1
+            Ethernet4  up       down
+            `),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_AdminSwitchCmd_Topology(t *testing.T) {
+	tests := []*e2e.Test[adminv2.SwitchServiceListResponse, *apiv2.Switch]{
+		{
+			Name:    "topology as mermaid",
+			CmdArgs: []string{"admin", "switch", "topology", "--partition", "fra-equ01", "--format", "mermaid"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceListRequest{
+							Query: &apiv2.SwitchQuery{
+								Partition: new("fra-equ01"),
+							},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceListResponse{
+								Switches: []*apiv2.Switch{testresources.Switch1()},
+							})
+						},
+					},
+					{
+						WantRequest: &adminv2.SwitchServiceConnectedMachinesRequest{
+							Query: &apiv2.SwitchQuery{
+								Partition: new("fra-equ01"),
+							},
+							MachineQuery: &apiv2.MachineQuery{
+								Partition: new("fra-equ01"),
+							},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceConnectedMachinesResponse{
+								SwitchesWithMachines: []*apiv2.SwitchWithMachines{testresources.SwitchWithMachines1()},
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`
+flowchart LR
+    n0["fra-equ01"]
+    n1["rack-1"]
+    n2["leaf01<br/>no second leaf switch found in the rack"]
+    n3["leaf01"]
+    n4["Ethernet0"]
+    n5["id1"]
+    n6["Ethernet4<br/>no machine connected; SWITCH_PORT_STATUS_DOWN but should be SWITCH_PORT_STATUS_UP; bgp is BGP_STATE_IDLE"]
+    n0 --> n1
+    n1 --> n2
+    n2 --> n3
+    n3 --> n4
+    n3 --> n6
+    n4 --> n5
+    classDef issue stroke:#f00,color:#f00
+    class n2,n6 issue
+`),
+		},
+		{
+			Name:       "unsupported format",
+			CmdArgs:    []string{"admin", "switch", "topology", "--format", "svg"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{}),
+			WantErr:    fmt.Errorf(`unsupported topology format "svg", possible values: dot|mermaid|json`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_AdminSwitchCmd_Check(t *testing.T) {
+	tests := []*e2e.Test[adminv2.SwitchServiceListResponse, []*helpers.SwitchFinding]{
+		{
+			Name:    "consistent switch pair",
+			CmdArgs: []string{"admin", "switch", "check", "--partition", "fra-equ01"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceListRequest{
+							Query: &apiv2.SwitchQuery{
+								Partition: new("fra-equ01"),
+							},
+						},
+						WantResponse: func() connect.AnyResponse {
+							leaf02 := testresources.Switch2()
+							leaf02.MachineConnections = []*apiv2.MachineConnection{{MachineId: "id1", Nic: testresources.Nic1()}}
+
+							return connect.NewResponse(&adminv2.SwitchServiceListResponse{
+								Switches: []*apiv2.Switch{testresources.Switch1(), leaf02},
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            PARTITION  RACK  FINDING  SWITCHES  MACHINE  DETAILS
+            `),
+		},
+		{
+			Name:    "switch without pair",
+			CmdArgs: []string{"admin", "switch", "check"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceListRequest{
+							Query: &apiv2.SwitchQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceListResponse{
+								Switches: []*apiv2.Switch{testresources.Switch1()},
+							})
+						},
+					},
+				},
+			}),
+			WantErr: fmt.Errorf("found 1 problem(s) in the switch configuration"),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_AdminSwitchCmd_SSH(t *testing.T) {
+	port, privateKey := e2erootcmd.NewTestSSHServer(t)
+
+	tests := []*e2e.Test[adminv2.SwitchServiceGetResponse, *apiv2.Switch]{
+		{
+			Name:    "ssh with recording",
+			CmdArgs: []string{"admin", "switch", "ssh", testresources.Switch1().Id, "--identity", "/id_ed25519", "--ssh-port", fmt.Sprintf("%d", port), "--record", "/session.cast"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(fs *afero.Afero) {
+					require.NoError(t, fs.WriteFile("/id_ed25519", privateKey, 0600))
+				},
+				MockStdin: bytes.NewBufferString("show version\n"),
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							sw := testresources.Switch1()
+							sw.ManagementIp = "127.0.0.1"
+
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: sw,
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`show version`),
+		},
+		{
+			Name:    "missing identity",
+			CmdArgs: []string{"admin", "switch", "ssh", testresources.Switch1().Id, "--identity", "/id_ed25519"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &adminv2.SwitchServiceGetRequest{
+							Id: testresources.Switch1().Id,
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&adminv2.SwitchServiceGetResponse{
+								Switch: testresources.Switch1(),
+							})
+						},
+					},
+				},
+			}),
+			WantErr: fmt.Errorf("unable to read identity file: open /id_ed25519: file does not exist"),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
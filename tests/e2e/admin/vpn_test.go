@@ -1,6 +1,7 @@
 package admin_e2e
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -11,6 +12,8 @@ import (
 	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
 	"github.com/metal-stack/cli/tests/e2e/testresources"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -61,16 +64,24 @@ func Test_AdminVpnCmd_List(t *testing.T) {
 func Test_AdminVpnCmd_AuthKey(t *testing.T) {
 	tests := []*e2e.Test[any, any]{
 		{
-			Name:    "list",
-			CmdArgs: []string{"admin", "vpn", "auth-key", "--project", testresources.Project1().Uuid},
+			Name:    "auth-key",
+			CmdArgs: []string{"admin", "vpn", "auth-key", "--project", testresources.Project1().Uuid, "--reason", "firewall debugging", "--ticket", "OPS-1"},
 			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
 				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.UserServiceGetRequest{},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.UserServiceGetResponse{
+								User: testresources.User(),
+							})
+						},
+					},
 					{
 						WantRequest: &adminv2.VPNServiceAuthKeyRequest{
 							Project:   testresources.Project1().Uuid,
 							Ephemeral: true,
 							Expires:   durationpb.New(1 * time.Hour),
-							Reason:    "",
+							Reason:    "[OPS-1] firewall debugging",
 						},
 						WantResponse: func() connect.AnyResponse {
 							return connect.NewResponse(&adminv2.VPNServiceAuthKeyResponse{
@@ -91,6 +102,40 @@ ephemeral: true
 expires in: 1h0m0s
 			`),
 		},
+		{
+			Name:    "auth-key is not requested if the access cannot be recorded",
+			CmdArgs: []string{"admin", "vpn", "auth-key", "--project", testresources.Project1().Uuid, "--reason", "firewall debugging"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.UserServiceGetRequest{},
+						WantError:   connect.NewError(connect.CodeUnavailable, fmt.Errorf("user service unavailable")),
+					},
+				},
+			}),
+			WantErr: fmt.Errorf("unable to record access, current user cannot be determined: unavailable: user service unavailable"),
+		},
+		{
+			Name:       "auth-key without reason",
+			CmdArgs:    []string{"admin", "vpn", "auth-key", "--project", testresources.Project1().Uuid},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{}),
+			WantErr:    fmt.Errorf("a non-empty --reason is required for privileged access"),
+		},
+		{
+			Name:    "auth-key with reason not matching the pattern",
+			CmdArgs: []string{"admin", "vpn", "auth-key", "--project", testresources.Project1().Uuid, "--reason", "firewall debugging", "--config", "/config.yaml"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(fs *afero.Afero) {
+					require.NoError(t, fs.WriteFile("/config.yaml", []byte(`current-context: test
+contexts:
+- name: test
+  api-token: token
+  reason-pattern: ^\[OPS-\d+\]
+`), 0600))
+				},
+			}),
+			WantErr: fmt.Errorf(`reason "firewall debugging" does not match the pattern "^\\[OPS-\\d+\\]" required by the current context`),
+		},
 	}
 	for _, tt := range tests {
 		tt.TestCmd(t)
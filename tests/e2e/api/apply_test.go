@@ -0,0 +1,159 @@
+package api_e2e
+
+import (
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/api/go/client"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ApplyCmd(t *testing.T) {
+	tests := []*e2e.Test[any, any]{
+		{
+			Name:    "apply multiple kinds in dependency order",
+			CmdArgs: []string{"apply", "-f", e2e.InputFilePath},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, e2e.MustMarshalToMultiYAML(t, testresources.IP1(), testresources.Project1()), 0755))
+					},
+					ClientCalls: []client.ClientCall{
+						{
+							WantRequest: &apiv2.ProjectServiceCreateRequest{
+								Login:       testresources.Project1().Tenant,
+								Name:        testresources.Project1().Name,
+								Description: testresources.Project1().Description,
+							},
+							WantError: connect.NewError(connect.CodeAlreadyExists, fmt.Errorf("already exists")),
+						},
+						{
+							WantRequest: &apiv2.ProjectServiceUpdateRequest{
+								Project:     testresources.Project1().Uuid,
+								Name:        new(testresources.Project1().Name),
+								Description: new(testresources.Project1().Description),
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.ProjectServiceUpdateResponse{
+									Project: testresources.Project1(),
+								})
+							},
+						},
+						{
+							WantRequest: &apiv2.IPServiceCreateRequest{
+								Ip:          &testresources.IP1().Ip,
+								Project:     testresources.IP1().Project,
+								Network:     testresources.IP1().Network,
+								Name:        &testresources.IP1().Name,
+								Description: &testresources.IP1().Description,
+								Labels:      testresources.IP1().Meta.Labels,
+								Type:        &testresources.IP1().Type,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceCreateResponse{
+									Ip: testresources.IP1(),
+								})
+							},
+						},
+					},
+				},
+			),
+			WantTable: new(`
+            KIND     ID                                              ACTION   DURATION  ERROR
+            project  0d81bca7-73f6-4da3-8397-4a8c52a0c583            updated  0s
+            ip       1.1.1.1 (ce19a655-7933-4745-8f3e-9592b4a90488)  created  0s
+			`),
+		},
+		{
+			Name:    "apply with prune",
+			CmdArgs: []string{"apply", "-f", e2e.InputFilePath, "--prune", "--selector", "a=b", "--project", testresources.IP2().Project, "--skip-security-prompts"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, e2e.MustMarshal(t, testresources.IP1()), 0755))
+					},
+					ClientCalls: []client.ClientCall{
+						{
+							WantRequest: &apiv2.IPServiceCreateRequest{
+								Ip:          &testresources.IP1().Ip,
+								Project:     testresources.IP1().Project,
+								Network:     testresources.IP1().Network,
+								Name:        &testresources.IP1().Name,
+								Description: &testresources.IP1().Description,
+								Labels:      testresources.IP1().Meta.Labels,
+								Type:        &testresources.IP1().Type,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceCreateResponse{
+									Ip: testresources.IP1(),
+								})
+							},
+						},
+						{
+							WantRequest: &apiv2.IPServiceListRequest{
+								Project: testresources.IP2().Project,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceListResponse{
+									Ips: []*apiv2.IP{
+										testresources.IP1(),
+										testresources.IP2(),
+									},
+								})
+							},
+						},
+						{
+							WantRequest: &apiv2.IPServiceDeleteRequest{
+								Ip:      testresources.IP2().Ip,
+								Project: testresources.IP2().Project,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceDeleteResponse{
+									Ip: testresources.IP2(),
+								})
+							},
+						},
+					},
+				},
+			),
+			WantTable: new(`
+            KIND  ID                                              ACTION   DURATION  ERROR
+            ip    1.1.1.1 (ce19a655-7933-4745-8f3e-9592b4a90488)  created  0s
+            ip    4.3.2.1 (46bdfc45-9c8d-4268-b359-b40e3079d384)  deleted  0s
+			`),
+		},
+		{
+			Name:    "prune without selector",
+			CmdArgs: []string{"apply", "-f", e2e.InputFilePath, "--prune"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, e2e.MustMarshal(t, testresources.IP1()), 0755))
+					},
+				},
+			),
+			WantErr: fmt.Errorf("pruning requires a label selector, please provide --selector"),
+		},
+		{
+			Name:    "unsupported kind",
+			CmdArgs: []string{"apply", "-f", e2e.InputFilePath},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, []byte("kind: size\nid: c1-large-x86\n"), 0755))
+					},
+				},
+			),
+			WantErr: fmt.Errorf(`unsupported kind "size" in document /file.yaml[0]`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
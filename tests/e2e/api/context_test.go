@@ -0,0 +1,284 @@
+package api_e2e
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/api/go/client"
+	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
+	"github.com/metal-stack/cli/tests/e2e/testresources"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testJWT(expires time.Time) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc(fmt.Appendf(nil, `{"exp":%d}`, expires.Unix())) + ".signature"
+}
+
+func Test_ContextCmd_TokenRefresh(t *testing.T) {
+	expiringConfig := func(fs *afero.Afero) {
+		require.NoError(t, fs.WriteFile("/config.yaml", fmt.Appendf(nil, `current-context: test
+contexts:
+- name: test
+  api-token: %s
+  token-auto-refresh: true
+`, testJWT(e2e.TimeBubbleStartTime().Add(30*time.Minute))), 0600))
+	}
+
+	t.Run("token about to expire gets refreshed", func(t *testing.T) {
+		var (
+			fs        *afero.Afero
+			refreshed = testJWT(e2e.TimeBubbleStartTime().Add(24 * time.Hour))
+		)
+
+		tt := &e2e.Test[apiv2.HealthServiceGetResponse, *apiv2.Health]{
+			Name:    "refresh",
+			CmdArgs: []string{"health", "--config", "/config.yaml"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(f *afero.Afero) {
+					fs = f
+					expiringConfig(f)
+				},
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.TokenServiceRefreshRequest{},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.TokenServiceRefreshResponse{
+								Token:  testresources.Token1(),
+								Secret: refreshed,
+							})
+						},
+					},
+					{
+						WantRequest: &apiv2.HealthServiceGetRequest{},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.HealthServiceGetResponse{
+								Health: health1(),
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+			NAME  MESSAGE
+			✔  ipam  i am healthy
+			`),
+		}
+		tt.TestCmd(t)
+
+		raw, err := fs.ReadFile("/config.yaml")
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "api-token: "+refreshed)
+		assert.Contains(t, string(raw), "token-expiry: \"2000-01-02T00:00:00Z\"")
+	})
+
+	notExpiring := &e2e.Test[apiv2.HealthServiceGetResponse, *apiv2.Health]{
+		Name:    "token not about to expire is not refreshed",
+		CmdArgs: []string{"health", "--config", "/config.yaml"},
+		NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+			FsMocks: func(fs *afero.Afero) {
+				require.NoError(t, fs.WriteFile("/config.yaml", fmt.Appendf(nil, `current-context: test
+contexts:
+- name: test
+  api-token: %s
+  token-auto-refresh: true
+`, testJWT(e2e.TimeBubbleStartTime().Add(8*time.Hour))), 0600))
+			},
+			ClientCalls: []client.ClientCall{
+				{
+					WantRequest: &apiv2.HealthServiceGetRequest{},
+					WantResponse: func() connect.AnyResponse {
+						return connect.NewResponse(&apiv2.HealthServiceGetResponse{
+							Health: health1(),
+						})
+					},
+				},
+			},
+		}),
+		WantTable: new(`
+		NAME  MESSAGE
+		✔  ipam  i am healthy
+		`),
+	}
+	notExpiring.TestCmd(t)
+
+	tests := []*e2e.Test[any, any]{
+		{
+			Name:    "context commands do not refresh the token",
+			CmdArgs: []string{"context", "show-current", "--config", "/config.yaml"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: expiringConfig,
+			}),
+			WantDefault: new("test"),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+// fakeCredentialHelper stores a single credential next to the script, following the docker credential helper protocol
+const fakeCredentialHelper = `#!/bin/sh
+store="$(dirname "$0")/store"
+case "$1" in
+  store) cat > "$store" ;;
+  get) [ -f "$store" ] || { echo "credentials not found in native keychain"; exit 1; }; cat "$store" ;;
+  erase) rm -f "$store" ;;
+esac
+`
+
+func newFakeCredentialHelper(t *testing.T) (helper, store string) {
+	dir := t.TempDir()
+	helper = path.Join(dir, "credential-helper")
+	require.NoError(t, os.WriteFile(helper, []byte(fakeCredentialHelper), 0700)) //nolint:gosec
+	return helper, path.Join(dir, "store")
+}
+
+func Test_ContextCmd_CredentialHelper(t *testing.T) {
+	t.Run("add context with credential helper", func(t *testing.T) {
+		helper, store := newFakeCredentialHelper(t)
+
+		var fs *afero.Afero
+		tt := &e2e.Test[any, any]{
+			Name:    "add",
+			CmdArgs: []string{"context", "add", "test", "--config", "/config.yaml", "--api-token", "secret-token", "--credential-helper", helper},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(f *afero.Afero) {
+					fs = f
+				},
+			}),
+			WantDefault: new(`✔ added context "test"`),
+		}
+		tt.TestCmd(t)
+
+		stored, err := os.ReadFile(store)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ServerURL":"metalctlv2/test","Username":"metalctlv2","Secret":"secret-token"}`, string(stored))
+
+		raw, err := fs.ReadFile("/config.yaml")
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "secret-token")
+		assert.Contains(t, string(raw), "credential-store: helper")
+	})
+
+	t.Run("migrate plaintext tokens", func(t *testing.T) {
+		helper, store := newFakeCredentialHelper(t)
+
+		var fs *afero.Afero
+		tt := &e2e.Test[any, any]{
+			Name:    "migrate",
+			CmdArgs: []string{"context", "migrate-credentials", "--config", "/config.yaml", "--credential-helper", helper},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(f *afero.Afero) {
+					fs = f
+					require.NoError(t, f.WriteFile("/config.yaml", []byte(`current-context: test
+contexts:
+- name: test
+  api-token: plaintext-token
+`), 0600))
+				},
+			}),
+			WantDefault: new(`✔ moved token of context "test" to helper`),
+		}
+		tt.TestCmd(t)
+
+		stored, err := os.ReadFile(store)
+		require.NoError(t, err)
+		assert.Contains(t, string(stored), "plaintext-token")
+
+		raw, err := fs.ReadFile("/config.yaml")
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "plaintext-token")
+	})
+
+	t.Run("migrate between credential helpers", func(t *testing.T) {
+		oldHelper, oldStore := newFakeCredentialHelper(t)
+		newHelper, newStore := newFakeCredentialHelper(t)
+
+		failingHelper := path.Join(t.TempDir(), "credential-helper")
+		require.NoError(t, os.WriteFile(failingHelper, []byte("#!/bin/sh\necho \"keychain locked\"\nexit 1\n"), 0700)) //nolint:gosec
+
+		require.NoError(t, os.WriteFile(oldStore, []byte(`{"ServerURL":"metalctlv2/test","Username":"metalctlv2","Secret":"helper-token"}`), 0600))
+
+		fsMocks := func(f *afero.Afero) {
+			require.NoError(t, f.WriteFile("/config.yaml", fmt.Appendf(nil, `current-context: test
+contexts:
+- name: test
+  credential-store: helper
+  credential-helper: %s
+`, oldHelper), 0600))
+		}
+
+		failing := &e2e.Test[any, any]{
+			Name:    "failing",
+			CmdArgs: []string{"context", "migrate-credentials", "test", "--config", "/config.yaml", "--credential-helper", failingHelper},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: fsMocks,
+			}),
+			WantErr: fmt.Errorf(`unable to store token of context "test": credential helper %q failed to store: exit status 1 keychain locked`, failingHelper),
+		}
+		failing.TestCmd(t)
+
+		stored, err := os.ReadFile(oldStore)
+		require.NoError(t, err)
+		assert.Contains(t, string(stored), "helper-token", "the previous store must keep the token when the migration fails")
+
+		tt := &e2e.Test[any, any]{
+			Name:    "migrate",
+			CmdArgs: []string{"context", "migrate-credentials", "test", "--config", "/config.yaml", "--credential-helper", newHelper},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: fsMocks,
+			}),
+			WantDefault: new(`✔ moved token of context "test" to helper`),
+		}
+		tt.TestCmd(t)
+
+		stored, err = os.ReadFile(newStore)
+		require.NoError(t, err)
+		assert.Contains(t, string(stored), "helper-token")
+
+		assert.NoFileExists(t, oldStore)
+	})
+}
+
+func Test_ContextCmd_ColumnPresets(t *testing.T) {
+	var fs *afero.Afero
+	tt := &e2e.Test[any, any]{
+		Name: "update",
+		CmdArgs: []string{"context", "update", "test", "--config", "/config.yaml",
+			"--column-preset", "machine  list=ID:.uuid,RACK:.rack",
+			"--column-preset", "size list=",
+		},
+		NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+			FsMocks: func(f *afero.Afero) {
+				fs = f
+				require.NoError(t, f.WriteFile("/config.yaml", []byte(`current-context: test
+contexts:
+- name: test
+  api-token: token
+  column-presets:
+    size list: ID:.id
+    ip list: IP:.ip
+`), 0600))
+			},
+		}),
+		WantDefault: new(""),
+	}
+	tt.TestCmd(t)
+
+	raw, err := fs.ReadFile("/config.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `  column-presets:
+    ip list: IP:.ip
+    machine list: ID:.uuid,RACK:.rack
+`)
+}
@@ -7,6 +7,7 @@ import (
 	"connectrpc.com/connect"
 	"github.com/metal-stack/api/go/client"
 	apiv2 "github.com/metal-stack/api/go/metalstack/api/v2"
+	"github.com/metal-stack/cli/cmd/dryrun"
 	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
 	"github.com/metal-stack/cli/tests/e2e/testresources"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
@@ -404,3 +405,110 @@ func Test_IPCmd_Apply(t *testing.T) {
 		tt.TestCmd(t)
 	}
 }
+
+func Test_IPCmd_DryRun(t *testing.T) {
+	changedIP := func() *apiv2.IP {
+		ip := testresources.IP1()
+		ip.Description = "changed description"
+		return ip
+	}
+
+	tests := []*e2e.Test[apiv2.IPServiceGetResponse, *apiv2.IP]{
+		{
+			Name:    "update without changes",
+			CmdArgs: []string{"ip", "update", "-f", e2e.InputFilePath, "--dry-run"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, e2e.MustMarshal(t, testresources.IP1()), 0755))
+					},
+					ClientCalls: []client.ClientCall{
+						{
+							WantRequest: &apiv2.IPServiceGetRequest{
+								Ip:      testresources.IP1().Ip,
+								Project: testresources.IP1().Project,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceGetResponse{
+									Ip: testresources.IP1(),
+								})
+							},
+						},
+					},
+				},
+			),
+			WantDefault: new(`= ip "1.1.1.1 (ce19a655-7933-4745-8f3e-9592b4a90488)" is up to date`),
+		},
+		{
+			Name:    "update with changes",
+			CmdArgs: []string{"ip", "update", "-f", e2e.InputFilePath, "--dry-run"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, e2e.MustMarshal(t, changedIP()), 0755))
+					},
+					ClientCalls: []client.ClientCall{
+						{
+							WantRequest: &apiv2.IPServiceGetRequest{
+								Ip:      testresources.IP1().Ip,
+								Project: testresources.IP1().Project,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceGetResponse{
+									Ip: testresources.IP1(),
+								})
+							},
+						},
+					},
+				},
+			),
+			WantErr: dryrun.ErrChangesDetected,
+		},
+		{
+			Name:    "apply not existing",
+			CmdArgs: []string{"ip", "apply", "-f", e2e.InputFilePath, "--dry-run"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					FsMocks: func(fs *afero.Afero) {
+						require.NoError(t, fs.WriteFile(e2e.InputFilePath, e2e.MustMarshal(t, testresources.IP1()), 0755))
+					},
+					ClientCalls: []client.ClientCall{
+						{
+							WantRequest: &apiv2.IPServiceGetRequest{
+								Ip:      testresources.IP1().Ip,
+								Project: testresources.IP1().Project,
+							},
+							WantError: connect.NewError(connect.CodeNotFound, fmt.Errorf("not found")),
+						},
+					},
+				},
+			),
+			WantErr: dryrun.ErrChangesDetected,
+		},
+		{
+			Name:    "update from flags",
+			CmdArgs: []string{"ip", "update", testresources.IP1().Ip, "--project", testresources.IP1().Project, "--description", "changed description", "--dry-run"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t,
+				&e2erootcmd.TestConfig{
+					ClientCalls: []client.ClientCall{
+						{
+							WantRequest: &apiv2.IPServiceGetRequest{
+								Ip:      testresources.IP1().Ip,
+								Project: testresources.IP1().Project,
+							},
+							WantResponse: func() connect.AnyResponse {
+								return connect.NewResponse(&apiv2.IPServiceGetResponse{
+									Ip: testresources.IP1(),
+								})
+							},
+						},
+					},
+				},
+			),
+			WantErr: dryrun.ErrChangesDetected,
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
@@ -0,0 +1,108 @@
+package api_e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	e2erootcmd "github.com/metal-stack/cli/testing/e2e"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/e2e"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const loginTestConfig = `current-context: test
+contexts:
+- name: test
+  api-token: old-token
+  default-project: 0d81bca7-73f6-4da3-8397-4a8c52a0c583
+  provider: openid-connect
+`
+
+func Test_LoginCmd(t *testing.T) {
+	var polls atomic.Int32
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/auth/openid-connect/device":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"device_code":      "device-code",
+				"user_code":        "ABCD-EFGH",
+				"verification_uri": "http://" + r.Host + "/device",
+				"expires_in":       300,
+				"interval":         1,
+			})
+		case "/auth/openid-connect/device/token":
+			if r.FormValue("device_code") != "device-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+				return
+			}
+
+			if polls.Add(1) < 2 {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]any{"error": "authorization_pending"})
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(map[string]any{"token": "device-token"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer authServer.Close()
+
+	fsMocks := func(fs *afero.Afero) {
+		require.NoError(t, fs.WriteFile("/config.yaml", []byte(loginTestConfig), 0600))
+	}
+
+	tests := []*e2e.Test[any, any]{
+		{
+			Name:    "login with pasted token",
+			CmdArgs: []string{"login", "--config", "/config.yaml", "--no-browser"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks:   fsMocks,
+				MockStdin: bytes.NewBufferString("pasted-token\n"),
+			}),
+			WantDefault: new(`✔ login successful! Updated and activated context "test"`),
+		},
+		{
+			Name:    "login with pasted callback url",
+			CmdArgs: []string{"login", "--config", "/config.yaml", "--no-browser"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks:   fsMocks,
+				MockStdin: bytes.NewBufferString("http://localhost:12345/callback?token=pasted-token\n"),
+			}),
+			WantDefault: new(`✔ login successful! Updated and activated context "test"`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+
+	t.Run("login with device flow", func(t *testing.T) {
+		var fs *afero.Afero
+		tt := &e2e.Test[any, any]{
+			Name:    "login with device flow",
+			CmdArgs: []string{"login", "--config", "/config.yaml", "--device-flow", "--api-url", authServer.URL},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(f *afero.Afero) {
+					fs = f
+					fsMocks(f)
+				},
+			}),
+			WantDefault: new(`✔ login successful! Updated and activated context "test"`),
+		}
+		tt.TestCmd(t)
+
+		raw, err := fs.ReadFile("/config.yaml")
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), "api-token: device-token")
+		assert.NotContains(t, string(raw), "old-token")
+	})
+}
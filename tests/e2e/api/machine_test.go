@@ -59,6 +59,236 @@ func Test_MachineCmd_List(t *testing.T) {
             | 673fc473-63ca-4ea4-b9dd-b45cb2127a6fd | 🛡 | Phoned Home | 1m   | 1m  | machine-2 | f3b4e6a1-2c8d-4e5f-a7b9-1d3e5f7a9b0c | v1-medium-x86 | Ubuntu 24.04 | partition-2 | rack-1 |
 			`),
 		},
+		{
+			Name:    "list as csv",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "-o", "csv"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`
+ID,Last Event,When,Age,Description,Name,Hostname,Project,Ips,Size,Image,Partition,Rack,Started,Tags,State
+673fc473-63ca-4ea4-b9dd-b45cb2127a6fd,Phoned Home,1m,1m,machine 2,machine-2,machine-2,f3b4e6a1-2c8d-4e5f-a7b9-1d3e5f7a9b0c,"4.5.6.7
+192.1.1.1",v1-medium-x86,Ubuntu 24.04,partition-2,rack-1,1999-12-31T23:59:00Z,c=d,available
+			`),
+		},
+		{
+			Name:    "list as tsv with selected columns",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "-o", "tsv", "--no-headers", "--columns", "hostname,id,last-event"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new("machine-2\t673fc473-63ca-4ea4-b9dd-b45cb2127a6fd\tPhoned Home"),
+		},
+		{
+			Name:    "list with selected columns",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "--columns", "id,hostname,partition"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantTable: new(`
+            ID                                     HOSTNAME   PARTITION
+            673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  machine-2  partition-2
+			`),
+			WantWideTable: new(`
+            ID                                     HOSTNAME   PARTITION
+            673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  machine-2  partition-2
+			`),
+		},
+		{
+			Name:    "list with unknown column",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "-o", "csv", "--columns", "id,foo"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantErr: fmt.Errorf(`unknown column "foo", available columns are: ID, Last Event, When, Age, Description, Name, Hostname, Project, Ips, Size, Image, Partition, Rack, Started, Tags, State`),
+		},
+		{
+			Name:    "list with custom columns",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "--no-headers", "-o", "custom-columns=ID:.uuid,RACK:.rack,SIZE:.size.id,IPS:.allocation.networks.ips"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`
+673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  rack-1  v1-medium-x86  4.5.6.7,192.1.1.1
+			`),
+		},
+		{
+			Name:    "list with column preset of the context",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "--no-headers", "--config", "/config.yaml"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				FsMocks: func(fs *afero.Afero) {
+					require.NoError(t, fs.WriteFile("/config.yaml", []byte(`current-context: test
+contexts:
+- name: test
+  api-token: token
+  column-presets:
+    machine list: HOSTNAME:.allocation.hostname,STATE:.status.condition.state,LABELS:.meta.labels.labels
+`), 0600))
+				},
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantDefault: new(`
+machine-2  MACHINE_STATE_AVAILABLE  c=d
+			`),
+		},
+		{
+			Name:    "list with unknown custom column field",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "-o", "custom-columns=ID:.uuid,FOO:.foo"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: []client.ClientCall{
+					{
+						WantRequest: &apiv2.MachineServiceListRequest{
+							Project: testresources.Project2().Uuid,
+							Query:   &apiv2.MachineQuery{},
+						},
+						WantResponse: func() connect.AnyResponse {
+							return connect.NewResponse(&apiv2.MachineServiceListResponse{
+								Machines: []*apiv2.Machine{
+									testresources.Machine2(),
+								},
+							})
+						},
+					},
+				},
+			}),
+			WantErr: fmt.Errorf(`unable to evaluate field path ".foo": field "foo" does not exist in metalstack.api.v2.Machine`),
+		},
+	}
+	for _, tt := range tests {
+		tt.TestCmd(t)
+	}
+}
+
+func Test_MachineCmd_ListFilter(t *testing.T) {
+	clientCalls := []client.ClientCall{
+		{
+			WantRequest: &apiv2.MachineServiceListRequest{
+				Project: testresources.Project2().Uuid,
+				Query:   &apiv2.MachineQuery{},
+			},
+			WantResponse: func() connect.AnyResponse {
+				return connect.NewResponse(&apiv2.MachineServiceListResponse{
+					Machines: []*apiv2.Machine{
+						testresources.Machine1(),
+						testresources.Machine2(),
+					},
+				})
+			},
+		},
+	}
+
+	tests := []*e2e.Test[apiv2.MachineServiceListResponse, apiv2.Machine]{
+		{
+			Name:    "list with filter",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "--filter", `has(allocation) && recent_provisioning_events.last_event_time > now - duration("1h")`},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: clientCalls,
+			}),
+			WantTable: new(`
+            ID                                        LAST EVENT   WHEN  AGE  HOSTNAME   PROJECT                               SIZE           IMAGE         PARTITION    RACK
+            673fc473-63ca-4ea4-b9dd-b45cb2127a6fd  🛡  Phoned Home  1m    1m   machine-2  f3b4e6a1-2c8d-4e5f-a7b9-1d3e5f7a9b0c  v1-medium-x86  Ubuntu 24.04  partition-2  rack-1
+			`),
+		},
+		{
+			Name:    "list as jsonpath",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "-o", `jsonpath={.uuid}{"\t"}{.allocation.hostname}{"\t"}{.meta.labels.labels}`},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: clientCalls,
+			}),
+			WantDefault: new("5fa2bbe1-407c-4142-92d5-e4419daf9646\t\ta=b\n673fc473-63ca-4ea4-b9dd-b45cb2127a6fd\tmachine-2\tc=d"),
+		},
+		{
+			Name:    "list with invalid filter",
+			CmdArgs: []string{"machine", "list", "--project", testresources.Project2().Uuid, "--filter", "rack"},
+			NewRootCmd: e2erootcmd.NewRootCmd(t, &e2erootcmd.TestConfig{
+				ClientCalls: clientCalls,
+			}),
+			WantErr: fmt.Errorf("filter expression must evaluate to a bool, got string"),
+		},
 	}
 	for _, tt := range tests {
 		tt.TestCmd(t)